@@ -0,0 +1,799 @@
+package config
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Compatibility modes of responses, legacy omits `__type__` and `__version__` discriminators.
+const (
+	CompatCurrent string = "current"
+	CompatLegacy  string = "legacy"
+)
+
+// Output forms of DateTime in responses, both of them are accepted on input.
+const (
+	DateTimeFormatObject  string = "object"
+	DateTimeFormatISO8601 string = "iso8601"
+)
+
+const (
+	DefaultAllowedSources      string        = ""
+	DefaultAttachmentTypes     string        = "application/pdf,image/jpeg,image/png,text/plain"
+	DefaultDatabaseFile        string        = "file::memory:?cache=shared"
+	DefaultDBMaxConcurrency    int           = 1
+	DefaultDBStartupRetries    int           = 10
+	DefaultGzip                bool          = true
+	DefaultHandlerTimeout      time.Duration = 5 * time.Second
+	DefaultInstanceName        string        = "eventshub"
+	DefaultJWTLeeway           time.Duration = 30 * time.Second
+	DefaultLoginLockout        time.Duration = 15 * time.Minute
+	DefaultLoginMaxFailures    int           = 5
+	DefaultLoginMaxIPFailures  int           = 20
+	DefaultMaxImportBytes      int           = 10 << 20
+	DefaultMaxAttachmentBytes  int           = 10 << 20
+	DefaultMaxTokenBytes       int           = 4 << 10
+	DefaultMaxRangeDays        int           = 366
+	DefaultMaxStreamConns      int           = 100
+	DefaultMaxTextLength       int           = 255
+	DefaultMinFreeBytes        int           = 100 << 20
+	DefaultPathPrefix          string        = "/api/v1"
+	DefaultPasswordAlgo        string        = "bcrypt"
+	DefaultPasswordMinLength   int           = 8
+	DefaultRecoverPanics       bool          = true
+	DefaultReminderDays        int           = 1
+	DefaultReminderInterval    time.Duration = 1 * time.Minute
+	DefaultSlowQuery           time.Duration = 500 * time.Millisecond
+	DefaultSMTPPort            string        = "587"
+	DefaultSQLiteBusyTimeout   time.Duration = 5 * time.Second
+	DefaultSQLiteBusyRetries   int           = 3
+	DefaultSQLiteBusyBackoff   time.Duration = 20 * time.Millisecond
+	DefaultTimeZone            string        = "Europe/Warsaw"
+	DefaultUUIDPrefixMinLength int           = 4
+	DefaultWriteTimeout        time.Duration = 30 * time.Second
+)
+
+// Config holds every setting the application reads from the environment.
+// It is populated once by Load (or LoadClient) and passed explicitly to
+// the components that need it, so nothing else should call os.Getenv.
+type Config struct {
+	Host                string
+	Port                string
+	GRPCPort            string
+	AdminUsername       string
+	AdminPassword       string
+	AdminHash           string
+	TokenSecret         string
+	CertificatePath     string
+	SigningKeyPath      string
+	CACertificatePath   string
+	DeadlyPackage       string
+	EncryptAddress      bool
+	EventTTLDays        int
+	Features            Features
+	FieldEncryptionKey  []byte
+	TimeZone            string
+	TrustedProxies      []netip.Prefix
+	AllowedSources      []string
+	AllowInsecure       bool
+	AttachmentsDir      string
+	AttachmentTypes     []string
+	Compat              string
+	DatabaseFile        string
+	DateTimeFormat      string
+	DBMaxConcurrency    int
+	DBStartupRetries    int
+	Gzip                bool
+	HandlerTimeout      time.Duration
+	ImportAllowedNets   []netip.Prefix
+	ImportDedup         bool
+	InstanceName        string
+	JWTLeeway           time.Duration
+	LoginLockout        time.Duration
+	LoginMaxFailures    int
+	LoginMaxIPFailures  int
+	MaxEventsPerUser    int
+	MaxImportBytes      int
+	MaxAttachmentBytes  int
+	MaxTokenBytes       int
+	MaxRangeDays        int
+	MaxStreamConns      int
+	MaxTitleLength      int
+	MaxAddressLength    int
+	MaxInfoLength       int
+	MinFreeBytes        int
+	PasswordAlgo        string
+	PasswordMinLength   int
+	PathPrefix          string
+	HealthPathPrefix    string
+	QueryBudget         int
+	PasswordMixedClass  bool
+	RecoverPanics       bool
+	ReminderDays        int
+	ReminderInterval    time.Duration
+	SlowQuery           time.Duration
+	SMTP                SMTP
+	SQLiteBusyTimeout   time.Duration
+	SQLiteBusyRetries   int
+	SQLiteBusyBackoff   time.Duration
+	SQLiteWAL           bool
+	UUIDPrefixMinLength int
+	WriteTimeout        time.Duration
+}
+
+// SMTP holds settings of the mail server reminders are sent through.
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Enabled reports whether email reminders should be delivered.
+func (s SMTP) Enabled() bool {
+	return s.Host != ""
+}
+
+// Default returns a Config with all defaults applied and no environment read.
+func Default() *Config {
+	return &Config{
+		TimeZone:            DefaultTimeZone,
+		Compat:              CompatCurrent,
+		AllowedSources:      ParseSources(DefaultAllowedSources),
+		DatabaseFile:        DefaultDatabaseFile,
+		DateTimeFormat:      DateTimeFormatObject,
+		DBMaxConcurrency:    DefaultDBMaxConcurrency,
+		DBStartupRetries:    DefaultDBStartupRetries,
+		Gzip:                DefaultGzip,
+		HandlerTimeout:      DefaultHandlerTimeout,
+		Features:            ParseFeatures(DefaultFeatures),
+		InstanceName:        DefaultInstanceName,
+		JWTLeeway:           DefaultJWTLeeway,
+		LoginLockout:        DefaultLoginLockout,
+		LoginMaxFailures:    DefaultLoginMaxFailures,
+		LoginMaxIPFailures:  DefaultLoginMaxIPFailures,
+		MaxImportBytes:      DefaultMaxImportBytes,
+		MaxAttachmentBytes:  DefaultMaxAttachmentBytes,
+		AttachmentTypes:     ParseMediaTypes(DefaultAttachmentTypes),
+		MaxTokenBytes:       DefaultMaxTokenBytes,
+		MaxRangeDays:        DefaultMaxRangeDays,
+		MaxStreamConns:      DefaultMaxStreamConns,
+		MaxTitleLength:      DefaultMaxTextLength,
+		MaxAddressLength:    DefaultMaxTextLength,
+		MaxInfoLength:       DefaultMaxTextLength,
+		MinFreeBytes:        DefaultMinFreeBytes,
+		PasswordAlgo:        DefaultPasswordAlgo,
+		PasswordMinLength:   DefaultPasswordMinLength,
+		PathPrefix:          DefaultPathPrefix,
+		HealthPathPrefix:    DefaultPathPrefix,
+		RecoverPanics:       DefaultRecoverPanics,
+		ReminderDays:        DefaultReminderDays,
+		ReminderInterval:    DefaultReminderInterval,
+		SlowQuery:           DefaultSlowQuery,
+		SMTP:                SMTP{Port: DefaultSMTPPort},
+		SQLiteBusyTimeout:   DefaultSQLiteBusyTimeout,
+		SQLiteBusyRetries:   DefaultSQLiteBusyRetries,
+		SQLiteBusyBackoff:   DefaultSQLiteBusyBackoff,
+		UUIDPrefixMinLength: DefaultUUIDPrefixMinLength,
+		WriteTimeout:        DefaultWriteTimeout,
+	}
+}
+
+// Load reads the server configuration from the environment, applies
+// defaults and validates that all settings required by the server are present.
+func Load() (*Config, error) {
+	cfg, err := fromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.validateServer(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadClient reads the configuration used by auxiliary tools talking to
+// the server (e.g. xmlparser) and validates the settings they require.
+func LoadClient() (*Config, error) {
+	cfg, err := fromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.validateClient(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// ParseSources reads a comma separated list of event sources. Sources are
+// uppercased, as events store them, and empty entries are skipped.
+func ParseSources(list string) []string {
+	var sources []string
+
+	for _, source := range strings.Split(list, ",") {
+		if source = strings.ToUpper(strings.TrimSpace(source)); source != "" {
+			sources = append(sources, source)
+		}
+	}
+
+	return sources
+}
+
+// ParseMediaTypes reads a comma separated list of media types. Types are
+// lowercased, as they are case-insensitive, and empty entries are skipped.
+func ParseMediaTypes(list string) []string {
+	var types []string
+
+	for _, mediaType := range strings.Split(list, ",") {
+		if mediaType = strings.ToLower(strings.TrimSpace(mediaType)); mediaType != "" {
+			types = append(types, mediaType)
+		}
+	}
+
+	return types
+}
+
+// AttachmentTypeAllowed reports whether attachments may be of the media type.
+// Unlike sources, an empty allowlist allows no attachment at all.
+func (cfg *Config) AttachmentTypeAllowed(mediaType string) bool {
+	for _, allowed := range cfg.AttachmentTypes {
+		if strings.EqualFold(allowed, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SourceAllowed reports whether events may carry the source. Any source is
+// allowed when the allowlist is empty, event without source always is.
+func (cfg *Config) SourceAllowed(source string) bool {
+	if len(cfg.AllowedSources) == 0 || source == "" {
+		return true
+	}
+
+	for _, allowed := range cfg.AllowedSources {
+		if strings.EqualFold(allowed, source) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Location returns the time zone used for event date conversions.
+func (cfg *Config) Location() (*time.Location, error) {
+	return time.LoadLocation(cfg.TimeZone)
+}
+
+func fromEnv() (*Config, error) {
+	/* Read all known variables and apply defaults for the missing optional ones. */
+	var err error
+
+	cfg := Default()
+
+	cfg.Host = os.Getenv("GOCALENDAR_HOST")
+	cfg.Port = os.Getenv("GOCALENDAR_PORT")
+	cfg.GRPCPort = os.Getenv("GOCALENDAR_GRPC_PORT")
+	cfg.AdminUsername = os.Getenv("GOCALENDAR_ADMIN_USERNAME")
+	cfg.AdminPassword = os.Getenv("GOCALENDAR_ADMIN_PASSWORD")
+	cfg.AdminHash = os.Getenv("GOCALENDAR_ADMIN_HASH")
+	cfg.TokenSecret = os.Getenv("GOCALENDAR_TOKEN_SECRET")
+	cfg.CertificatePath = os.Getenv("GOCALENDAR_OPENSSL_CALENDAR_CERTIFICATE")
+	cfg.SigningKeyPath = os.Getenv("GOCALENDAR_OPENSSL_CALENDAR_SIGNING_KEY")
+	cfg.CACertificatePath = os.Getenv("GOCALENDAR_OPENSSL_CA_CERTIFICATE")
+	cfg.DeadlyPackage = os.Getenv("GOCALENDAR_DEADLY_PACKAGE")
+	cfg.SMTP.Host = os.Getenv("GOCALENDAR_SMTP_HOST")
+	cfg.SMTP.Username = os.Getenv("GOCALENDAR_SMTP_USERNAME")
+	cfg.SMTP.Password = os.Getenv("GOCALENDAR_SMTP_PASSWORD")
+	cfg.SMTP.From = os.Getenv("GOCALENDAR_SMTP_FROM")
+
+	if port := os.Getenv("GOCALENDAR_SMTP_PORT"); port != "" {
+		cfg.SMTP.Port = port
+	}
+
+	if key := os.Getenv("GOCALENDAR_FIELD_ENCRYPTION_KEY"); key != "" {
+		if cfg.FieldEncryptionKey, err = base64.StdEncoding.DecodeString(key); err != nil {
+			return nil, fmt.Errorf("failed to parse GOCALENDAR_FIELD_ENCRYPTION_KEY: %w", err)
+		}
+	}
+
+	if cfg.EncryptAddress, err = boolFromEnv("GOCALENDAR_ENCRYPT_ADDRESS", cfg.EncryptAddress); err != nil {
+		return nil, err
+	}
+
+	/* Set but empty variable disables all optional features */
+	if features, ok := os.LookupEnv("GOCALENDAR_FEATURES"); ok {
+		cfg.Features = ParseFeatures(features)
+	}
+
+	/* Empty list, also the default one, accepts any source */
+	if sources, ok := os.LookupEnv("GOCALENDAR_ALLOWED_SOURCES"); ok {
+		cfg.AllowedSources = ParseSources(sources)
+	}
+
+	/* Attachments are disabled unless a directory to store them in is set */
+	cfg.AttachmentsDir = os.Getenv("GOCALENDAR_ATTACHMENTS_DIR")
+
+	if types, ok := os.LookupEnv("GOCALENDAR_ATTACHMENT_TYPES"); ok {
+		cfg.AttachmentTypes = ParseMediaTypes(types)
+	}
+
+	/* Status endpoints follow the API prefix unless they are located independently */
+	if prefix, ok := os.LookupEnv("GOCALENDAR_PATH_PREFIX"); ok {
+		cfg.PathPrefix = normalizePathPrefix(prefix)
+		cfg.HealthPathPrefix = cfg.PathPrefix
+	}
+
+	if prefix, ok := os.LookupEnv("GOCALENDAR_HEALTH_PATH_PREFIX"); ok {
+		cfg.HealthPathPrefix = normalizePathPrefix(prefix)
+	}
+
+	if compat := os.Getenv("GOCALENDAR_COMPAT"); compat != "" {
+		cfg.Compat = strings.ToLower(strings.TrimSpace(compat))
+	}
+
+	if format := os.Getenv("GOCALENDAR_DATETIME_FORMAT"); format != "" {
+		cfg.DateTimeFormat = strings.ToLower(strings.TrimSpace(format))
+	}
+
+	if timeZone := os.Getenv("GOCALENDAR_TIMEZONE"); timeZone != "" {
+		cfg.TimeZone = timeZone
+	}
+
+	if passwordAlgo := os.Getenv("GOCALENDAR_PASSWORD_ALGO"); passwordAlgo != "" {
+		cfg.PasswordAlgo = strings.ToLower(strings.TrimSpace(passwordAlgo))
+	}
+
+	if databaseFile := os.Getenv("GOCALENDAR_DATABASE"); databaseFile != "" {
+		cfg.DatabaseFile = databaseFile
+	}
+
+	if instanceName := os.Getenv("GOCALENDAR_INSTANCE_NAME"); instanceName != "" {
+		cfg.InstanceName = instanceName
+	} else if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		cfg.InstanceName = hostname
+	}
+
+	if cfg.DBMaxConcurrency, err = intFromEnv("GOCALENDAR_DB_MAX_CONCURRENCY", cfg.DBMaxConcurrency); err != nil {
+		return nil, err
+	}
+
+	if cfg.DBStartupRetries, err = intFromEnv("GOCALENDAR_DB_STARTUP_RETRIES", cfg.DBStartupRetries); err != nil {
+		return nil, err
+	}
+
+	if cfg.EventTTLDays, err = intFromEnv("GOCALENDAR_EVENT_TTL_DAYS", cfg.EventTTLDays); err != nil {
+		return nil, err
+	}
+
+	if cfg.ImportDedup, err = boolFromEnv("GOCALENDAR_IMPORT_DEDUP", cfg.ImportDedup); err != nil {
+		return nil, err
+	}
+
+	if cfg.JWTLeeway, err = durationFromEnv("GOCALENDAR_JWT_LEEWAY", cfg.JWTLeeway); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxTokenBytes, err = intFromEnv("GOCALENDAR_MAX_TOKEN_BYTES", cfg.MaxTokenBytes); err != nil {
+		return nil, err
+	}
+
+	if cfg.QueryBudget, err = intFromEnv("GOCALENDAR_QUERY_BUDGET", cfg.QueryBudget); err != nil {
+		return nil, err
+	}
+
+	if cfg.HandlerTimeout, err = durationFromEnv("GOCALENDAR_HANDLER_TIMEOUT", cfg.HandlerTimeout); err != nil {
+		return nil, err
+	}
+
+	if cfg.WriteTimeout, err = durationFromEnv("GOCALENDAR_WRITE_TIMEOUT", cfg.WriteTimeout); err != nil {
+		return nil, err
+	}
+
+	if cfg.SQLiteWAL, err = boolFromEnv("GOCALENDAR_SQLITE_WAL", cfg.SQLiteWAL); err != nil {
+		return nil, err
+	}
+
+	if cfg.Gzip, err = boolFromEnv("GOCALENDAR_GZIP", cfg.Gzip); err != nil {
+		return nil, err
+	}
+
+	if cfg.SQLiteBusyTimeout, err = durationFromEnv("GOCALENDAR_SQLITE_BUSY_TIMEOUT", cfg.SQLiteBusyTimeout); err != nil {
+		return nil, err
+	}
+
+	if cfg.SQLiteBusyRetries, err = intFromEnv("GOCALENDAR_SQLITE_BUSY_RETRIES", cfg.SQLiteBusyRetries); err != nil {
+		return nil, err
+	}
+
+	if cfg.SQLiteBusyBackoff, err = durationFromEnv("GOCALENDAR_SQLITE_BUSY_BACKOFF", cfg.SQLiteBusyBackoff); err != nil {
+		return nil, err
+	}
+
+	if cfg.ReminderInterval, err = durationFromEnv("GOCALENDAR_REMINDER_INTERVAL", cfg.ReminderInterval); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxEventsPerUser, err = intFromEnv("GOCALENDAR_MAX_EVENTS_PER_USER", cfg.MaxEventsPerUser); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxRangeDays, err = intFromEnv("GOCALENDAR_MAX_RANGE_DAYS", cfg.MaxRangeDays); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxStreamConns, err = intFromEnv("GOCALENDAR_MAX_STREAM_CONNS", cfg.MaxStreamConns); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxTitleLength, err = intFromEnv("GOCALENDAR_MAX_TITLE_LENGTH", cfg.MaxTitleLength); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxAddressLength, err = intFromEnv("GOCALENDAR_MAX_ADDRESS_LENGTH", cfg.MaxAddressLength); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxInfoLength, err = intFromEnv("GOCALENDAR_MAX_INFO_LENGTH", cfg.MaxInfoLength); err != nil {
+		return nil, err
+	}
+
+	if cfg.ReminderDays, err = intFromEnv("GOCALENDAR_DEFAULT_REMINDER_DAYS", cfg.ReminderDays); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxImportBytes, err = intFromEnv("GOCALENDAR_MAX_IMPORT_BYTES", cfg.MaxImportBytes); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxAttachmentBytes, err = intFromEnv("GOCALENDAR_MAX_ATTACHMENT_BYTES", cfg.MaxAttachmentBytes); err != nil {
+		return nil, err
+	}
+
+	if cfg.LoginMaxFailures, err = intFromEnv("GOCALENDAR_LOGIN_MAX_FAILURES", cfg.LoginMaxFailures); err != nil {
+		return nil, err
+	}
+
+	if cfg.LoginMaxIPFailures, err = intFromEnv("GOCALENDAR_LOGIN_MAX_IP_FAILURES", cfg.LoginMaxIPFailures); err != nil {
+		return nil, err
+	}
+
+	if cfg.LoginLockout, err = durationFromEnv("GOCALENDAR_LOGIN_LOCKOUT", cfg.LoginLockout); err != nil {
+		return nil, err
+	}
+
+	slowQueryMS, err := intFromEnv("GOCALENDAR_SLOW_QUERY_MS", int(cfg.SlowQuery/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.SlowQuery = time.Duration(slowQueryMS) * time.Millisecond
+
+	if cfg.MinFreeBytes, err = intFromEnv("GOCALENDAR_MIN_FREE_BYTES", cfg.MinFreeBytes); err != nil {
+		return nil, err
+	}
+
+	if cfg.PasswordMinLength, err = intFromEnv("GOCALENDAR_PASSWORD_MIN_LENGTH", cfg.PasswordMinLength); err != nil {
+		return nil, err
+	}
+
+	if cfg.PasswordMixedClass, err = boolFromEnv("GOCALENDAR_PASSWORD_MIXED_CLASS", cfg.PasswordMixedClass); err != nil {
+		return nil, err
+	}
+
+	if cfg.RecoverPanics, err = boolFromEnv("GOCALENDAR_RECOVER_PANICS", cfg.RecoverPanics); err != nil {
+		return nil, err
+	}
+
+	if cfg.AllowInsecure, err = boolFromEnv("GOCALENDAR_ALLOW_INSECURE", cfg.AllowInsecure); err != nil {
+		return nil, err
+	}
+
+	if cfg.TrustedProxies, err = prefixesFromEnv("GOCALENDAR_TRUSTED_PROXIES"); err != nil {
+		return nil, err
+	}
+
+	if cfg.ImportAllowedNets, err = prefixesFromEnv("GOCALENDAR_IMPORT_ALLOWED_NETS"); err != nil {
+		return nil, err
+	}
+
+	if cfg.UUIDPrefixMinLength, err = intFromEnv("GOCALENDAR_UUID_PREFIX_MIN_LENGTH", cfg.UUIDPrefixMinLength); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func normalizePathPrefix(prefix string) string {
+	/* Make prefix start with a slash and drop the trailing ones, `/` and empty mean root */
+	prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		return ""
+	}
+
+	return "/" + prefix
+}
+
+func intFromEnv(name string, fallback int) (int, error) {
+	/* Read integer variable, return fallback if it is not set. */
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	return result, nil
+}
+
+func boolFromEnv(name string, fallback bool) (bool, error) {
+	/* Read boolean variable, return fallback if it is not set. */
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	return result, nil
+}
+
+func durationFromEnv(name string, fallback time.Duration) (time.Duration, error) {
+	/* Read duration variable like `30s` or `1m`, return fallback if it is not set. */
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	result, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	return result, nil
+}
+
+func prefixesFromEnv(name string) ([]netip.Prefix, error) {
+	/* Read comma separated list of CIDR prefixes, single addresses are accepted as well. */
+	var prefixes []netip.Prefix
+
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		value = strings.TrimSpace(value)
+
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+			}
+
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+func (cfg *Config) validateServer() error {
+	/* Check that all settings required to run the server are present. */
+	if cfg.Host == "" {
+		return errors.New("failed to obtain host")
+	}
+
+	if cfg.Port == "" {
+		return errors.New("failed to obtain port")
+	}
+
+	/* gRPC is served on its own listener, it can not share the REST one */
+	if cfg.GRPCPort != "" {
+		if port, err := strconv.Atoi(cfg.GRPCPort); err != nil || port < 0 || port > 65535 {
+			return fmt.Errorf("invalid gRPC port %q", cfg.GRPCPort)
+		}
+
+		if cfg.GRPCPort == cfg.Port {
+			return errors.New("gRPC port must differ from port")
+		}
+	}
+
+	if cfg.AdminUsername == "" {
+		return errors.New("failed to obtain adminUsername")
+	}
+
+	if cfg.AdminHash == "" {
+		return errors.New("failed to obtain adminHash")
+	}
+
+	if cfg.TokenSecret == "" {
+		return errors.New("failed to obtain token secret")
+	}
+
+	if _, err := cfg.Location(); err != nil {
+		return err
+	}
+
+	if cfg.DBMaxConcurrency < 1 {
+		return errors.New("database max concurrency must be positive")
+	}
+
+	if cfg.DBStartupRetries < 0 {
+		return errors.New("database startup retries must not be negative")
+	}
+
+	if cfg.JWTLeeway < 0 {
+		return errors.New("JWT leeway must not be negative")
+	}
+
+	if cfg.EventTTLDays < 0 {
+		return errors.New("event TTL days must not be negative")
+	}
+
+	if cfg.HandlerTimeout <= 0 || cfg.WriteTimeout <= 0 {
+		return errors.New("handler and write timeouts must be positive")
+	}
+
+	/* Socket deadline shorter than processing one would drop the connection before 503 is sent */
+	if cfg.WriteTimeout < cfg.HandlerTimeout {
+		return errors.New("write timeout must not be shorter than handler timeout")
+	}
+
+	if cfg.SMTP.Enabled() && cfg.SMTP.From == "" {
+		return errors.New("failed to obtain SMTP sender address")
+	}
+
+	/* AES-128, AES-192 or AES-256 */
+	switch len(cfg.FieldEncryptionKey) {
+	case 0, 16, 24, 32:
+	default:
+		return errors.New("field encryption key must be 16, 24 or 32 bytes long")
+	}
+
+	if cfg.EncryptAddress && len(cfg.FieldEncryptionKey) == 0 {
+		return errors.New("address encryption requires field encryption key")
+	}
+
+	if cfg.ReminderInterval <= 0 {
+		return errors.New("reminder interval must be positive")
+	}
+
+	if cfg.MaxEventsPerUser < 0 {
+		return errors.New("maximum events per user must not be negative")
+	}
+
+	if cfg.MaxRangeDays < 1 {
+		return errors.New("maximum range days must be positive")
+	}
+
+	if cfg.MaxStreamConns < 0 {
+		return errors.New("maximum stream connections must not be negative")
+	}
+
+	if cfg.MaxTitleLength < 1 || cfg.MaxAddressLength < 1 || cfg.MaxInfoLength < 1 {
+		return errors.New("maximum title, address and info lengths must be positive")
+	}
+
+	if cfg.Compat != CompatCurrent && cfg.Compat != CompatLegacy {
+		return fmt.Errorf("unknown compatibility mode %q, expected %s or %s", cfg.Compat, CompatCurrent, CompatLegacy)
+	}
+
+	if cfg.DateTimeFormat != DateTimeFormatObject && cfg.DateTimeFormat != DateTimeFormatISO8601 {
+		return fmt.Errorf("unknown date time format %q, expected %s or %s",
+			cfg.DateTimeFormat, DateTimeFormatObject, DateTimeFormatISO8601)
+	}
+
+	if cfg.PasswordAlgo != "bcrypt" && cfg.PasswordAlgo != "argon2id" {
+		return fmt.Errorf("unknown password algorithm %q, expected bcrypt or argon2id", cfg.PasswordAlgo)
+	}
+
+	if cfg.MaxImportBytes < 1 {
+		return errors.New("maximum import size must be positive")
+	}
+
+	if cfg.MaxAttachmentBytes < 1 {
+		return errors.New("maximum attachment size must be positive")
+	}
+
+	if cfg.MaxTokenBytes < 1 {
+		return errors.New("maximum token size must be positive")
+	}
+
+	if cfg.QueryBudget < 0 {
+		return errors.New("query budget must not be negative")
+	}
+
+	if cfg.ReminderDays < 0 {
+		return errors.New("default reminder days must not be negative")
+	}
+
+	if cfg.SQLiteBusyTimeout < 0 {
+		return errors.New("SQLite busy timeout must not be negative")
+	}
+
+	if cfg.SQLiteBusyRetries < 0 {
+		return errors.New("SQLite busy retries must not be negative")
+	}
+
+	if cfg.SQLiteBusyBackoff < 0 {
+		return errors.New("SQLite busy backoff must not be negative")
+	}
+
+	if cfg.LoginMaxFailures < 0 {
+		return errors.New("login failures before lockout must not be negative")
+	}
+
+	if cfg.LoginMaxIPFailures < 0 {
+		return errors.New("login failures of an address before lockout must not be negative")
+	}
+
+	if (cfg.LoginMaxFailures > 0 || cfg.LoginMaxIPFailures > 0) && cfg.LoginLockout <= 0 {
+		return errors.New("login lockout must be positive")
+	}
+
+	if cfg.SlowQuery < 0 {
+		return errors.New("slow query threshold must not be negative")
+	}
+
+	if cfg.MinFreeBytes < 0 {
+		return errors.New("minimum free bytes must not be negative")
+	}
+
+	if cfg.PasswordMinLength < 0 {
+		return errors.New("password minimum length must not be negative")
+	}
+
+	if cfg.UUIDPrefixMinLength < 1 {
+		return errors.New("UUID prefix minimum length must be positive")
+	}
+
+	return nil
+}
+
+func (cfg *Config) validateClient() error {
+	/* Check that all settings required by client tools are present. */
+	if cfg.AdminUsername == "" || cfg.AdminPassword == "" {
+		return errors.New("missing user data")
+	}
+
+	if cfg.CACertificatePath == "" {
+		return errors.New("failed to obtain CA certificate path")
+	}
+
+	if cfg.MaxImportBytes < 1 {
+		return errors.New("maximum import size must be positive")
+	}
+
+	return nil
+}
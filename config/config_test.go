@@ -0,0 +1,715 @@
+package config
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"net/netip"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setServerEnv(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("GOCALENDAR_HOST", "localhost")
+	t.Setenv("GOCALENDAR_PORT", "4789")
+	t.Setenv("GOCALENDAR_ADMIN_USERNAME", "admin")
+	t.Setenv("GOCALENDAR_ADMIN_HASH", "$2a$10$hash")
+	t.Setenv("GOCALENDAR_TOKEN_SECRET", "secret")
+	t.Setenv("GOCALENDAR_TIMEZONE", "")
+	t.Setenv("GOCALENDAR_DATABASE", "")
+	t.Setenv("GOCALENDAR_INSTANCE_NAME", "")
+}
+
+func Test_LoadAppliesDefaults(t *testing.T) {
+	/* GIVEN all required server variables
+	 * AND no optional variables
+	 * WHEN Load() is called
+	 * THEN no error should be returned
+	 * AND defaults should be applied to optional settings
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Equal(t, "4789", cfg.Port)
+	assert.Equal(t, DefaultTimeZone, cfg.TimeZone)
+	assert.Equal(t, DefaultDatabaseFile, cfg.DatabaseFile)
+
+	hostname, _ := os.Hostname()
+	assert.Equal(t, hostname, cfg.InstanceName)
+}
+
+func Test_LoadOverridesDefaults(t *testing.T) {
+	/* GIVEN all required server variables
+	 * AND optional variables set
+	 * WHEN Load() is called
+	 * THEN optional settings should be taken from the environment
+	 */
+	setServerEnv(t)
+	t.Setenv("GOCALENDAR_TIMEZONE", "UTC")
+	t.Setenv("GOCALENDAR_DATABASE", "/tmp/eventshub.db")
+	t.Setenv("GOCALENDAR_INSTANCE_NAME", "eventshub-1")
+
+	cfg, err := Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "UTC", cfg.TimeZone)
+	assert.Equal(t, "/tmp/eventshub.db", cfg.DatabaseFile)
+	assert.Equal(t, "eventshub-1", cfg.InstanceName)
+}
+
+func Test_LoadFailsOnMissingRequired(t *testing.T) {
+	/* GIVEN one of required server variables missing
+	 * WHEN Load() is called
+	 * THEN an error should be returned
+	 */
+	for _, name := range []string{
+		"GOCALENDAR_HOST",
+		"GOCALENDAR_PORT",
+		"GOCALENDAR_ADMIN_USERNAME",
+		"GOCALENDAR_ADMIN_HASH",
+		"GOCALENDAR_TOKEN_SECRET",
+	} {
+		t.Run(name, func(t *testing.T) {
+			setServerEnv(t)
+			t.Setenv(name, "")
+
+			cfg, err := Load()
+
+			assert.Error(t, err)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func Test_LoadFailsOnUnknownTimeZone(t *testing.T) {
+	/* GIVEN all required server variables
+	 * AND an unknown time zone
+	 * WHEN Load() is called
+	 * THEN an error should be returned
+	 */
+	setServerEnv(t)
+	t.Setenv("GOCALENDAR_TIMEZONE", "Mars/Olympus_Mons")
+
+	_, err := Load()
+
+	assert.Error(t, err)
+}
+
+func Test_LoadClientFailsOnMissingRequired(t *testing.T) {
+	/* GIVEN client variables without a password
+	 * WHEN LoadClient() is called
+	 * THEN an error should be returned
+	 */
+	t.Setenv("GOCALENDAR_ADMIN_USERNAME", "admin")
+	t.Setenv("GOCALENDAR_ADMIN_PASSWORD", "")
+	t.Setenv("GOCALENDAR_OPENSSL_CA_CERTIFICATE", "/tmp/ca.pem")
+
+	_, err := LoadClient()
+
+	assert.Error(t, err)
+}
+
+func Test_FeaturesFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_FEATURES not set, set to a list with unknown name, and set empty
+	 * WHEN Load() is called
+	 * THEN default features should be enabled when variable is not set
+	 * AND only listed features should be enabled otherwise
+	 * AND unknown names should be collected without failing
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, Features{Audit: true, Import: true, Reminders: true, Streams: true}, cfg.Features)
+
+	t.Setenv("GOCALENDAR_FEATURES", " Kill, metrics ,audit,STREAMS")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, Features{Audit: true, Kill: true, Streams: true, Unknown: []string{"metrics"}}, cfg.Features)
+	assert.Equal(t, []string{FeatureAudit, FeatureKill, FeatureStreams}, cfg.Features.Enabled())
+
+	t.Setenv("GOCALENDAR_FEATURES", "")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, Features{}, cfg.Features)
+}
+
+func Test_TrustedProxiesFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_TRUSTED_PROXIES with a CIDR and a single address
+	 * WHEN Load() is called
+	 * THEN both should be parsed into prefixes
+	 * AND malformed entry should fail loading
+	 */
+	setServerEnv(t)
+	t.Setenv("GOCALENDAR_TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.7")
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.1.7/32")}, cfg.TrustedProxies)
+
+	t.Setenv("GOCALENDAR_TRUSTED_PROXIES", "10.0.0.0/33")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_AllowedSourcesFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_ALLOWED_SOURCES unset, set or set empty
+	 * WHEN Load() is called
+	 * THEN any source should be allowed without the variable
+	 * AND only listed sources, case insensitive, should be allowed with it
+	 * AND any source should be allowed with empty one
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.AllowedSources)
+	assert.True(t, cfg.SourceAllowed("XML"))
+	assert.True(t, cfg.SourceAllowed(""))
+	assert.True(t, cfg.SourceAllowed("TYPO"))
+
+	t.Setenv("GOCALENDAR_ALLOWED_SOURCES", "app, typo")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.True(t, cfg.SourceAllowed("TYPO"))
+	assert.False(t, cfg.SourceAllowed("XML"))
+
+	t.Setenv("GOCALENDAR_ALLOWED_SOURCES", "")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.AllowedSources)
+	assert.True(t, cfg.SourceAllowed("TYPO"))
+}
+
+func Test_JWTLeewayFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_JWT_LEEWAY unset, set to a duration or negative
+	 * WHEN Load() is called
+	 * THEN default, provided value or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultJWTLeeway, cfg.JWTLeeway)
+
+	t.Setenv("GOCALENDAR_JWT_LEEWAY", "1m")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, cfg.JWTLeeway)
+
+	t.Setenv("GOCALENDAR_JWT_LEEWAY", "-1s")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_PathPrefixesFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_PATH_PREFIX and GOCALENDAR_HEALTH_PATH_PREFIX variables
+	 * WHEN Load() is called
+	 * THEN prefixes should be normalized
+	 * AND health prefix should follow the API prefix unless set on its own
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPathPrefix, cfg.PathPrefix)
+	assert.Equal(t, DefaultPathPrefix, cfg.HealthPathPrefix)
+
+	t.Setenv("GOCALENDAR_PATH_PREFIX", "calendar/api/")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "/calendar/api", cfg.PathPrefix)
+	assert.Equal(t, "/calendar/api", cfg.HealthPathPrefix)
+
+	t.Setenv("GOCALENDAR_HEALTH_PATH_PREFIX", "/")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "", cfg.HealthPathPrefix)
+}
+
+func Test_PasswordAlgoFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_PASSWORD_ALGO unset, set to a known or unknown algorithm
+	 * WHEN Load() is called
+	 * THEN bcrypt, provided algorithm or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPasswordAlgo, cfg.PasswordAlgo)
+
+	t.Setenv("GOCALENDAR_PASSWORD_ALGO", "Argon2id")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "argon2id", cfg.PasswordAlgo)
+
+	t.Setenv("GOCALENDAR_PASSWORD_ALGO", "md5")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_SMTPFromEnv(t *testing.T) {
+	/* GIVEN SMTP variables unset, set with or without sender address
+	 * WHEN Load() is called
+	 * THEN reminders should be disabled, configured or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.False(t, cfg.SMTP.Enabled())
+	assert.Equal(t, DefaultSMTPPort, cfg.SMTP.Port)
+
+	t.Setenv("GOCALENDAR_SMTP_HOST", "mail.example.com")
+
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv("GOCALENDAR_SMTP_PORT", "2525")
+	t.Setenv("GOCALENDAR_SMTP_FROM", "eventshub@example.com")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.True(t, cfg.SMTP.Enabled())
+	assert.Equal(t, "2525", cfg.SMTP.Port)
+	assert.Equal(t, "eventshub@example.com", cfg.SMTP.From)
+}
+
+func Test_CompatFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_COMPAT unset, set to legacy or to an unknown mode
+	 * WHEN Load() is called
+	 * THEN current mode, legacy mode or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, CompatCurrent, cfg.Compat)
+
+	t.Setenv("GOCALENDAR_COMPAT", "Legacy")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, CompatLegacy, cfg.Compat)
+
+	t.Setenv("GOCALENDAR_COMPAT", "ancient")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_DateTimeFormatFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_DATETIME_FORMAT unset, set to iso8601 or to an unknown format
+	 * WHEN Load() is called
+	 * THEN object format, ISO 8601 format or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DateTimeFormatObject, cfg.DateTimeFormat)
+
+	t.Setenv("GOCALENDAR_DATETIME_FORMAT", " ISO8601")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DateTimeFormatISO8601, cfg.DateTimeFormat)
+
+	t.Setenv("GOCALENDAR_DATETIME_FORMAT", "unix")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_MinFreeBytesFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_MIN_FREE_BYTES unset, set to a number or negative
+	 * WHEN Load() is called
+	 * THEN the default, given number or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultMinFreeBytes, cfg.MinFreeBytes)
+
+	t.Setenv("GOCALENDAR_MIN_FREE_BYTES", "0")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cfg.MinFreeBytes)
+
+	t.Setenv("GOCALENDAR_MIN_FREE_BYTES", "-1")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_FieldEncryptionKeyFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_FIELD_ENCRYPTION_KEY unset, malformed, of wrong or valid length
+	 * WHEN Load() is called
+	 * THEN no key, an error, an error or the decoded key should be the result
+	 * AND address encryption should require the key
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.FieldEncryptionKey)
+
+	t.Setenv("GOCALENDAR_ENCRYPT_ADDRESS", "true")
+
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv("GOCALENDAR_FIELD_ENCRYPTION_KEY", "not base64!")
+
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv("GOCALENDAR_FIELD_ENCRYPTION_KEY", "c2hvcnQ=")
+
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv("GOCALENDAR_FIELD_ENCRYPTION_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("0123456789abcdef0123456789abcdef"), cfg.FieldEncryptionKey)
+	assert.True(t, cfg.EncryptAddress)
+}
+
+func Test_SlowQueryFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_SLOW_QUERY_MS unset, set to milliseconds or negative
+	 * WHEN Load() is called
+	 * THEN the default, given duration or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultSlowQuery, cfg.SlowQuery)
+
+	t.Setenv("GOCALENDAR_SLOW_QUERY_MS", "25")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 25*time.Millisecond, cfg.SlowQuery)
+
+	t.Setenv("GOCALENDAR_SLOW_QUERY_MS", "-1")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_SQLiteBusyRetriesFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_SQLITE_BUSY_RETRIES and GOCALENDAR_SQLITE_BUSY_BACKOFF unset, set or negative
+	 * WHEN Load() is called
+	 * THEN the defaults, given values or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultSQLiteBusyRetries, cfg.SQLiteBusyRetries)
+	assert.Equal(t, DefaultSQLiteBusyBackoff, cfg.SQLiteBusyBackoff)
+
+	t.Setenv("GOCALENDAR_SQLITE_BUSY_RETRIES", "0")
+	t.Setenv("GOCALENDAR_SQLITE_BUSY_BACKOFF", "5ms")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cfg.SQLiteBusyRetries)
+	assert.Equal(t, 5*time.Millisecond, cfg.SQLiteBusyBackoff)
+
+	t.Setenv("GOCALENDAR_SQLITE_BUSY_RETRIES", "-1")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_AttachmentsFromEnv(t *testing.T) {
+	/* GIVEN attachment variables unset, set or out of range
+	 * WHEN Load() is called
+	 * THEN attachments should be disabled with default limits, configured as given or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.AttachmentsDir)
+	assert.Equal(t, DefaultMaxAttachmentBytes, cfg.MaxAttachmentBytes)
+	assert.True(t, cfg.AttachmentTypeAllowed("application/pdf"))
+	assert.False(t, cfg.AttachmentTypeAllowed("application/x-msdownload"))
+
+	t.Setenv("GOCALENDAR_ATTACHMENTS_DIR", "/var/lib/eventshub/attachments")
+	t.Setenv("GOCALENDAR_ATTACHMENT_TYPES", " Text/Calendar, ,image/png")
+	t.Setenv("GOCALENDAR_MAX_ATTACHMENT_BYTES", "1024")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/lib/eventshub/attachments", cfg.AttachmentsDir)
+	assert.Equal(t, []string{"text/calendar", "image/png"}, cfg.AttachmentTypes)
+	assert.True(t, cfg.AttachmentTypeAllowed("TEXT/calendar"))
+	assert.False(t, cfg.AttachmentTypeAllowed("application/pdf"))
+	assert.Equal(t, 1024, cfg.MaxAttachmentBytes)
+
+	t.Setenv("GOCALENDAR_MAX_ATTACHMENT_BYTES", "0")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_MaxImportBytesFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_MAX_IMPORT_BYTES unset, set to a number or zero
+	 * WHEN Load() is called
+	 * THEN the default, given number or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultMaxImportBytes, cfg.MaxImportBytes)
+
+	t.Setenv("GOCALENDAR_MAX_IMPORT_BYTES", "1024")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 1024, cfg.MaxImportBytes)
+
+	t.Setenv("GOCALENDAR_MAX_IMPORT_BYTES", "0")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_DefaultReminderDaysFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_DEFAULT_REMINDER_DAYS unset, set to a number or negative
+	 * WHEN Load() is called
+	 * THEN the default, given number or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultReminderDays, cfg.ReminderDays)
+
+	t.Setenv("GOCALENDAR_DEFAULT_REMINDER_DAYS", "14")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 14, cfg.ReminderDays)
+
+	t.Setenv("GOCALENDAR_DEFAULT_REMINDER_DAYS", "-1")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_GzipFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_GZIP unset, disabled or malformed
+	 * WHEN Load() is called
+	 * THEN compression should be enabled, disabled or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.True(t, cfg.Gzip)
+
+	t.Setenv("GOCALENDAR_GZIP", "false")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.False(t, cfg.Gzip)
+
+	t.Setenv("GOCALENDAR_GZIP", "sometimes")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_SQLiteWALFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_SQLITE_WAL and GOCALENDAR_SQLITE_BUSY_TIMEOUT unset, set or negative
+	 * WHEN Load() is called
+	 * THEN the defaults, given values or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.False(t, cfg.SQLiteWAL)
+	assert.Equal(t, DefaultSQLiteBusyTimeout, cfg.SQLiteBusyTimeout)
+
+	t.Setenv("GOCALENDAR_SQLITE_WAL", "true")
+	t.Setenv("GOCALENDAR_SQLITE_BUSY_TIMEOUT", "2s")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.True(t, cfg.SQLiteWAL)
+	assert.Equal(t, 2*time.Second, cfg.SQLiteBusyTimeout)
+
+	t.Setenv("GOCALENDAR_SQLITE_BUSY_TIMEOUT", "-1s")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_LoginLockoutFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_LOGIN_MAX_FAILURES, GOCALENDAR_LOGIN_MAX_IP_FAILURES and GOCALENDAR_LOGIN_LOCKOUT unset, set or invalid
+	 * WHEN Load() is called
+	 * THEN the defaults, given values or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLoginMaxFailures, cfg.LoginMaxFailures)
+	assert.Equal(t, DefaultLoginMaxIPFailures, cfg.LoginMaxIPFailures)
+	assert.Equal(t, DefaultLoginLockout, cfg.LoginLockout)
+
+	t.Setenv("GOCALENDAR_LOGIN_MAX_FAILURES", "3")
+	t.Setenv("GOCALENDAR_LOGIN_MAX_IP_FAILURES", "10")
+	t.Setenv("GOCALENDAR_LOGIN_LOCKOUT", "1m")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cfg.LoginMaxFailures)
+	assert.Equal(t, 10, cfg.LoginMaxIPFailures)
+	assert.Equal(t, time.Minute, cfg.LoginLockout)
+
+	t.Setenv("GOCALENDAR_LOGIN_LOCKOUT", "0s")
+
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv("GOCALENDAR_LOGIN_MAX_FAILURES", "0")
+	t.Setenv("GOCALENDAR_LOGIN_MAX_IP_FAILURES", "0")
+
+	_, err = Load()
+	assert.NoError(t, err)
+
+	t.Setenv("GOCALENDAR_LOGIN_MAX_IP_FAILURES", "-1")
+
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv("GOCALENDAR_LOGIN_MAX_IP_FAILURES", "0")
+
+	t.Setenv("GOCALENDAR_LOGIN_MAX_FAILURES", "-1")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_AllowInsecureFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_ALLOW_INSECURE unset or set
+	 * WHEN Load() is called
+	 * THEN plaintext serving should be allowed only when it is set to true
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.False(t, cfg.AllowInsecure)
+
+	t.Setenv("GOCALENDAR_ALLOW_INSECURE", "true")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.True(t, cfg.AllowInsecure)
+}
+
+func Test_MaxTokenBytesFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_MAX_TOKEN_BYTES unset, set or zero
+	 * WHEN Load() is called
+	 * THEN the default, given value or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultMaxTokenBytes, cfg.MaxTokenBytes)
+
+	t.Setenv("GOCALENDAR_MAX_TOKEN_BYTES", "1024")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 1024, cfg.MaxTokenBytes)
+
+	t.Setenv("GOCALENDAR_MAX_TOKEN_BYTES", "0")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_QueryBudgetFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_QUERY_BUDGET unset, set or negative
+	 * WHEN Load() is called
+	 * THEN budget should be disabled, the given value or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cfg.QueryBudget)
+
+	t.Setenv("GOCALENDAR_QUERY_BUDGET", "50")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 50, cfg.QueryBudget)
+
+	t.Setenv("GOCALENDAR_QUERY_BUDGET", "-1")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
+
+func Test_GRPCPortFromEnv(t *testing.T) {
+	/* GIVEN GOCALENDAR_GRPC_PORT unset, set, malformed or equal to GOCALENDAR_PORT
+	 * WHEN Load() is called
+	 * THEN gRPC should be disabled, served on the given port or an error should be the result
+	 */
+	setServerEnv(t)
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.GRPCPort)
+
+	t.Setenv("GOCALENDAR_GRPC_PORT", "4790")
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "4790", cfg.GRPCPort)
+
+	t.Setenv("GOCALENDAR_GRPC_PORT", "grpc")
+
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv("GOCALENDAR_GRPC_PORT", "4789")
+
+	_, err = Load()
+	assert.Error(t, err)
+}
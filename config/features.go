@@ -0,0 +1,86 @@
+package config
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"sort"
+	"strings"
+)
+
+// Names of optional features accepted in GOCALENDAR_FEATURES.
+const (
+	FeatureAudit     string = "audit"
+	FeatureImport    string = "import"
+	FeatureKill      string = "kill"
+	FeatureReminders string = "reminders"
+	FeatureStreams   string = "streams"
+)
+
+// DefaultFeatures is the safe set enabled when GOCALENDAR_FEATURES is not set.
+// Remote kill is left out, as it allows to stop the server over the network.
+const DefaultFeatures string = FeatureAudit + "," + FeatureImport + "," + FeatureReminders + "," + FeatureStreams
+
+// Features tells which optional routes and background jobs are enabled.
+type Features struct {
+	Audit     bool
+	Import    bool
+	Kill      bool
+	Reminders bool
+	Streams   bool
+	Unknown   []string
+}
+
+// Enabled returns names of enabled features in the order they are documented.
+func (f Features) Enabled() []string {
+	var names []string
+
+	for _, feature := range []struct {
+		name    string
+		enabled bool
+	}{
+		{FeatureAudit, f.Audit},
+		{FeatureImport, f.Import},
+		{FeatureKill, f.Kill},
+		{FeatureReminders, f.Reminders},
+		{FeatureStreams, f.Streams},
+	} {
+		if feature.enabled {
+			names = append(names, feature.name)
+		}
+	}
+
+	return names
+}
+
+// ParseFeatures reads a comma separated list of feature names.
+// Unknown names are collected in Unknown instead of failing, so the
+// caller may warn about them.
+func ParseFeatures(list string) Features {
+	var f Features
+
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		switch name {
+		case "":
+		case FeatureAudit:
+			f.Audit = true
+		case FeatureImport:
+			f.Import = true
+		case FeatureKill:
+			f.Kill = true
+		case FeatureReminders:
+			f.Reminders = true
+		case FeatureStreams:
+			f.Streams = true
+		default:
+			f.Unknown = append(f.Unknown, name)
+		}
+	}
+
+	sort.Strings(f.Unknown)
+
+	return f
+}
@@ -27,6 +27,23 @@ func NewConsoleLogger(name string, level int) *ConsoleLogger {
 	return cl
 }
 
+// With returns a logger writing to the same outputs at the same level,
+// with prefix appended to the name of every line.
+func (cl *ConsoleLogger) With(prefix string) *ConsoleLogger {
+	derive := func(l *log.Logger) *log.Logger {
+		return log.New(l.Writer(), l.Prefix()+prefix+" ", l.Flags())
+	}
+
+	return &ConsoleLogger{
+		debug:    derive(cl.debug),
+		info:     derive(cl.info),
+		warning:  derive(cl.warning),
+		error:    derive(cl.error),
+		critical: derive(cl.critical),
+		level:    cl.level,
+	}
+}
+
 func (cl *ConsoleLogger) Debug(v ...interface{}) {
 	if DEBUG >= cl.level {
 		cl.debug.Printf("DEBUG: %v", fmt.Sprint(v...))
@@ -55,6 +72,11 @@ func (cl *ConsoleLogger) Critical(v ...interface{}) {
 	cl.critical.Printf("CRITICAL: %v", fmt.Sprint(v...))
 }
 
+// Level returns the lowest level of messages written by the logger.
+func (cl *ConsoleLogger) Level() int {
+	return cl.level
+}
+
 func (cl *ConsoleLogger) SetLoggingLevel(lvl int) {
 	if lvl >= DEBUG && lvl <= CRITICAL {
 		cl.level = lvl
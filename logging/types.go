@@ -8,6 +8,24 @@ const (
 	CRITICAL
 )
 
+// LevelName returns human readable name of the logging level.
+func LevelName(level int) string {
+	switch level {
+	case DEBUG:
+		return "DEBUG"
+	case INFO:
+		return "INFO"
+	case WARNING:
+		return "WARNING"
+	case ERROR:
+		return "ERROR"
+	case CRITICAL:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 type Logger interface {
 	Debug(v ...interface{})
 	Info(v ...interface{})
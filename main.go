@@ -1,6 +1,7 @@
 package main
 
 import (
+	"eventshub/config"
 	v1rest "eventshub/service/v1/rest"
 	"log"
 	"os"
@@ -12,13 +13,18 @@ import (
 func main() {
 	var wg sync.WaitGroup
 
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
 	restServer := v1rest.HTTPRestServer{}
 
 	// We want a server to gracefully shutdown after receiving
 	// a SIGTERM, or a SIGINT (Ctrl+C) signal.
 	sigs := make(chan os.Signal, 1)
 
-	restServer.Configure(sigs)
+	restServer.Configure(sigs, cfg)
 	restServer.StartTLS()
 
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
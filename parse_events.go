@@ -1,11 +1,18 @@
 package main
 
 import (
+	"eventshub/config"
 	logger "eventshub/logging"
 	xmlparser "eventshub/xmlparser"
+	"log"
 )
 
 func main() {
-	parser := xmlparser.NewXMLEventsParser("./xmlparser/config.json", logger.INFO)
+	cfg, err := config.LoadClient()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	parser := xmlparser.NewXMLEventsParser("./xmlparser/config.json", logger.INFO, cfg)
 	parser.UploadStoredEvents()
 }
@@ -0,0 +1,233 @@
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+// gRPC contract mirroring the REST API types in service/v1/rest/types.go.
+// Requests other than Login carry the JWT in the "token" metadata key,
+// validated the same way as the REST "Token" header.
+//
+// Go code is generated with protoc-gen-go and protoc-gen-go-grpc:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//		--go-grpc_out=. --go-grpc_opt=paths=source_relative eventshub.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: eventshub.proto
+
+package v1grpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	EventsHub_Login_FullMethodName                    = "/eventshub.v1.EventsHub/Login"
+	EventsHub_InsertEvent_FullMethodName              = "/eventshub.v1.EventsHub/InsertEvent"
+	EventsHub_GetEventsWithinTimeRange_FullMethodName = "/eventshub.v1.EventsHub/GetEventsWithinTimeRange"
+	EventsHub_GetStatus_FullMethodName                = "/eventshub.v1.EventsHub/GetStatus"
+)
+
+// EventsHubClient is the client API for EventsHub service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EventsHubClient interface {
+	Login(ctx context.Context, in *User, opts ...grpc.CallOption) (*TokenMsg, error)
+	InsertEvent(ctx context.Context, in *AddEventReq, opts ...grpc.CallOption) (*AddEventResp, error)
+	GetEventsWithinTimeRange(ctx context.Context, in *GetEventsReq, opts ...grpc.CallOption) (*GetEventsResp, error)
+	GetStatus(ctx context.Context, in *GetStatusReq, opts ...grpc.CallOption) (*GetStatusResp, error)
+}
+
+type eventsHubClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEventsHubClient(cc grpc.ClientConnInterface) EventsHubClient {
+	return &eventsHubClient{cc}
+}
+
+func (c *eventsHubClient) Login(ctx context.Context, in *User, opts ...grpc.CallOption) (*TokenMsg, error) {
+	out := new(TokenMsg)
+	err := c.cc.Invoke(ctx, EventsHub_Login_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventsHubClient) InsertEvent(ctx context.Context, in *AddEventReq, opts ...grpc.CallOption) (*AddEventResp, error) {
+	out := new(AddEventResp)
+	err := c.cc.Invoke(ctx, EventsHub_InsertEvent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventsHubClient) GetEventsWithinTimeRange(ctx context.Context, in *GetEventsReq, opts ...grpc.CallOption) (*GetEventsResp, error) {
+	out := new(GetEventsResp)
+	err := c.cc.Invoke(ctx, EventsHub_GetEventsWithinTimeRange_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventsHubClient) GetStatus(ctx context.Context, in *GetStatusReq, opts ...grpc.CallOption) (*GetStatusResp, error) {
+	out := new(GetStatusResp)
+	err := c.cc.Invoke(ctx, EventsHub_GetStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EventsHubServer is the server API for EventsHub service.
+// All implementations must embed UnimplementedEventsHubServer
+// for forward compatibility
+type EventsHubServer interface {
+	Login(context.Context, *User) (*TokenMsg, error)
+	InsertEvent(context.Context, *AddEventReq) (*AddEventResp, error)
+	GetEventsWithinTimeRange(context.Context, *GetEventsReq) (*GetEventsResp, error)
+	GetStatus(context.Context, *GetStatusReq) (*GetStatusResp, error)
+	mustEmbedUnimplementedEventsHubServer()
+}
+
+// UnimplementedEventsHubServer must be embedded to have forward compatible implementations.
+type UnimplementedEventsHubServer struct {
+}
+
+func (UnimplementedEventsHubServer) Login(context.Context, *User) (*TokenMsg, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedEventsHubServer) InsertEvent(context.Context, *AddEventReq) (*AddEventResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InsertEvent not implemented")
+}
+func (UnimplementedEventsHubServer) GetEventsWithinTimeRange(context.Context, *GetEventsReq) (*GetEventsResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEventsWithinTimeRange not implemented")
+}
+func (UnimplementedEventsHubServer) GetStatus(context.Context, *GetStatusReq) (*GetStatusResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedEventsHubServer) mustEmbedUnimplementedEventsHubServer() {}
+
+// UnsafeEventsHubServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EventsHubServer will
+// result in compilation errors.
+type UnsafeEventsHubServer interface {
+	mustEmbedUnimplementedEventsHubServer()
+}
+
+func RegisterEventsHubServer(s grpc.ServiceRegistrar, srv EventsHubServer) {
+	s.RegisterService(&EventsHub_ServiceDesc, srv)
+}
+
+func _EventsHub_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsHubServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventsHub_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsHubServer).Login(ctx, req.(*User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventsHub_InsertEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddEventReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsHubServer).InsertEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventsHub_InsertEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsHubServer).InsertEvent(ctx, req.(*AddEventReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventsHub_GetEventsWithinTimeRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsHubServer).GetEventsWithinTimeRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventsHub_GetEventsWithinTimeRange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsHubServer).GetEventsWithinTimeRange(ctx, req.(*GetEventsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventsHub_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsHubServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventsHub_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsHubServer).GetStatus(ctx, req.(*GetStatusReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// EventsHub_ServiceDesc is the grpc.ServiceDesc for EventsHub service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EventsHub_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "eventshub.v1.EventsHub",
+	HandlerType: (*EventsHubServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Login",
+			Handler:    _EventsHub_Login_Handler,
+		},
+		{
+			MethodName: "InsertEvent",
+			Handler:    _EventsHub_InsertEvent_Handler,
+		},
+		{
+			MethodName: "GetEventsWithinTimeRange",
+			Handler:    _EventsHub_GetEventsWithinTimeRange_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _EventsHub_GetStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "eventshub.proto",
+}
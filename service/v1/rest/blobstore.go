@@ -0,0 +1,119 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrBlobNotFound is returned when BlobStore has no blob stored under the key.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps binary content, e.g. attachments of events, outside of the
+// database, which stores only keys of the blobs.
+type BlobStore interface {
+	Put(key string, r io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// LocalBlobStore is a BlobStore keeping every blob in a file of a local directory.
+type LocalBlobStore struct {
+	root string
+}
+
+func NewLocalBlobStore(root string) (*LocalBlobStore, error) {
+	/* Create store rooted at the directory, which is created when missing */
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, err
+	}
+
+	return &LocalBlobStore{root: root}, nil
+}
+
+func (s *LocalBlobStore) path(key string) (string, error) {
+	/* Map key to a file directly within root, keys naming other paths are refused */
+	if key == "" || key == "." || key == ".." || filepath.Base(key) != key {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(s.root, key), nil
+}
+
+func (s *LocalBlobStore) Put(key string, r io.Reader) error {
+	/* Store content of r under the key, replacing previous one. Content is written to
+	 * a temporary file first, so a failed upload never leaves a partial blob behind.
+	 */
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(s.root, ".upload-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+
+	return nil
+}
+
+func (s *LocalBlobStore) Get(key string) (io.ReadCloser, error) {
+	/* Open blob stored under the key, caller closes it */
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+	}
+
+	return file, err
+}
+
+func (s *LocalBlobStore) Delete(key string) error {
+	/* Remove blob stored under the key, removing a missing one is not an error */
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func newBlobKey() (string, error) {
+	/* Generate random 128-bit blob key, never derived from client input */
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(key), nil
+}
@@ -0,0 +1,227 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// attachmentsRepo is a DatabaseRepo keeping attachments of a single known event of alice in memory.
+type attachmentsRepo struct {
+	stubRepo
+	uuid        string
+	attachments map[string]Attachment
+}
+
+func (repo *attachmentsRepo) GetEventOwner(uuid string) (string, error) {
+	if uuid != repo.uuid {
+		return "", fmt.Errorf("%w: %s", ErrEventNotFound, uuid)
+	}
+
+	return "alice", nil
+}
+
+func (repo *attachmentsRepo) SetAttachment(a Attachment) (string, error) {
+	previous := repo.attachments[a.EventUUID].Key
+	repo.attachments[a.EventUUID] = a
+
+	return previous, nil
+}
+
+func (repo *attachmentsRepo) GetAttachment(uuid string) (Attachment, error) {
+	a, ok := repo.attachments[uuid]
+	if !ok {
+		return Attachment{}, fmt.Errorf("%w: %s", ErrAttachmentNotFound, uuid)
+	}
+
+	return a, nil
+}
+
+func (repo *attachmentsRepo) DeleteAttachment(uuid string) (Attachment, error) {
+	a, err := repo.GetAttachment(uuid)
+	delete(repo.attachments, uuid)
+
+	return a, err
+}
+
+func Test_LocalBlobStoreRoundTrips(t *testing.T) {
+	/* GIVEN a local blob store in a temporary directory
+	 * WHEN a blob is put, read, replaced and deleted
+	 * THEN its latest content should be read back until it is deleted
+	 * AND keys naming paths outside the directory should be refused
+	 */
+	t.Parallel()
+
+	root := t.TempDir()
+
+	sut, err := NewLocalBlobStore(root)
+	assert.NoError(t, err)
+
+	read := func(key string) (string, error) {
+		blob, err := sut.Get(key)
+		if err != nil {
+			return "", err
+		}
+
+		defer blob.Close()
+
+		data, err := io.ReadAll(blob)
+
+		return string(data), err
+	}
+
+	assert.NoError(t, sut.Put("a1", strings.NewReader("first")))
+	assert.NoError(t, sut.Put("a1", strings.NewReader("second")))
+
+	content, err := read("a1")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", content)
+
+	assert.NoError(t, sut.Delete("a1"))
+	assert.NoError(t, sut.Delete("a1"))
+
+	_, err = read("a1")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	for _, key := range []string{"", "..", "../escaped", "dir/a1"} {
+		assert.Error(t, sut.Put(key, strings.NewReader("x")), key)
+	}
+
+	/* Only the temporary files of failed uploads could be left behind, there must be none */
+	entries, err := os.ReadDir(root)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func Test_AttachmentUploadDownloadDelete(t *testing.T) {
+	/* GIVEN a server storing attachments of up to 16 bytes of text/plain in a temporary directory
+	 * WHEN an attachment is uploaded, downloaded and deleted
+	 * THEN the same content and media type should be downloaded, never rendered inline
+	 * AND only the blob key should be stored in the repository
+	 * AND after delete both the attachment and its blob should be gone
+	 * AND too large, disallowed and unknown event uploads should be refused
+	 */
+	const uuid = "e0b2dd0f43614138995beafa87b6356b"
+
+	root := t.TempDir()
+
+	blobs, err := NewLocalBlobStore(root)
+	assert.NoError(t, err)
+
+	repo := &attachmentsRepo{uuid: uuid, attachments: map[string]Attachment{}}
+	srv, _ := newStubServer(t, repo)
+	srv.cfg.MaxAttachmentBytes = 16
+	srv.cfg.AttachmentTypes = []string{"text/plain"}
+	srv.blobs = blobs
+
+	token, err := createJWT(srv.cfg, srv.clock, "alice")
+	assert.NoError(t, err)
+
+	request := func(method, uuid, contentType, body string) *httptest.ResponseRecorder {
+		req := authorizedRequest(method, "/api/v1/attachment?uuid="+uuid, body, token)
+		req.Header.Set("Content-Type", contentType)
+
+		rec := httptest.NewRecorder()
+		srv.attachment(rec, req)
+
+		return rec
+	}
+
+	var resp AttachmentResp
+
+	rec := request(http.MethodPut, uuid, "text/plain; charset=utf-8", "meeting notes")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "text/plain", resp.Attachment.ContentType)
+	assert.Equal(t, int64(len("meeting notes")), resp.Attachment.Size)
+	assert.NotContains(t, rec.Body.String(), repo.attachments[uuid].Key)
+
+	rec = request(http.MethodGet, uuid, "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Disposition"), "attachment;"))
+	assert.Equal(t, "meeting notes", rec.Body.String())
+
+	key := repo.attachments[uuid].Key
+	_, err = os.Stat(root + "/" + key)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, request(http.MethodPut, uuid, "text/plain", strings.Repeat("x", 17)).Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType, request(http.MethodPut, uuid, "application/x-msdownload", "MZ").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPut, "f0000000000000000000000000000000", "text/plain", "x").Code)
+
+	/* Refused uploads leave previous attachment in place */
+	assert.Equal(t, key, repo.attachments[uuid].Key)
+
+	rec = request(http.MethodDelete, uuid, "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	_, err = os.Stat(root + "/" + key)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, uuid, "", "").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodDelete, uuid, "", "").Code)
+
+	entries, err := os.ReadDir(root)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func Test_AttachmentIsAvailableToEventOwnerOnly(t *testing.T) {
+	/* GIVEN an event of alice with an attachment
+	 * WHEN bob and the admin download, replace and delete the attachment
+	 * THEN every request of bob should be refused with 403 and leave the attachment in place
+	 * AND the admin should be able to download it
+	 */
+	const uuid = "e0b2dd0f43614138995beafa87b6356b"
+
+	blobs, err := NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+
+	assert.NoError(t, blobs.Put("a1", strings.NewReader("meeting notes")))
+
+	repo := &attachmentsRepo{uuid: uuid, attachments: map[string]Attachment{
+		uuid: {EventUUID: uuid, ContentType: "text/plain", Size: int64(len("meeting notes")), Key: "a1"},
+	}}
+	srv, _ := newStubServer(t, repo)
+	srv.cfg.AdminUsername = "admin"
+	srv.cfg.AttachmentTypes = []string{"text/plain"}
+	srv.blobs = blobs
+
+	request := func(user, method string) *httptest.ResponseRecorder {
+		token, err := createJWT(srv.cfg, srv.clock, user)
+		assert.NoError(t, err)
+
+		req := authorizedRequest(method, "/api/v1/attachment?uuid="+uuid, "stolen", token)
+		req.Header.Set("Content-Type", "text/plain")
+
+		rec := httptest.NewRecorder()
+		srv.attachment(rec, req)
+
+		return rec
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		rec := request("bob", method)
+		assert.Equal(t, http.StatusForbidden, rec.Code, method)
+		assert.NotContains(t, rec.Body.String(), "meeting notes")
+	}
+
+	assert.Equal(t, "a1", repo.attachments[uuid].Key)
+
+	rec := request("admin", http.MethodGet)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "meeting notes", rec.Body.String())
+}
@@ -0,0 +1,102 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// ErrQueryBudgetExceeded is returned by database statements of a request which used up cfg.QueryBudget.
+var ErrQueryBudgetExceeded = errors.New("query budget exceeded")
+
+// queryBudget counts database statements of a single request, see queryBudgetMiddleware.
+// Statements over the limit are refused only until the request changes the database, from
+// then on they are counted and logged, so a request is never left half done by the budget.
+type queryBudget struct {
+	limit   int64
+	used    atomic.Int64
+	wrote   atomic.Bool
+	refused atomic.Bool
+}
+
+func (b *queryBudget) charge(write bool) error {
+	/* Count statement, refuse it once more than limit statements were run and nothing was written yet */
+	if b == nil {
+		return nil
+	}
+
+	if used := b.used.Add(1); used > b.limit && !b.wrote.Load() {
+		b.refused.Store(true)
+		return fmt.Errorf("%w: statement %d, at most %d allowed", ErrQueryBudgetExceeded, used, b.limit)
+	}
+
+	if write {
+		b.wrote.Store(true)
+	}
+
+	return nil
+}
+
+func (b *queryBudget) exceeded() bool {
+	return b.used.Load() > b.limit
+}
+
+// budgetedRepository is implemented by repositories able to charge their statements to a budget.
+type budgetedRepository interface {
+	withBudget(budget *queryBudget) DatabaseRepo
+}
+
+func (srv *HTTPRestServer) repo(r *http.Request) DatabaseRepo {
+	/* Return repository for handler of the request, charging the query budget of the
+	 * request if it has one. Handlers use it instead of srv.db, so runaway query counts,
+	 * e.g. of N+1 patterns, fail the request instead of going unnoticed.
+	 */
+	return srv.contextRepo(r.Context())
+}
+
+func (srv *HTTPRestServer) contextRepo(ctx context.Context) DatabaseRepo {
+	/* Return repository charging query budget stored in ctx, gRPC methods have no request */
+	budget, ok := ctx.Value(budgetKey).(*queryBudget)
+	if !ok {
+		return srv.db
+	}
+
+	if repo, ok := srv.db.(budgetedRepository); ok {
+		return repo.withBudget(budget)
+	}
+
+	return srv.db
+}
+
+// budgetWriter discards response of a handler whose database statement was refused by the
+// query budget, as queryBudgetMiddleware answers such requests itself.
+type budgetWriter struct {
+	http.ResponseWriter
+	budget      *queryBudget
+	wroteHeader bool
+}
+
+func (w *budgetWriter) WriteHeader(code int) {
+	if w.wroteHeader || w.budget.refused.Load() {
+		return
+	}
+
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *budgetWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader && w.budget.refused.Load() {
+		return len(b), nil
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	return w.ResponseWriter.Write(b)
+}
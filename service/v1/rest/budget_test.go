@@ -0,0 +1,104 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_QueryBudgetAbortsRunawayHandler(t *testing.T) {
+	/* GIVEN getStatus handler counting changes, which runs more than a single database statement
+	 * WHEN it is called through the middleware with query budget of 1 and of 100
+	 * THEN the first request should be answered by the middleware with 500 ErrorResp
+	 * AND the second one by the handler with 200
+	 * AND streaming endpoints should not be limited
+	 */
+	srv, token := newTestServer(t)
+	srv.cfg.QueryBudget = 1
+
+	handler := srv.queryBudgetMiddleware(http.HandlerFunc(srv.getStatus))
+
+	req := httptest.NewRequest(http.MethodGet, srv.cfg.PathPrefix+"/getStatus?since=0", nil)
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var errResp ErrorResp
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errResp))
+	assert.Equal(t, ErrorRespName, errResp.Type)
+	assert.Contains(t, errResp.Status.Message, "query budget")
+
+	srv.cfg.QueryBudget = 100
+	handler = srv.queryBudgetMiddleware(http.HandlerFunc(srv.getStatus))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"changes":0`)
+
+	srv.cfg.QueryBudget = 1
+	handler = srv.queryBudgetMiddleware(http.HandlerFunc(srv.getStatus))
+
+	req = httptest.NewRequest(http.MethodGet, srv.cfg.PathPrefix+"/export.csv", nil)
+	rec = httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func Test_QueryBudgetDoesNotInterruptWrites(t *testing.T) {
+	/* GIVEN query budget of 2 and three stored events
+	 * WHEN updateFlags changes all of them, auditing each in a statement of its own
+	 * THEN the request should succeed once the events were updated
+	 * AND every audit entry should be recorded, so no change is left half done
+	 */
+	srv, token := newTestServer(t)
+
+	uuids := []string{"b1000000000000000000000000000001", "b1000000000000000000000000000002", "b1000000000000000000000000000003"}
+
+	for _, uuid := range uuids {
+		event := TestEvent1
+		event.UUID = uuid
+
+		_, err := srv.db.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	srv.cfg.QueryBudget = 2
+	handler := srv.queryBudgetMiddleware(http.HandlerFunc(srv.updateFlags))
+
+	done := true
+	body, err := json.Marshal(UpdateFlagsReq{UUIDs: uuids, Done: &done})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, srv.cfg.PathPrefix+"/updateFlags", bytes.NewReader(body))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var resp UpdateFlagsResp
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, int64(3), resp.Updated)
+
+	var audited int
+
+	assert.NoError(t, srv.db.(*SQLiteRepository).handle().QueryRow(
+		"SELECT COUNT(*) FROM audit WHERE action = ?", AuditActionUpdate).Scan(&audited))
+	assert.Equal(t, 3, audited)
+}
@@ -0,0 +1,69 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import "sync"
+
+// EventChange describes a single event inserted, updated or deleted in the database.
+// Action is one of AuditActionInsert, AuditActionUpdate or AuditActionDelete.
+type EventChange struct {
+	UUID   string `json:"uuid"`
+	Action string `json:"action"`
+}
+
+// EventBus broadcasts event changes to subscribers within the process.
+// Publishing never blocks, changes are dropped for subscribers which buffer
+// of EventBusBufferSize changes is full, so a slow consumer can not stall writes.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[<-chan EventChange]chan EventChange
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[<-chan EventChange]chan EventChange)}
+}
+
+// Subscribe returns a channel receiving all changes published from now on.
+// Subscriber has to call Unsubscribe once it is not interested in changes anymore.
+func (b *EventBus) Subscribe() <-chan EventChange {
+	ch := make(chan EventChange, EventBusBufferSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers[ch] = ch
+
+	return ch
+}
+
+// Unsubscribe stops delivery of changes to the channel and closes it.
+func (b *EventBus) Unsubscribe(ch <-chan EventChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if subscriber, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(subscriber)
+	}
+}
+
+func (b *EventBus) publish(changes ...EventChange) {
+	/* Deliver changes to every subscriber without waiting, nil bus discards them */
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, subscriber := range b.subscribers {
+		for _, change := range changes {
+			select {
+			case subscriber <- change:
+			default:
+			}
+		}
+	}
+}
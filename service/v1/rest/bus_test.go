@@ -0,0 +1,41 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SlowSubscriberDoesNotBlockPublishing(t *testing.T) {
+	/* GIVEN a subscriber which does not read changes
+	 * WHEN more changes than its buffer holds are published
+	 * THEN publishing should not block
+	 * AND the subscriber should receive buffered changes only
+	 * AND its channel should be closed once it unsubscribes
+	 */
+	t.Parallel()
+
+	bus := NewEventBus()
+	changes := bus.Subscribe()
+
+	for i := 0; i < EventBusBufferSize*2; i++ {
+		bus.publish(EventChange{UUID: "e0b2dd0f43614138995beafa87b6356b", Action: AuditActionUpdate})
+	}
+
+	assert.Len(t, changes, EventBusBufferSize)
+
+	bus.Unsubscribe(changes)
+
+	received := 0
+	for range changes {
+		received++
+	}
+
+	assert.Equal(t, EventBusBufferSize, received)
+
+	bus.publish(EventChange{UUID: "e0b2dd0f43614138995beafa87b6356b", Action: AuditActionDelete})
+}
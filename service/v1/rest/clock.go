@@ -0,0 +1,20 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import "time"
+
+// Clock provides current time. Server, repository and tokens read time only
+// through it, so tests can replace the wall clock with a fixed one.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock reading the wall clock, used by default.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
@@ -6,44 +6,391 @@ package v1rest
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"eventshub/config"
 	logger "eventshub/logging"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
-	// SQLite driver
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 var (
-	SQLFile = "file::memory:?cache=shared"
+	SQLFile = config.DefaultDatabaseFile
 )
 
+// inMemoryKeepAlive holds a sentinel connection of every shared-cache in-memory
+// database opened by NewSQLiteRepository. SQLite drops such database together with
+// its last connection, so without the sentinel Close of a repository would silently
+// wipe data expected by the next handle opened with the same name. Shutdown releases it.
+var inMemoryKeepAlive = struct {
+	sync.Mutex
+	dbs map[string]*sql.DB
+}{dbs: map[string]*sql.DB{}}
+
+// SchemaVersion is stored by Migrate in `PRAGMA user_version`, bump it with
+// every change of the tables so the version of a database file can be told.
+const SchemaVersion = 4
+
+// inMemoryDatabaseFile returns data source name of a named in-memory database.
+// Handles opened with the same name share the database, different names never do,
+// so e.g. every test may get a fresh database instead of the shared SQLFile.
+func inMemoryDatabaseFile(name string) string {
+	return "file:" + url.PathEscape(name) + "?mode=memory&cache=shared"
+}
+
+// Columns are listed explicitly, as convertRawEventRecordToEventData scans them in this
+// order and tables may contain additional bookkeeping columns.
+const selectEventsSQL = `
+	SELECT id, version, uuid, title, start, end, address, info,
+		reminder, done, important, urgent, source, category, color
+	FROM events`
+
+// eventSortColumns whitelists fields events may be sorted by, user input never reaches SQL.
+var eventSortColumns = map[string]string{
+	"":           "start",
+	"start":      "start",
+	"end":        "end",
+	"title":      "title",
+	"uuid":       "uuid",
+	"source":     "source",
+	"category":   "category",
+	"created_at": "created_at",
+}
+
 type DatabaseRepo interface {
 	AddUser(user string, password string, hashed bool) error
+	UpsertUser(user string, password string, hashed bool) error
 	AuthenticateUser(user string, password string) (bool, error)
+	Backup(path string) error
 	Close()
+	CountEventsByDay(start, end int64) ([]DayCount, error)
+	CountEventsChangedSince(since int64) (int64, error)
+	CountEventsFiltered(opts EventQueryOptions) (int64, error)
+	DeleteAttachment(uuid string) (Attachment, error)
 	DeleteEvent(e *EventData) (bool, error)
+	DeleteEvents(uuids []string) ([]string, error)
+	DeleteEventsEndedBefore(cutoff int64) (int64, error)
+	FindEventsByUUIDPrefix(prefix string) ([]EventData, error)
+	FindOverlapping(start, end int64, excludeUUID string) ([]EventData, error)
+	ForEachEvent(batchSize int, fn func(EventData) error) error
 	GetAllEvents() ([]EventData, error)
+	GetAllEventsFiltered(opts EventQueryOptions) ([]EventData, error)
+	GetAttachment(uuid string) (Attachment, error)
+	GetAudit(limit int) ([]AuditEntry, error)
+	GetDueReminders(now int64) ([]DueReminder, error)
+	FindEventByContentHash(hash string) (EventData, error)
+	GetEventsByCategory(category string) ([]EventData, error)
+	GetEventsByPriority(important, urgent bool, start, end int64) ([]EventData, error)
+	GetEventsByTitle(title string) ([]EventData, error)
 	GetEventsByTimeRange(start, end int64) ([]EventData, error)
+	GetEventsCreatedBetween(start, end int64) ([]EventData, error)
 	GetEventByUUID(uuid string) (EventData, error)
+	GetEventsByUUIDs(uuids []string) ([]EventData, error)
+	GetEventDetail(uuid string) (EventDetail, error)
+	GetEventOwner(uuid string) (string, error)
+	GetLocations(prefix string, limit int) ([]string, error)
+	GetSchemaVersion() (int, error)
 	GetStatus() (GetStatusResp, error)
+	GetStatusHistory(limit int) ([]GetStatusResp, error)
+	GetUserEmail(user string) (string, error)
+	GetUserReminders(user string, from, to int64) ([]UserReminder, error)
+	GetUserSettings(user string) (string, error)
+	HealthCheck() error
 	InsertEvent(e *EventData) (*EventData, error)
+	InsertEvents(events []*EventData) ([]error, error)
+	InsertIfAbsent(e *EventData) (bool, error)
+	MarkReminded(uuid string) error
+	MergeEvents(primary, secondary string) (*EventData, error)
+	PatchEvent(p *PatchEventReq) (*EventData, error)
+	ReassignEvents(fromUser, toUser string) ([]string, error)
+	RecordAudit(user, action, uuid string) error
+	SetAttachment(a Attachment) (string, error)
+	SetUserEmail(user, email string) error
+	SetUserSettings(user, settings string) error
+	Shutdown()
+	Truncate() error
+	UpdateFlags(p *UpdateFlagsReq) ([]string, error)
 	Migrate() error
 }
 
+// queryer is implemented by both *sql.DB and *sql.Tx, so the same statements
+// can run standalone or as a part of a transaction.
+type queryer interface {
+	Prepare(query string) (*sql.Stmt, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// dbHandle is the database handle shared by repository and its budgeted copies,
+// it may be replaced by Reconnect.
+type dbHandle struct {
+	db *sql.DB
+	mu sync.RWMutex
+}
+
 type SQLiteRepository struct {
-	db  *sql.DB
-	log *logger.ConsoleLogger
+	blobs  BlobStore
+	budget *queryBudget
+	bus    *EventBus
+	cfg    *config.Config
+	clock  Clock
+	conn   *dbHandle
+	log    *logger.ConsoleLogger
+	sem    chan struct{}
+}
+
+func NewSQLiteRepository(db *sql.DB, cfg *config.Config) *SQLiteRepository {
+	/* SQLite serializes writes, single connection avoids `database is locked` errors. */
+	db.SetMaxOpenConns(1)
+
+	repo := &SQLiteRepository{
+		cfg:   cfg,
+		clock: SystemClock{},
+		conn:  &dbHandle{db: db},
+		log:   logger.NewConsoleLogger("SQLite", logger.INFO),
+		sem:   make(chan struct{}, cfg.DBMaxConcurrency),
+	}
+
+	if err := keepInMemoryAlive(cfg.DatabaseFile); err != nil {
+		repo.log.Warning("Failed to open keep-alive connection of in-memory database. ", err)
+	}
+
+	return repo
+}
+
+func keepInMemoryAlive(file string) error {
+	/* Open sentinel connection of shared-cache in-memory database, once per data source name */
+	if !isInMemory(file) || !strings.Contains(file, "cache=shared") {
+		return nil
+	}
+
+	inMemoryKeepAlive.Lock()
+	defer inMemoryKeepAlive.Unlock()
+
+	if _, ok := inMemoryKeepAlive.dbs[file]; ok {
+		return nil
+	}
+
+	db, err := sql.Open("sqlite3", file)
+	if err != nil {
+		return err
+	}
+
+	/* Idle connection is never closed by database/sql, so it keeps the database alive */
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return err
+	}
+
+	inMemoryKeepAlive.dbs[file] = db
+
+	return nil
+}
+
+func releaseInMemory(file string) {
+	/* Close sentinel connection opened by keepInMemoryAlive, if any */
+	inMemoryKeepAlive.Lock()
+	defer inMemoryKeepAlive.Unlock()
+
+	if db, ok := inMemoryKeepAlive.dbs[file]; ok {
+		db.Close()
+		delete(inMemoryKeepAlive.dbs, file)
+	}
+}
+
+func (r *SQLiteRepository) acquire() {
+	/* Wait for a free database slot, bounds number of concurrent operations. */
+	r.sem <- struct{}{}
+}
+
+func (r *SQLiteRepository) release() {
+	/* Return database slot taken by acquire. */
+	<-r.sem
+}
+
+func (r *SQLiteRepository) timed(op string) func() {
+	/* Measure repository operation, returned function logs it when it took longer than
+	 * cfg.SlowQuery. Call as `defer r.timed("Op")()` after acquire, so waiting for a free
+	 * slot is not counted.
+	 */
+	threshold := r.cfg.SlowQuery
+	if threshold <= 0 {
+		return func() {}
+	}
+
+	start := time.Now()
+
+	return func() {
+		if elapsed := time.Since(start); elapsed >= threshold {
+			r.log.Warning(fmt.Sprintf("Slow database operation %s took %s, threshold %s.", op, elapsed, threshold))
+		}
+	}
+}
+
+func (r *SQLiteRepository) handle() *sql.DB {
+	/* Return current database handle, it may be replaced by Reconnect. */
+	r.conn.mu.RLock()
+	defer r.conn.mu.RUnlock()
+
+	return r.conn.db
+}
+
+func (r *SQLiteRepository) withBudget(budget *queryBudget) DatabaseRepo {
+	/* Return copy of repository charging its statements to budget, sharing handle and slots */
+	budgeted := *r
+	budgeted.budget = budget
+
+	return &budgeted
+}
+
+func (r *SQLiteRepository) exec(query string, args ...any) (sql.Result, error) {
+	/* Run standalone statement on current handle, retried while database is busy */
+	var result sql.Result
+
+	if err := r.budget.charge(true); err != nil {
+		return nil, err
+	}
+
+	err := r.retryBusy(func() (err error) {
+		result, err = r.handle().Exec(query, args...)
+		return err
+	})
+
+	return result, err
+}
+
+func (r *SQLiteRepository) query(query string, args ...any) (*sql.Rows, error) {
+	/* Run standalone query on current handle, retried while database is busy */
+	var rows *sql.Rows
+
+	if err := r.budget.charge(false); err != nil {
+		return nil, err
+	}
+
+	err := r.retryBusy(func() (err error) {
+		rows, err = r.handle().Query(query, args...)
+		return err
+	})
+
+	return rows, err
+}
+
+// retriedRow is a single row query, run by Scan and retried while database is busy.
+type retriedRow struct {
+	r     *SQLiteRepository
+	query string
+	args  []any
+}
+
+func (row retriedRow) Scan(dest ...any) error {
+	if err := row.r.budget.charge(false); err != nil {
+		return err
+	}
+
+	return row.r.retryBusy(func() error {
+		return row.r.handle().QueryRow(row.query, row.args...).Scan(dest...)
+	})
+}
+
+func (r *SQLiteRepository) queryRow(query string, args ...any) retriedRow {
+	/* Run standalone single row query on current handle once scanned, retried while database is busy */
+	return retriedRow{r: r, query: query, args: args}
+}
+
+func (r *SQLiteRepository) retryBusy(op func() error) error {
+	/* Repeat op up to cfg.SQLiteBusyRetries times while it fails with busy or locked
+	 * database, waiting cfg.SQLiteBusyBackoff doubled after every attempt. Other errors
+	 * are returned right away. Statements of transactions are not retried one by one,
+	 * transaction repeats the whole of it instead.
+	 */
+	backoff := r.cfg.SQLiteBusyBackoff
+
+	err := op()
+	for attempt := 1; attempt <= r.cfg.SQLiteBusyRetries && isBusyError(err); attempt++ {
+		r.log.Warning(fmt.Sprintf("Database busy, retry %d of %d in %s.", attempt, r.cfg.SQLiteBusyRetries, backoff))
+
+		time.Sleep(backoff)
+		backoff *= 2
+
+		err = op()
+	}
+
+	return err
+}
+
+func (r *SQLiteRepository) transaction(fn func(tx *sql.Tx) error) error {
+	/* Run fn within a transaction on current handle, commit it when fn succeeds and roll
+	 * it back otherwise. Whole transaction is repeated while database is busy, so fn must
+	 * not keep state of a failed attempt. Whole transaction is a single write of the budget.
+	 */
+	if err := r.budget.charge(true); err != nil {
+		return err
+	}
+
+	return r.retryBusy(func() error {
+		tx, err := r.handle().Begin()
+		if err != nil {
+			r.log.Error(err)
+			return err
+		}
+
+		if err = fn(tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if err = tx.Commit(); err != nil {
+			r.log.Error(err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+func isBusyError(err error) bool {
+	/* Check if error is SQLITE_BUSY or SQLITE_LOCKED, which go away once the other connection finishes */
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
 }
 
-func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
-	return &SQLiteRepository{
-		db:  db,
-		log: logger.NewConsoleLogger("SQLite", logger.INFO),
+func isInMemory(file string) bool {
+	/* Check if SQLite data source name points to in-memory database */
+	return strings.Contains(file, ":memory:") || strings.Contains(file, "mode=memory")
+}
+
+func databaseDir(file string) string {
+	/* Return directory holding database file of SQLite data source name, e.g. `file:data/events.db?_fk=1` */
+	file = strings.TrimPrefix(file, "file:")
+	if i := strings.IndexByte(file, '?'); i >= 0 {
+		file = file[:i]
 	}
+
+	return filepath.Dir(file)
+}
+
+func isConnectionError(err error) bool {
+	/* Check if error means that database handle or connection is no longer usable */
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		err.Error() == "sql: database is closed"
 }
 
-func (r *SQLiteRepository) insertEvent(e *EventData) (*EventData, error) {
-	/* Insert event to database. */
+func (r *SQLiteRepository) insertEvent(q queryer, e *EventData, onConflict string) (*EventData, bool, error) {
+	/* Insert event to database. Conflict clause, e.g. `ON CONFLICT (uuid) DO NOTHING`,
+	 * may leave the row out, returned bool tells whether it was created.
+	 */
 	var (
 		err            error
 		result         sql.Result
@@ -53,49 +400,64 @@ func (r *SQLiteRepository) insertEvent(e *EventData) (*EventData, error) {
 				version, uuid, title, 
 				start, end, address, 
 				info, reminder, done, 
-				important, urgent, source) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+				important, urgent, source,
+				category, color, updated_at,
+				content_hash, created_at, owner) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`
 	)
 
-	statement, err = r.db.Prepare(insertEventSQL)
+	statement, err = q.Prepare(insertEventSQL + onConflict + ";")
 	if err != nil {
 		r.log.Error(err)
-		return nil, err
+		return nil, false, err
 	}
 
-	start, _ := dateTimeToUnix(&e.Start)
-	end, _ := dateTimeToUnix(&e.End)
-	done := Btoi(e.Done)
-	important := Btoi(e.Important)
-	urgent := Btoi(e.Urgent)
+	start, _ := dateTimeToUnix(&e.Start, r.cfg.TimeZone)
+	end, _ := dateTimeToUnix(&e.End, r.cfg.TimeZone)
+	done := encodeBool(BackendSQLite, e.Done)
+	important := encodeBool(BackendSQLite, e.Important)
+	urgent := encodeBool(BackendSQLite, e.Urgent)
+
+	now := r.clock.Now().Unix()
 
-	result, err = statement.Exec(e.Version, e.UUID, e.Title, start, end, e.Address, e.Info, e.Reminder, done, important, urgent, e.Source)
+	address, info, err := r.storedFields(e)
 	if err != nil {
 		r.log.Error(err)
-		return nil, err
+		return nil, false, err
 	}
 
-	id, err := result.LastInsertId()
+	result, err = statement.Exec(e.Version, e.UUID, e.Title, start, end, address, info, e.Reminder, done, important, urgent, e.Source,
+		e.Category, e.Color, now, e.ContentHash(), now, e.Owner)
+	if err != nil {
+		r.log.Error(err)
+		return nil, false, err
+	}
 
+	affected, err := result.RowsAffected()
 	if err != nil {
-		r.log.Error("Failed to get LastID.", err)
+		r.log.Error(err)
+		return nil, false, err
+	}
 
-		return nil, err
+	if affected == 0 {
+		return e, false, nil
 	}
 
-	e.ID = id
+	id, err := result.LastInsertId()
 
-	err = r.updateStatus()
 	if err != nil {
-		r.log.Error(err)
-		return nil, err
+		r.log.Error("Failed to get LastID.", err)
+
+		return nil, false, err
 	}
 
-	return e, nil
+	e.ID = id
+
+	return e, true, nil
 }
 
-func (r *SQLiteRepository) updateEvent(e *EventData) (*EventData, error) {
+func (r *SQLiteRepository) updateEvent(q queryer, e *EventData) (*EventData, error) {
 	/* Update existing event with latest data */
 	var (
 		err            error
@@ -113,32 +475,36 @@ func (r *SQLiteRepository) updateEvent(e *EventData) (*EventData, error) {
 			done = ?, 
 			important = ?,
 			urgent = ?,
-			source = ? 
+			source = ?,
+			category = ?,
+			color = ?,
+			updated_at = ?,
+			content_hash = ?
 		WHERE
 			uuid = ?;
 		`
 	)
 
-	statement, err = r.db.Prepare(updateEventSQL)
+	statement, err = q.Prepare(updateEventSQL)
 	if err != nil {
 		r.log.Error(err)
 		return nil, err
 	}
 
-	start, _ := dateTimeToUnix(&e.Start)
-	end, _ := dateTimeToUnix(&e.End)
-	done := Btoi(e.Done)
-	important := Btoi(e.Important)
-	urgent := Btoi(e.Urgent)
+	start, _ := dateTimeToUnix(&e.Start, r.cfg.TimeZone)
+	end, _ := dateTimeToUnix(&e.End, r.cfg.TimeZone)
+	done := encodeBool(BackendSQLite, e.Done)
+	important := encodeBool(BackendSQLite, e.Important)
+	urgent := encodeBool(BackendSQLite, e.Urgent)
 
-	_, err = statement.Exec(e.Version, e.Title, start, end, e.Address, e.Info, e.Reminder, done, important, urgent, e.Source, e.UUID)
+	address, info, err := r.storedFields(e)
 	if err != nil {
 		r.log.Error(err)
-
 		return nil, err
 	}
 
-	err = r.updateStatus()
+	_, err = statement.Exec(e.Version, e.Title, start, end, address, info, e.Reminder, done, important, urgent, e.Source,
+		e.Category, e.Color, r.clock.Now().Unix(), e.ContentHash(), e.UUID)
 	if err != nil {
 		r.log.Error(err)
 
@@ -148,152 +514,150 @@ func (r *SQLiteRepository) updateEvent(e *EventData) (*EventData, error) {
 	return e, nil
 }
 
-func (r *SQLiteRepository) updateStatus() error {
-	/* Update status table */
-	var (
-		err             error
-		statement       *sql.Stmt
-		updateStatusSQL = `INSERT INTO status (timestamp, version) VALUES (?, ?)`
-	)
+func (r *SQLiteRepository) storedFields(e *EventData) (address, info string, err error) {
+	/* Return address and info as they are written to database, encrypted when the key is
+	 * configured. Event itself keeps plain text, so its content hash is not affected.
+	 */
+	key := r.cfg.FieldEncryptionKey
 
-	statement, err = r.db.Prepare(updateStatusSQL)
-	if err != nil {
-		r.log.Error(err)
-		return err
+	if info, err = encryptField(key, e.Info, e.UUID); err != nil {
+		return "", "", err
 	}
 
-	t := time.Now().Unix()
+	/* Address stored in plain text goes through encryptField without key, which refuses ciphertext lookalikes */
+	if !r.cfg.EncryptAddress {
+		key = nil
+	}
 
-	_, err = statement.Exec(t, VERSION)
-	if err != nil {
-		r.log.Error(err)
-		return err
+	if address, err = encryptField(key, e.Address, e.UUID); err != nil {
+		return "", "", err
 	}
 
-	return nil
+	return address, info, nil
 }
 
-func (r *SQLiteRepository) AddUser(user, password string, hashed bool) error {
-	/* Add new user to database */
-	var (
-		err           error
-		hash          string
-		statement     *sql.Stmt
-		insertUserSQL = "INSERT INTO users (username, password) VALUES (?, ?);"
-	)
-
-	if !hashed {
-		hash, err = hashPassword(password)
-		if err != nil {
-			r.log.Error(err)
-			return err
-		}
-	} else {
-		hash = password
+func (r *SQLiteRepository) readEvent(rows *sql.Rows) (EventData, error) {
+	/* Convert current row to EventData, decrypting fields stored by storedFields */
+	e, err := convertRawEventRecordToEventData(rows, BackendSQLite, r.cfg.TimeZone)
+	if err != nil {
+		return e, err
 	}
 
-	statement, err = r.db.Prepare(insertUserSQL)
-	if err != nil {
-		r.log.Error(err)
-		return err
+	if e.Address, err = decryptField(r.cfg.FieldEncryptionKey, e.Address, e.UUID); err != nil {
+		return e, fmt.Errorf("address of event %s: %w", e.UUID, err)
 	}
 
-	_, err = statement.Exec(user, hash)
-	if err != nil {
-		r.log.Error(err)
-		return err
+	if e.Info, err = decryptField(r.cfg.FieldEncryptionKey, e.Info, e.UUID); err != nil {
+		return e, fmt.Errorf("info of event %s: %w", e.UUID, err)
 	}
 
-	return nil
+	return e, nil
 }
 
-func (r *SQLiteRepository) AuthenticateUser(username, password string) (bool, error) {
-	/* Authenticate user  */
+func (r *SQLiteRepository) upsertEvent(q queryer, e *EventData) (*EventData, string, error) {
+	/* Insert new event, or update existing one with the same UUID.
+	 * Returned action is AuditActionInsert or AuditActionUpdate, empty if database content was not changed.
+	 */
 	var (
-		err  error
-		rows *sql.Rows
-		user User
+		err     error
+		dbEvent EventData
 	)
 
-	rows, err = r.db.Query("SELECT username, password FROM users WHERE username = ?;", username)
+	e.Normalize()
+
+	rows, err := q.Query(selectEventsSQL+" WHERE uuid = ?", e.UUID)
 	if err != nil {
 		r.log.Error(err)
-		return false, err
+		return e, "", err
 	}
 
-	for rows.Next() {
-		if err := rows.Scan(&user.Username, &user.Password); err != nil {
+	if rows.Next() {
+		/* Event exist in database. Check if update is needed */
+		dbEvent, err = r.readEvent(rows)
+		if err != nil {
 			r.log.Error(err)
-			return false, err
+			rows.Close()
+
+			return e, "", err
 		}
-	}
 
-	return checkPasswordHash(password, user.Password), nil
-}
+		rows.Close()
 
-func (r *SQLiteRepository) Close() {
-	/* Cleanup SQLiteRepository resources */
-	r.log.Info("Closing database.")
-	r.db.Close()
-}
+		if err = e.Validate(r.cfg); err != nil {
+			return e, "", err
+		}
 
-func (r *SQLiteRepository) DeleteEvent(e *EventData) (bool, error) {
-	/* Delete event based on Event UUID */
-	var (
-		deleteEventSQL = "DELETE FROM events WHERE uuid = ?;"
-		err            error
-		statement      *sql.Stmt
-	)
+		e.ID = dbEvent.ID
 
-	statement, err = r.db.Prepare(deleteEventSQL)
-	if err != nil {
-		r.log.Error(err)
-		return false, err
+		/* Check if passed event has some changes that requires update */
+		if dbEvent.Sha256() == e.Sha256() {
+			return e, "", nil
+		}
+
+		//nolint:govet //Event returned is same event that is passed with additional data like ID
+		e, err := r.updateEvent(q, e)
+		if err != nil {
+			r.log.Error(err)
+			return e, "", err
+		}
+
+		return e, AuditActionUpdate, nil
+	}
+
+	rows.Close()
+
+	if err = e.ValidateNew(r.cfg); err != nil {
+		return e, "", err
 	}
 
-	_, err = statement.Exec(e.UUID)
+	if err = r.checkNewEvent(q, e); err != nil {
+		return e, "", err
+	}
+
+	e, _, err = r.insertEvent(q, e, "")
 	if err != nil {
-		r.log.Error(err)
-		return false, err
+		return e, "", err
 	}
 
-	return true, err
+	return e, AuditActionInsert, nil
 }
 
-func (r *SQLiteRepository) GetAllEvents() ([]EventData, error) {
-	/* Return result events present in database. */
-	var (
-		result []EventData
-	)
+func (r *SQLiteRepository) checkNewEvent(q queryer, e *EventData) error {
+	/* Check rules applying only to events not stored yet, updates are let through */
 
-	rows, err := r.db.Query("SELECT * FROM events")
-	if err != nil {
-		r.log.Error(err)
-		return nil, err
+	/* Re-imports may carry the same event under a new UUID, keep the stored copy only */
+	if r.cfg.ImportDedup {
+		duplicate, err := r.findEventByContentHash(q, e.ContentHash())
+		if err == nil {
+			r.log.Info(fmt.Sprintf("Skipping event %s, it duplicates content of event %s.", e.UUID, duplicate.UUID))
+			return fmt.Errorf("%w: content equal to event %s", ErrDuplicateEvent, duplicate.UUID)
+		} else if !errors.Is(err, ErrEventNotFound) {
+			return err
+		}
 	}
 
-	defer rows.Close()
+	if r.cfg.MaxEventsPerUser > 0 && e.Owner != "" {
+		var owned int
 
-	for rows.Next() {
-		e, err := convertRawEventRecordToEventData(rows)
+		err := q.QueryRow("SELECT COUNT(*) FROM events WHERE owner = ?;", e.Owner).Scan(&owned)
 		if err != nil {
 			r.log.Error(err)
-			continue
+			return err
 		}
 
-		result = append(result, e)
+		if owned >= r.cfg.MaxEventsPerUser {
+			return fmt.Errorf("%w: %s already owns %d events", ErrQuotaExceeded, e.Owner, owned)
+		}
 	}
 
-	return result, nil
+	return nil
 }
 
-func (r *SQLiteRepository) GetEventsByTimeRange(start, end int64) ([]EventData, error) {
-	/* Return result events present in database listed by provided time range. */
-	var (
-		result []EventData
-	)
+func (r *SQLiteRepository) queryChanges(q queryer, action, changeSQL string, args ...any) ([]EventChange, error) {
+	/* Run statement returning UUIDs of affected events and describe them as changes of the action */
+	var changes []EventChange
 
-	rows, err := r.db.Query("SELECT * FROM events WHERE end >= ? AND start <= ?", start, end)
+	rows, err := q.Query(changeSQL, args...)
 	if err != nil {
 		r.log.Error(err)
 		return nil, err
@@ -302,119 +666,2292 @@ func (r *SQLiteRepository) GetEventsByTimeRange(start, end int64) ([]EventData,
 	defer rows.Close()
 
 	for rows.Next() {
-		e, err := convertRawEventRecordToEventData(rows)
-		if err != nil {
+		change := EventChange{Action: action}
+
+		if err = rows.Scan(&change.UUID); err != nil {
 			r.log.Error(err)
-			continue
+			return nil, err
 		}
 
-		result = append(result, e)
+		changes = append(changes, change)
 	}
 
-	return result, nil
-}
+	if err = rows.Err(); err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
 
-func (r *SQLiteRepository) GetEventByUUID(uuid string) (EventData, error) {
-	/* Return events based on UUID. */
-	rows, err := r.db.Query("SELECT * FROM events WHERE uuid = ?", uuid)
+	return changes, nil
+}
 
-	if err != nil {
-		return EventData{Common: Common{Type: EventDataStructName}}, err
+func changedUUIDs(changes []EventChange) []string {
+	/* UUIDs of events affected by the changes, in the order the statement returned them */
+	uuids := make([]string, len(changes))
+	for i, change := range changes {
+		uuids[i] = change.UUID
 	}
 
-	defer rows.Close()
+	return uuids
+}
+
+func (r *SQLiteRepository) deleteAttachments(q queryer, where string, args ...any) ([]string, error) {
+	/* Forget attachments of events being deleted and return keys of their blobs, which
+	 * are removed by deleteBlobs once the transaction commits.
+	 */
+	var keys []string
+
+	//nolint:gosec // Condition is a constant of the caller, values are passed as arguments
+	rows, err := q.Query("DELETE FROM attachments WHERE "+where+" RETURNING blob_key;", args...)
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+
+		if err = rows.Scan(&key); err != nil {
+			r.log.Error(err)
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *SQLiteRepository) deleteBlobs(keys []string) {
+	/* Remove blobs of deleted attachments, failure is logged only as the rows are already gone */
+	if r.blobs == nil {
+		return
+	}
+
+	for _, key := range keys {
+		if err := r.blobs.Delete(key); err != nil {
+			r.log.Warning("Failed to delete attachment blob ", key, ". ", err)
+		}
+	}
+}
+
+func (r *SQLiteRepository) updateStatus() error {
+	/* Update status table */
+	updateStatusSQL := `INSERT INTO status (timestamp, version) VALUES (?, ?)`
+
+	t := r.clock.Now().Unix()
+
+	_, err := r.exec(updateStatusSQL, t, VERSION)
+	if err != nil {
+		r.log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) AddUser(user, password string, hashed bool) error {
+	/* Add new user to database */
+	return r.storeUser("INSERT INTO users (username, password) VALUES (?, ?);", user, password, hashed)
+}
+
+func (r *SQLiteRepository) UpsertUser(user, password string, hashed bool) error {
+	/* Add new user to database, or update password of already existing one */
+	return r.storeUser(`
+		INSERT INTO users (username, password) VALUES (?, ?)
+		ON CONFLICT (username) DO UPDATE SET password = excluded.password;`, user, password, hashed)
+}
+
+func (r *SQLiteRepository) storeUser(storeUserSQL, user, password string, hashed bool) error {
+	/* Execute provided user statement with hashed password */
+	var (
+		err  error
+		hash string
+	)
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("storeUser")()
+
+	if err = r.HealthCheck(); err != nil {
+		return err
+	}
+
+	if !hashed {
+		/* Pre-hashed passwords (e.g. admin bootstrap) can not be checked */
+		err = validatePasswordStrength(password, r.cfg)
+		if err != nil {
+			r.log.Warning(err)
+			return err
+		}
+
+		var hasher PasswordHasher
+
+		hasher, err = passwordHasher(r.cfg.PasswordAlgo)
+		if err != nil {
+			r.log.Error(err)
+			return err
+		}
+
+		hash, err = hasher.Hash(password)
+		if err != nil {
+			r.log.Error(err)
+			return err
+		}
+	} else {
+		hash = password
+	}
+
+	_, err = r.exec(storeUserSQL, user, hash)
+	if err != nil {
+		r.log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) AuthenticateUser(username, password string) (bool, error) {
+	/* Authenticate user, password stored using other than configured algorithm
+	 * is hashed again once it is known to be correct.
+	 */
+	var (
+		err    error
+		hasher PasswordHasher
+		rows   *sql.Rows
+		user   User
+	)
+
+	hasher, err = passwordHasher(r.cfg.PasswordAlgo)
+	if err != nil {
+		r.log.Error(err)
+		return false, err
+	}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("AuthenticateUser")()
+
+	if err = r.HealthCheck(); err != nil {
+		return false, err
+	}
+
+	rows, err = r.query("SELECT username, password FROM users WHERE username = ?;", username)
+	if err != nil {
+		r.log.Error(err)
+		return false, err
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(&user.Username, &user.Password); err != nil {
+			r.log.Error(err)
+			return false, err
+		}
+	}
+
+	valid, rehash := verifyPassword(password, user.Password, hasher)
+	if rehash {
+		r.rehashPassword(hasher, username, password)
+	}
+
+	return valid, nil
+}
+
+func (r *SQLiteRepository) rehashPassword(hasher PasswordHasher, username, password string) {
+	/* Store password using preferred algorithm, failure is logged only as the old hash still works */
+	hash, err := hasher.Hash(password)
+	if err != nil {
+		r.log.Error(err)
+		return
+	}
+
+	_, err = r.exec("UPDATE users SET password = ? WHERE username = ?;", hash, username)
+	if err != nil {
+		r.log.Error(err)
+		return
+	}
+
+	r.log.Info("Password of ", username, " rehashed using ", hasher.Name())
+}
+
+func (r *SQLiteRepository) Backup(path string) error {
+	/* Write consistent snapshot of the database into a new file at path.
+	 * VACUUM INTO runs as a single read transaction, so the slot is held
+	 * only while the snapshot is written, not while it is sent anywhere.
+	 */
+	if isInMemory(r.cfg.DatabaseFile) {
+		return ErrBackupNotSupported
+	}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("Backup")()
+
+	if err := r.HealthCheck(); err != nil {
+		return err
+	}
+
+	if _, err := r.exec("VACUUM INTO ?;", path); err != nil {
+		r.log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) Close() {
+	/* Cleanup SQLiteRepository resources.
+	 * Shared-cache in-memory database is kept alive by its sentinel connection,
+	 * use Shutdown to drop it as well.
+	 */
+	r.log.Info("Closing database.")
+	r.handle().Close()
+}
+
+func (r *SQLiteRepository) Shutdown() {
+	/* Close database and release keep-alive connection of in-memory database,
+	 * its data is gone once no other handle is open.
+	 */
+	r.Close()
+	releaseInMemory(r.cfg.DatabaseFile)
+}
+
+func (r *SQLiteRepository) CountEventsByDay(start, end int64) ([]DayCount, error) {
+	/* Return number of events overlapping every day within provided time range.
+	 * Day buckets are computed in configured time zone, so they respect DST changes,
+	 * and are joined with events in a single grouped query. Days without events have zero count.
+	 */
+	var (
+		args    []any
+		buckets []string
+		result  []DayCount
+	)
+
+	loc, err := r.cfg.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	first := time.Unix(start, 0).In(loc)
+	last := time.Unix(end, 0).In(loc)
+
+	for day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc); !day.After(last); day = day.AddDate(0, 0, 1) {
+		if len(buckets) == EventCountsMaxDays {
+			return nil, fmt.Errorf("time range exceeds %d days", EventCountsMaxDays)
+		}
+
+		buckets = append(buckets, "(?, ?, ?)")
+		args = append(args, day.Format("2006-01-02"), day.Unix(), day.AddDate(0, 0, 1).Unix())
+	}
+
+	if len(buckets) == 0 {
+		return result, nil
+	}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("CountEventsByDay")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	//nolint:gosec // Only placeholders are concatenated, values are passed as arguments
+	rows, err := r.query(`
+		WITH days (day, day_start, day_end) AS (VALUES `+strings.Join(buckets, ", ")+`)
+		SELECT days.day, COUNT(events.id)
+		FROM days LEFT JOIN events ON events.start < days.day_end AND events.end >= days.day_start
+		GROUP BY days.day
+		ORDER BY days.day;`, args...)
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var dc DayCount
+
+		if err := rows.Scan(&dc.Day, &dc.Count); err != nil {
+			r.log.Error(err)
+			return nil, err
+		}
+
+		result = append(result, dc)
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteRepository) CountEventsChangedSince(since int64) (int64, error) {
+	/* Return number of events created or modified at, or after provided Unix time */
+	var count int64
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("CountEventsChangedSince")()
+
+	if err := r.HealthCheck(); err != nil {
+		return 0, err
+	}
+
+	err := r.queryRow("SELECT COUNT(*) FROM events WHERE updated_at >= ?;", since).Scan(&count)
+	if err != nil {
+		r.log.Error(err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *SQLiteRepository) DeleteEvent(e *EventData) (bool, error) {
+	/* Delete event based on Event UUID together with its attachment */
+	r.acquire()
+	defer r.release()
+	defer r.timed("DeleteEvent")()
+
+	if err := r.HealthCheck(); err != nil {
+		return false, err
+	}
+
+	var (
+		deleted int64
+		keys    []string
+	)
+
+	err := r.transaction(func(tx *sql.Tx) error {
+		result, err := tx.Exec("DELETE FROM events WHERE uuid = ?;", e.UUID)
+		if err != nil {
+			r.log.Error(err)
+			return err
+		}
+
+		if deleted, err = result.RowsAffected(); err != nil {
+			r.log.Error(err)
+			return err
+		}
+
+		keys, err = r.deleteAttachments(tx, "event_uuid = ?", e.UUID)
+
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	r.deleteBlobs(keys)
+
+	if deleted > 0 {
+		r.bus.publish(EventChange{UUID: e.UUID, Action: AuditActionDelete})
+	}
+
+	return true, nil
+}
+
+func (r *SQLiteRepository) DeleteAttachment(uuid string) (Attachment, error) {
+	/* Forget attachment of the event and return it, so caller can delete its blob.
+	 * Returns ErrAttachmentNotFound when the event has no attachment.
+	 */
+	a := Attachment{Common: Common{Type: AttachmentStructName}, EventUUID: uuid}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("DeleteAttachment")()
+
+	if err := r.HealthCheck(); err != nil {
+		return Attachment{}, err
+	}
+
+	err := r.queryRow("DELETE FROM attachments WHERE event_uuid = ? RETURNING blob_key, content_type, size;", uuid).
+		Scan(&a.Key, &a.ContentType, &a.Size)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Attachment{}, fmt.Errorf("%w: %s", ErrAttachmentNotFound, uuid)
+	} else if err != nil {
+		r.log.Error(err)
+		return Attachment{}, err
+	}
+
+	return a, nil
+}
+
+func (r *SQLiteRepository) DeleteEvents(uuids []string) ([]string, error) {
+	/* Delete events with provided UUIDs and their attachments within a single transaction.
+	 * Returns UUIDs of events actually removed, unknown UUIDs are ignored.
+	 */
+	if len(uuids) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, len(uuids))
+	for i, uuid := range uuids {
+		args[i] = uuid
+	}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("DeleteEvents")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	var (
+		changes []EventChange
+		keys    []string
+	)
+
+	placeholders := "(?" + strings.Repeat(", ?", len(uuids)-1) + ")"
+
+	err := r.transaction(func(tx *sql.Tx) (err error) {
+		//nolint:gosec // Only placeholders are concatenated, values are passed as arguments
+		changes, err = r.queryChanges(tx, AuditActionDelete,
+			"DELETE FROM events WHERE uuid IN "+placeholders+" RETURNING uuid;", args...)
+		if err != nil {
+			return err
+		}
+
+		keys, err = r.deleteAttachments(tx, "event_uuid IN "+placeholders, args...)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.deleteBlobs(keys)
+	r.bus.publish(changes...)
+
+	deleted := changedUUIDs(changes)
+	if len(deleted) > 0 {
+		err = r.updateStatus()
+		if err != nil {
+			r.log.Error(err)
+			return deleted, err
+		}
+	}
+
+	return deleted, nil
+}
+
+func (r *SQLiteRepository) DeleteEventsEndedBefore(cutoff int64) (int64, error) {
+	/* Delete events which ended before cutoff with their attachments and return number of removed events.
+	 * Cutoff in the future is refused, so events which did not end yet are never removed.
+	 */
+	if cutoff > r.clock.Now().Unix() {
+		return 0, fmt.Errorf("cutoff %d is in the future", cutoff)
+	}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("DeleteEventsEndedBefore")()
+
+	if err := r.HealthCheck(); err != nil {
+		return 0, err
+	}
+
+	var (
+		changes []EventChange
+		keys    []string
+	)
+
+	err := r.transaction(func(tx *sql.Tx) (err error) {
+		/* Attachments go first, while their events can still be selected */
+		keys, err = r.deleteAttachments(tx, "event_uuid IN (SELECT uuid FROM events WHERE end < ?)", cutoff)
+		if err != nil {
+			return err
+		}
+
+		changes, err = r.queryChanges(tx, AuditActionDelete, "DELETE FROM events WHERE end < ? RETURNING uuid;", cutoff)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	r.deleteBlobs(keys)
+	r.bus.publish(changes...)
+
+	deleted := int64(len(changes))
+	if deleted > 0 {
+		err = r.updateStatus()
+		if err != nil {
+			r.log.Error(err)
+			return deleted, err
+		}
+	}
+
+	return deleted, nil
+}
+
+func (r *SQLiteRepository) GetLocations(prefix string, limit int) ([]string, error) {
+	/* Return at most limit distinct non-empty addresses starting with prefix, ordered alphabetically */
+	var result []string
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetLocations")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	if r.cfg.EncryptAddress {
+		return r.getEncryptedLocations(prefix, limit)
+	}
+
+	rows, err := r.query(`
+		SELECT DISTINCT address FROM events
+		WHERE address != '' AND address LIKE ? || '%' ESCAPE '\'
+		ORDER BY address LIMIT ?`, escapeLike(prefix), limit)
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var address string
+
+		if err := rows.Scan(&address); err != nil {
+			r.log.Error(err)
+			return nil, err
+		}
+
+		result = append(result, address)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *SQLiteRepository) getEncryptedLocations(prefix string, limit int) ([]string, error) {
+	/* Encrypted addresses can not be matched nor deduplicated by SQL, every one is decrypted instead */
+	var result []string
+
+	rows, err := r.query("SELECT uuid, address FROM events WHERE address != ''")
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+
+	for rows.Next() {
+		var uuid, address string
+
+		if err = rows.Scan(&uuid, &address); err != nil {
+			r.log.Error(err)
+			return nil, err
+		}
+
+		if address, err = decryptField(r.cfg.FieldEncryptionKey, address, uuid); err != nil {
+			r.log.Error(err)
+			continue
+		}
+
+		/* Case insensitive, same as LIKE of ASCII text */
+		if !seen[address] && strings.HasPrefix(strings.ToLower(address), strings.ToLower(prefix)) {
+			seen[address] = true
+			result = append(result, address)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	sort.Strings(result)
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteRepository) FindEventsByUUIDPrefix(prefix string) ([]EventData, error) {
+	/* Return events which UUID starts with provided prefix. */
+	return r.queryEvents("FindEventsByUUIDPrefix", EventQueryOptions{UUIDPrefix: prefix, SortBy: "uuid"})
+}
+
+func (r *SQLiteRepository) FindEventByContentHash(hash string) (EventData, error) {
+	/* Return the oldest event with provided EventData.ContentHash, ErrEventNotFound when there is none. */
+	r.acquire()
+	defer r.release()
+	defer r.timed("FindEventByContentHash")()
+
+	if err := r.HealthCheck(); err != nil {
+		return EventData{}, err
+	}
+
+	return r.findEventByContentHash(r.handle(), hash)
+}
+
+func (r *SQLiteRepository) findEventByContentHash(q queryer, hash string) (EventData, error) {
+	/* Look event up by content hash, caller is responsible for acquiring the database */
+	rows, err := q.Query(selectEventsSQL+" WHERE content_hash = ? ORDER BY id LIMIT 1", hash)
+	if err != nil {
+		r.log.Error(err)
+		return EventData{}, err
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		e, err := r.readEvent(rows)
+		if err != nil {
+			r.log.Error(err)
+			return EventData{}, err
+		}
+
+		return e, nil
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.Error(err)
+		return EventData{}, err
+	}
+
+	return EventData{}, fmt.Errorf("%w: content hash %s", ErrEventNotFound, hash)
+}
+
+// DedupEventsSchemaVersion is the first schema version with unique event UUIDs.
+// Databases migrated by older versions may hold several copies of one UUID.
+const DedupEventsSchemaVersion = 3
+
+func (r *SQLiteRepository) dedupEvents() error {
+	/* Upserts never stored one UUID twice, but nothing enforced it before DedupEventsSchemaVersion.
+	 * Keep the latest copy of every event, so insert-if-absent can rely on ON CONFLICT (uuid).
+	 * Runs only while migrating from older schema; removed copies are moved to events_duplicates
+	 * within the same transaction, so nothing is lost if the choice of the latest copy was wrong.
+	 */
+	var (
+		version    int
+		duplicates int64
+	)
+
+	if err := r.queryRow("PRAGMA user_version;").Scan(&version); err != nil {
+		return err
+	}
+
+	if version >= DedupEventsSchemaVersion {
+		return nil
+	}
+
+	return r.transaction(func(tx *sql.Tx) error {
+		const duplicatesSQL = "FROM events WHERE id NOT IN (SELECT MAX(id) FROM events GROUP BY uuid)"
+
+		if err := tx.QueryRow("SELECT COUNT(*) " + duplicatesSQL).Scan(&duplicates); err != nil {
+			return err
+		}
+
+		if duplicates == 0 {
+			return nil
+		}
+
+		r.log.Warning("Moving ", duplicates, " duplicated events to table 'events_duplicates'.")
+
+		for _, query := range []string{
+			"CREATE TABLE IF NOT EXISTS events_duplicates AS SELECT * FROM events WHERE 0",
+			"INSERT INTO events_duplicates SELECT * " + duplicatesSQL,
+			"DELETE " + duplicatesSQL,
+		} {
+			if _, err := tx.Exec(query); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *SQLiteRepository) backfillContentHashes() error {
+	/* Compute content hash of events stored before the column was introduced */
+	rows, err := r.query(selectEventsSQL + " WHERE content_hash IS NULL OR content_hash = ''")
+	if err != nil {
+		return err
+	}
+
+	hashes := make(map[int64]string)
+
+	for rows.Next() {
+		e, err := r.readEvent(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		hashes[e.ID] = e.ContentHash()
+	}
+
+	rows.Close()
+
+	for id, hash := range hashes {
+		if _, err = r.exec("UPDATE events SET content_hash = ? WHERE id = ?;", hash, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) FindOverlapping(start, end int64, excludeUUID string) ([]EventData, error) {
+	/* Return events overlapping provided time span ordered by start, except event with excludeUUID.
+	 * Span boundaries are inclusive, same as in GetEventsByTimeRange.
+	 */
+	return r.queryEvents("FindOverlapping", EventQueryOptions{Start: &start, End: &end, ExcludeUUID: excludeUUID})
+}
+
+func (r *SQLiteRepository) GetAllEvents() ([]EventData, error) {
+	/* Return result events present in database. */
+	var (
+		result []EventData
+	)
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetAllEvents")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.query(selectEventsSQL)
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := r.readEvent(rows)
+		if err != nil {
+			r.log.Error(err)
+			continue
+		}
+
+		result = append(result, e)
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteRepository) ForEachEvent(batchSize int, fn func(EventData) error) error {
+	/* Call fn for every event ordered by ID without loading the whole table, for exports
+	 * and reindexing. Events are read batchSize at a time, every batch continues after
+	 * the last ID seen instead of using OFFSET, so rows are neither skipped nor repeated
+	 * when the table changes meanwhile. Database slot is released while fn runs, so fn may
+	 * use the repository. Iteration stops with the first error returned by fn.
+	 */
+	if batchSize < 1 {
+		return fmt.Errorf("%w: batch size must be positive", ErrInvalidQuery)
+	}
+
+	var after int64
+
+	for {
+		batch, last, scanned, err := r.eventsAfter(after, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range batch {
+			if err = fn(e); err != nil {
+				return err
+			}
+		}
+
+		/* Short batch is the last one, unreadable rows without ID can not move the cursor */
+		if scanned < batchSize || last <= after {
+			return nil
+		}
+
+		after = last
+	}
+}
+
+func (r *SQLiteRepository) eventsAfter(after int64, limit int) ([]EventData, int64, int, error) {
+	/* Return up to limit events with ID above after, the highest ID and number of rows read.
+	 * Rows which can not be read are logged and left out, but still count and move the cursor.
+	 */
+	var (
+		result  []EventData
+		last    = after
+		scanned int
+	)
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("ForEachEvent")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, 0, 0, err
+	}
+
+	rows, err := r.query(selectEventsSQL+" WHERE id > ? ORDER BY id LIMIT ?", after, limit)
+	if err != nil {
+		r.log.Error(err)
+		return nil, 0, 0, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		scanned++
+
+		e, err := r.readEvent(rows)
+		if e.ID > last {
+			last = e.ID
+		}
+
+		if err != nil {
+			r.log.Error(err)
+			continue
+		}
+
+		result = append(result, e)
+	}
+
+	return result, last, scanned, rows.Err()
+}
+
+func (r *SQLiteRepository) GetAllEventsFiltered(opts EventQueryOptions) ([]EventData, error) {
+	/* Return events matching options, ordered and paginated as requested. */
+	return r.queryEvents("GetAllEventsFiltered", opts)
+}
+
+func (r *SQLiteRepository) queryEvents(name string, opts EventQueryOptions) ([]EventData, error) {
+	/* Run query of buildEventQuery, shared by every method listing, searching or filtering
+	 * events. Name identifies the caller in slow query logs. Event which can not be read
+	 * fails the whole call, so no event silently goes missing from the result.
+	 */
+	var (
+		result []EventData
+	)
+
+	query, args, err := buildEventQuery(BackendSQLite, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed(name)()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.query(query, args...)
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := r.readEvent(rows)
+		if err != nil {
+			r.log.Error(err)
+			return nil, err
+		}
+
+		result = append(result, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteRepository) CountEventsFiltered(opts EventQueryOptions) (int64, error) {
+	/* Return number of events GetAllEventsFiltered would return without pagination. */
+	var count int64
+
+	query, args := buildCountQuery(BackendSQLite, opts)
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("CountEventsFiltered")()
+
+	if err := r.HealthCheck(); err != nil {
+		return 0, err
+	}
+
+	if err := r.queryRow(query, args...).Scan(&count); err != nil {
+		r.log.Error(err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func buildEventQuery(backend string, opts EventQueryOptions) (string, []any, error) {
+	/* Turn options into SELECT of events with placeholders for every value.
+	 * Only whitelisted column names and fixed keywords are put into the SQL text.
+	 */
+	column, ok := eventSortColumns[strings.ToLower(strings.TrimSpace(opts.SortBy))]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: can not sort by %q", ErrInvalidQuery, opts.SortBy)
+	}
+
+	if opts.Limit < 0 || opts.Offset < 0 {
+		return "", nil, fmt.Errorf("%w: limit and offset can not be negative", ErrInvalidQuery)
+	}
+
+	where, args := eventFilter(backend, opts)
+	query := selectEventsSQL + where
+
+	direction := "ASC"
+	if opts.Descending {
+		direction = "DESC"
+	}
+
+	/* UUID breaks ties, so pages do not overlap when sort values repeat */
+	query += fmt.Sprintf(" ORDER BY %s %s, uuid %s", column, direction, direction)
+
+	/* SQLite requires LIMIT to use OFFSET, -1 stands for no limit */
+	if opts.Limit > 0 || opts.Offset > 0 {
+		limit := opts.Limit
+		if limit == 0 {
+			limit = -1
+		}
+
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, opts.Offset)
+	}
+
+	return query, args, nil
+}
+
+func buildCountQuery(backend string, opts EventQueryOptions) (string, []any) {
+	/* Count events buildEventQuery would select, ordering and pagination do not apply */
+	where, args := eventFilter(backend, opts)
+
+	return "SELECT COUNT(*) FROM events" + where, args
+}
+
+func eventFilter(backend string, opts EventQueryOptions) (string, []any) {
+	/* Return WHERE clause of filtering options shared by buildEventQuery and buildCountQuery,
+	 * empty when no filter is set.
+	 */
+	var (
+		conditions []string
+		args       []any
+	)
+
+	for _, flag := range []struct {
+		column string
+		value  *bool
+	}{
+		{"done", opts.Done},
+		{"important", opts.Important},
+		{"urgent", opts.Urgent},
+	} {
+		if flag.value != nil {
+			conditions = append(conditions, flag.column+" = ?")
+			args = append(args, encodeBool(backend, *flag.value))
+		}
+	}
+
+	if source := strings.ToUpper(strings.TrimSpace(opts.Source)); source != "" {
+		conditions = append(conditions, "source = ?")
+		args = append(args, source)
+	}
+
+	/* Events overlapping the range, as selected by GetEventsByTimeRange */
+	if opts.Start != nil {
+		conditions = append(conditions, "end >= ?")
+		args = append(args, *opts.Start)
+	}
+
+	if opts.End != nil {
+		conditions = append(conditions, "start <= ?")
+		args = append(args, *opts.End)
+	}
+
+	if text := strings.TrimSpace(opts.Text); text != "" {
+		conditions = append(conditions, `title LIKE '%' || ? || '%' ESCAPE '\'`)
+		args = append(args, escapeLike(text))
+	}
+
+	if opts.Title != nil {
+		conditions = append(conditions, "title = ?")
+		args = append(args, strings.TrimSpace(*opts.Title))
+	}
+
+	if opts.Category != nil {
+		conditions = append(conditions, "category = ?")
+		args = append(args, strings.TrimSpace(*opts.Category))
+	}
+
+	if opts.UUIDPrefix != "" {
+		conditions = append(conditions, `uuid LIKE ? || '%' ESCAPE '\'`)
+		args = append(args, escapeLike(opts.UUIDPrefix))
+	}
+
+	if opts.ExcludeUUID != "" {
+		conditions = append(conditions, "uuid != ?")
+		args = append(args, opts.ExcludeUUID)
+	}
+
+	if opts.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *opts.CreatedFrom)
+	}
+
+	if opts.CreatedTo != nil {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, *opts.CreatedTo)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r *SQLiteRepository) GetEventsByCategory(category string) ([]EventData, error) {
+	/* Return events with provided category, ordered by start. */
+	return r.queryEvents("GetEventsByCategory", EventQueryOptions{Category: &category})
+}
+
+func (r *SQLiteRepository) GetEventsByUUIDs(uuids []string) ([]EventData, error) {
+	/* Return stored events with provided UUIDs in a single query, unknown UUIDs are ignored. */
+	var (
+		result []EventData
+	)
+
+	if len(uuids) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, len(uuids))
+	for i, uuid := range uuids {
+		args[i] = uuid
+	}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetEventsByUUIDs")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	//nolint:gosec // Only placeholders are concatenated, values are passed as arguments
+	rows, err := r.query(selectEventsSQL+" WHERE uuid IN (?"+strings.Repeat(", ?", len(uuids)-1)+")", args...)
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := r.readEvent(rows)
+		if err != nil {
+			r.log.Error(err)
+			continue
+		}
+
+		result = append(result, e)
+	}
+
+	return result, rows.Err()
+}
+
+func (r *SQLiteRepository) GetEventsByTitle(title string) ([]EventData, error) {
+	/* Return events which title equals provided one exactly, ordered by start. */
+	return r.queryEvents("GetEventsByTitle", EventQueryOptions{Title: &title})
+}
+
+func (r *SQLiteRepository) GetEventsByPriority(important, urgent bool, start, end int64) ([]EventData, error) {
+	/* Return events of one Eisenhower matrix quadrant within provided time range, ordered by start. */
+	return r.queryEvents("GetEventsByPriority", EventQueryOptions{Important: &important, Urgent: &urgent, Start: &start, End: &end})
+}
+
+func (r *SQLiteRepository) GetEventsByTimeRange(start, end int64) ([]EventData, error) {
+	/* Return result events present in database listed by provided time range, ordered by start. */
+	return r.queryEvents("GetEventsByTimeRange", EventQueryOptions{Start: &start, End: &end})
+}
+
+func (r *SQLiteRepository) GetEventsCreatedBetween(start, end int64) ([]EventData, error) {
+	/* Return events added to database within provided time range, ordered by creation.
+	 * Unlike GetEventsByTimeRange it does not look at event start and end at all.
+	 */
+	return r.queryEvents("GetEventsCreatedBetween", EventQueryOptions{CreatedFrom: &start, CreatedTo: &end, SortBy: "created_at"})
+}
+
+func (r *SQLiteRepository) GetEventByUUID(uuid string) (EventData, error) {
+	/* Return event based on UUID, ErrEventNotFound when there is none.
+	 * Event is left zero whenever error is returned.
+	 */
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetEventByUUID")()
+
+	if err := r.HealthCheck(); err != nil {
+		return EventData{}, err
+	}
+
+	rows, err := r.query(selectEventsSQL+" WHERE uuid = ?", uuid)
+
+	if err != nil {
+		r.log.Error(err)
+		return EventData{}, err
+	}
+
+	defer rows.Close()
 
 	if rows.Next() {
-		e, err := convertRawEventRecordToEventData(rows)
+		e, err := r.readEvent(rows)
+		if err != nil {
+			r.log.Error(err)
+			return EventData{}, err
+		}
+
+		return e, nil
+	}
+
+	/* Iteration may stop because of an error, not only because there are no rows */
+	if err := rows.Err(); err != nil {
+		r.log.Error(err)
+		return EventData{}, err
+	}
+
+	return EventData{}, fmt.Errorf("%w: %s", ErrEventNotFound, uuid)
+}
+
+func (r *SQLiteRepository) GetEventDetail(uuid string) (EventDetail, error) {
+	/* Return event with its bookkeeping metadata, ErrEventNotFound when there is none. */
+	var detail EventDetail
+
+	event, err := r.GetEventByUUID(uuid)
+	if err != nil {
+		return EventDetail{}, err
+	}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetEventDetail")()
+
+	if err := r.HealthCheck(); err != nil {
+		return EventDetail{}, err
+	}
+
+	err = r.queryRow("SELECT created_at, updated_at, last_modified_by FROM events WHERE uuid = ?;", uuid).
+		Scan(&detail.CreatedAt, &detail.UpdatedAt, &detail.LastModifiedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		/* Event was deleted in the meantime */
+		return EventDetail{}, fmt.Errorf("%w: %s", ErrEventNotFound, uuid)
+	} else if err != nil {
+		r.log.Error(err)
+		return EventDetail{}, err
+	}
+
+	detail.EventData = event
+
+	return detail, nil
+}
+
+func (r *SQLiteRepository) GetSchemaVersion() (int, error) {
+	/* Return schema version stored by the last Migrate */
+	var version int
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetSchemaVersion")()
+
+	if err := r.HealthCheck(); err != nil {
+		return 0, err
+	}
+
+	if err := r.queryRow("PRAGMA user_version;").Scan(&version); err != nil {
+		r.log.Error(err)
+		return 0, err
+	}
+
+	return version, nil
+}
+
+func (r *SQLiteRepository) GetStatus() (GetStatusResp, error) {
+	/* Return present server status */
+	var (
+		resp GetStatusResp
+	)
+
+	resp.Common = Common{Type: ResponseStatusName}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetStatus")()
+
+	if err := r.HealthCheck(); err != nil {
+		resp.Status = ResponseStatus{Common{Type: ResponseStatusName}, false, err.Error()}
+
+		return resp, err
+	}
+
+	rows, err := r.query("SELECT timestamp, version FROM status WHERE ROWID IN ( SELECT max( ROWID ) FROM status);")
+	if err != nil {
+		r.log.Error(err)
+		resp.Status = ResponseStatus{Common{Type: ResponseStatusName}, false, err.Error()}
+
+		return resp, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := rows.Scan(&resp.Timestamp, &resp.Version); err != nil {
+			r.log.Error(err)
+			resp.Status = ResponseStatus{Common{Type: ResponseStatusName}, false, err.Error()}
+
+			return GetStatusResp{}, err
+		}
+	}
+
+	resp.Status = ResponseStatus{Common{Type: ResponseStatusName}, true, ""}
+
+	return resp, nil
+}
+
+func (r *SQLiteRepository) GetEventOwner(uuid string) (string, error) {
+	/* Return user who inserted the event, ErrEventNotFound when there is none */
+	var owner string
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetEventOwner")()
+
+	if err := r.HealthCheck(); err != nil {
+		return "", err
+	}
+
+	err := r.queryRow("SELECT owner FROM events WHERE uuid = ?;", uuid).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %s", ErrEventNotFound, uuid)
+	} else if err != nil {
+		r.log.Error(err)
+		return "", err
+	}
+
+	return owner, nil
+}
+
+func (r *SQLiteRepository) GetAttachment(uuid string) (Attachment, error) {
+	/* Return attachment of the event, ErrAttachmentNotFound when it has none */
+	a := Attachment{Common: Common{Type: AttachmentStructName}, EventUUID: uuid}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetAttachment")()
+
+	if err := r.HealthCheck(); err != nil {
+		return Attachment{}, err
+	}
+
+	err := r.queryRow("SELECT blob_key, content_type, size FROM attachments WHERE event_uuid = ?;", uuid).
+		Scan(&a.Key, &a.ContentType, &a.Size)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Attachment{}, fmt.Errorf("%w: %s", ErrAttachmentNotFound, uuid)
+	} else if err != nil {
+		r.log.Error(err)
+		return Attachment{}, err
+	}
+
+	return a, nil
+}
+
+func (r *SQLiteRepository) GetAudit(limit int) ([]AuditEntry, error) {
+	/* Return last `limit` audit entries, most recent first */
+	var (
+		result []AuditEntry
+	)
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetAudit")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.query("SELECT timestamp, username, action, uuid FROM audit ORDER BY id DESC LIMIT ?;", limit)
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		entry := AuditEntry{Common: Common{Type: AuditEntryStructName}}
+
+		if err := rows.Scan(&entry.Timestamp, &entry.User, &entry.Action, &entry.UUID); err != nil {
+			r.log.Error(err)
+			return nil, err
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteRepository) GetStatusHistory(limit int) ([]GetStatusResp, error) {
+	/* Return last `limit` status records, most recent first */
+	var (
+		result []GetStatusResp
+	)
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetStatusHistory")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.query("SELECT timestamp, version FROM status ORDER BY ROWID DESC LIMIT ?;", limit)
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		resp := GetStatusResp{Common: Common{Type: GetStatusRespName}}
+
+		if err := rows.Scan(&resp.Timestamp, &resp.Version); err != nil {
+			r.log.Error(err)
+			return nil, err
+		}
+
+		resp.Status = ResponseStatus{Common{Type: ResponseStatusName}, true, ""}
+		result = append(result, resp)
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteRepository) GetUserEmail(user string) (string, error) {
+	/* Return reminder recipient of the user, empty when none was set */
+	var email string
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetUserEmail")()
+
+	if err := r.HealthCheck(); err != nil {
+		return "", err
+	}
+
+	err := r.queryRow("SELECT email FROM users WHERE username = ?;", user).Scan(&email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	} else if err != nil {
+		r.log.Error(err)
+		return "", err
+	}
+
+	return email, nil
+}
+
+func (r *SQLiteRepository) SetUserEmail(user, email string) error {
+	/* Store reminder recipient of the user, empty address stops reminders */
+	r.acquire()
+	defer r.release()
+	defer r.timed("SetUserEmail")()
+
+	if err := r.HealthCheck(); err != nil {
+		return err
+	}
+
+	_, err := r.exec("UPDATE users SET email = ? WHERE username = ?;", email, user)
+	if err != nil {
+		r.log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) GetDueReminders(now int64) ([]DueReminder, error) {
+	/* Return reminders of not done, upcoming events whose reminder period (in days
+	 * before start) has begun. Events are reminded once per start, so moving an event
+	 * arms its reminder again. Owners without email address are skipped.
+	 */
+	var (
+		recipients []DueReminder
+		result     []DueReminder
+	)
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetDueReminders")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.query("SELECT username, email FROM users WHERE email != '';")
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	for rows.Next() {
+		var recipient DueReminder
+
+		if err := rows.Scan(&recipient.Username, &recipient.Email); err != nil {
+			rows.Close()
+			r.log.Error(err)
+
+			return nil, err
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	rows.Close()
+
+	/* Single connection is used, so rows of users are closed before events are read */
+	for _, recipient := range recipients {
+		rows, err = r.query(selectEventsSQL+`
+			WHERE owner = ? AND reminder > 0 AND done = ? AND start > ?
+				AND start - reminder * 86400 <= ? AND reminded_start != start
+			ORDER BY start`, recipient.Username, encodeBool(BackendSQLite, false), now, now)
+		if err != nil {
+			r.log.Error(err)
+			return nil, err
+		}
+
+		for rows.Next() {
+			e, err := r.readEvent(rows)
+			if err != nil {
+				r.log.Error(err)
+				continue
+			}
+
+			result = append(result, DueReminder{Username: recipient.Username, Email: recipient.Email, Event: e})
+		}
+
+		rows.Close()
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteRepository) GetUserReminders(user string, from, to int64) ([]UserReminder, error) {
+	/* Return reminders of not done, upcoming events owned by the user which fire
+	 * between Unix times from and to, ordered by the moment they fire. Unlike
+	 * GetDueReminders it ignores delivery state and email address of the user.
+	 */
+	var result []UserReminder
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetUserReminders")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.query(selectEventsSQL+`
+		WHERE owner = ? AND reminder > 0 AND done = ? AND start > ?
+			AND start - reminder * 86400 BETWEEN ? AND ?
+		ORDER BY start - reminder * 86400, start, uuid`, user, encodeBool(BackendSQLite, false), from, from, to)
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := r.readEvent(rows)
+		if err != nil {
+			r.log.Error(err)
+			continue
+		}
+
+		start, err := dateTimeToUnix(&e.Start, r.cfg.TimeZone)
+		if err != nil {
+			r.log.Error(err)
+			continue
+		}
+
+		result = append(result, UserReminder{
+			Common:   Common{Type: UserReminderStructName},
+			RemindAt: start - int64(e.Reminder)*86400,
+			Event:    e,
+		})
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteRepository) MarkReminded(uuid string) error {
+	/* Remember reminder of the event was delivered for its present start */
+	r.acquire()
+	defer r.release()
+	defer r.timed("MarkReminded")()
+
+	if err := r.HealthCheck(); err != nil {
+		return err
+	}
+
+	_, err := r.exec("UPDATE events SET reminded_start = start WHERE uuid = ?;", uuid)
+	if err != nil {
+		r.log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *SQLiteRepository) GetUserSettings(user string) (string, error) {
+	/* Return settings JSON of the user, users without stored settings get UserSettingsDefault */
+	var settings string
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("GetUserSettings")()
+
+	if err := r.HealthCheck(); err != nil {
+		return "", err
+	}
+
+	err := r.queryRow("SELECT settings FROM user_settings WHERE username = ?;", user).Scan(&settings)
+	if errors.Is(err, sql.ErrNoRows) {
+		return UserSettingsDefault, nil
+	} else if err != nil {
+		r.log.Error(err)
+		return "", err
+	}
+
+	return settings, nil
+}
+
+func (r *SQLiteRepository) HealthCheck() error {
+	/* Check database connection and try to reconnect if it was lost */
+	err := r.handle().Ping()
+	if err == nil || !isConnectionError(err) {
+		return err
+	}
+
+	r.log.Warning("Database connection lost. ", err)
+
+	return r.Reconnect()
+}
+
+func (r *SQLiteRepository) Reconnect() error {
+	/* Reopen database handle, retry with exponential backoff.
+	 * Database structure is migrated again, as in memory database
+	 * does not survive closing of all its connections.
+	 */
+	var (
+		db      *sql.DB
+		err     error
+		backoff = DatabaseReconnectBackoff
+	)
+
+	for attempt := 1; attempt <= DatabaseReconnectAttempts; attempt++ {
+		r.log.Warning(fmt.Sprintf("Reconnecting to database, attempt %d of %d.", attempt, DatabaseReconnectAttempts))
+
+		db, err = sql.Open("sqlite3", r.cfg.DatabaseFile)
+		if err == nil {
+			db.SetMaxOpenConns(1)
+			err = db.Ping()
+		}
+
+		if err == nil {
+			r.conn.mu.Lock()
+			old := r.conn.db
+			r.conn.db = db
+			r.conn.mu.Unlock()
+
+			old.Close()
+
+			r.log.Info("Successfully reconnected to database.")
+
+			return r.Migrate()
+		}
+
+		r.log.Error("Reconnecting to database failed. ", err)
+
+		if db != nil {
+			db.Close()
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	return err
+}
+
+func (r *SQLiteRepository) InsertEvent(e *EventData) (*EventData, error) {
+	/* Insert new event into database, or update existing one.
+	 * Event will be updated if database contains different event with same UUID.
+	 * Event will be inserted is event UUID is unique in database.
+	 */
+	r.acquire()
+	defer r.release()
+	defer r.timed("InsertEvent")()
+
+	if err := r.HealthCheck(); err != nil {
+		return e, err
+	}
+
+	var (
+		action string
+		result *EventData
+	)
+
+	err := r.transaction(func(tx *sql.Tx) (err error) {
+		result, action, err = r.upsertEvent(tx, e)
+		return err
+	})
+	if err != nil || action == "" {
+		return result, err
+	}
+
+	e = result
+
+	r.bus.publish(EventChange{UUID: e.UUID, Action: action})
+
+	err = r.updateStatus()
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (r *SQLiteRepository) InsertIfAbsent(e *EventData) (bool, error) {
+	/* Insert event only if its UUID is not stored yet, existing event is left untouched.
+	 * Returns false without error when the UUID is already taken.
+	 */
+	var inserted bool
+
+	e.Normalize()
+
+	if err := e.ValidateNew(r.cfg); err != nil {
+		return false, err
+	}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("InsertIfAbsent")()
+
+	if err := r.HealthCheck(); err != nil {
+		return false, err
+	}
+
+	err := r.transaction(func(tx *sql.Tx) error {
+		/* Existing event is not new, neither dedup nor quota should reject it */
+		var exists bool
+
+		inserted = false
+
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM events WHERE uuid = ?);", e.UUID).Scan(&exists)
+		if err != nil {
+			r.log.Error(err)
+			return err
+		}
+
+		if exists {
+			return nil
+		}
+
+		if err = r.checkNewEvent(tx, e); err != nil {
+			return err
+		}
+
+		_, inserted, err = r.insertEvent(tx, e, " ON CONFLICT (uuid) DO NOTHING")
+
+		return err
+	})
+	if err != nil || !inserted {
+		return false, err
+	}
+
+	r.bus.publish(EventChange{UUID: e.UUID, Action: AuditActionInsert})
+
+	if err = r.updateStatus(); err != nil {
+		r.log.Error(err)
+		return true, err
+	}
+
+	return true, nil
+}
+
+func (r *SQLiteRepository) InsertEvents(events []*EventData) ([]error, error) {
+	/* Insert or update a batch of events within a single transaction.
+	 * Returns an error for every event (nil on success) in the same order as
+	 * passed events. Second error is set only when the transaction itself fails.
+	 */
+	var (
+		changes []EventChange
+		errs    = make([]error, len(events))
+	)
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("InsertEvents")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	err := r.transaction(func(tx *sql.Tx) error {
+		changes = nil
+
+		for i, e := range events {
+			var action string
+
+			_, action, errs[i] = r.upsertEvent(tx, e)
+			if isBusyError(errs[i]) {
+				return errs[i]
+			}
+
+			if action != "" {
+				changes = append(changes, EventChange{UUID: e.UUID, Action: action})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.bus.publish(changes...)
+
+	if len(changes) > 0 {
+		err = r.updateStatus()
+		if err != nil {
+			r.log.Error(err)
+			return errs, err
+		}
+	}
+
+	return errs, nil
+}
+
+func (r *SQLiteRepository) PatchEvent(p *PatchEventReq) (*EventData, error) {
+	/* Apply partial update to an existing event within a single transaction,
+	 * so concurrent edits of other fields are not overwritten with stale values.
+	 * Returns nil event without error if event does not exist.
+	 */
+	r.acquire()
+	defer r.release()
+	defer r.timed("PatchEvent")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	var (
+		action string
+		result *EventData
+	)
+
+	err := r.transaction(func(tx *sql.Tx) error {
+		result, action = nil, ""
+
+		rows, err := tx.Query(selectEventsSQL+" WHERE uuid = ?", p.UUID)
+		if err != nil {
+			r.log.Error(err)
+			return err
+		}
+
+		if !rows.Next() {
+			rows.Close()
+			return nil
+		}
+
+		e, err := r.readEvent(rows)
+		rows.Close()
+
+		if err != nil {
+			r.log.Error(err)
+			return err
+		}
+
+		p.Apply(&e)
+
+		result, action, err = r.upsertEvent(tx, &e)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if action != "" {
+		r.bus.publish(EventChange{UUID: result.UUID, Action: action})
+
+		err = r.updateStatus()
 		if err != nil {
 			r.log.Error(err)
-			return EventData{Common: Common{Type: EventDataStructName}}, err
+			return nil, err
 		}
-
-		return e, nil
 	}
 
-	return EventData{Common: Common{Type: EventDataStructName}}, nil
+	return result, nil
 }
 
-func (r *SQLiteRepository) GetStatus() (GetStatusResp, error) {
-	/* Return present server status */
-	var (
-		resp GetStatusResp
-	)
+func (r *SQLiteRepository) RecordAudit(user, action, uuid string) error {
+	/* Append entry to the audit log. Entries are never updated nor deleted.
+	 * Inserts and updates also mark the user as the last one who modified the event.
+	 */
+	r.acquire()
+	defer r.release()
+	defer r.timed("RecordAudit")()
 
-	resp.Common = Common{Type: ResponseStatusName}
+	if err := r.HealthCheck(); err != nil {
+		return err
+	}
 
-	rows, err := r.db.Query("SELECT timestamp, version FROM status WHERE ROWID IN ( SELECT max( ROWID ) FROM status);")
+	_, err := r.exec("INSERT INTO audit (timestamp, username, action, uuid) VALUES (?, ?, ?, ?);",
+		r.clock.Now().Unix(), user, action, uuid)
 	if err != nil {
 		r.log.Error(err)
-		resp.Status = ResponseStatus{Common{ResponseStatusName}, false, err.Error()}
+		return err
+	}
 
-		return resp, err
+	if action == AuditActionInsert || action == AuditActionUpdate {
+		_, err = r.exec("UPDATE events SET last_modified_by = ? WHERE uuid = ?;", user, uuid)
+		if err != nil {
+			r.log.Error(err)
+			return err
+		}
 	}
 
-	defer rows.Close()
+	return nil
+}
 
-	for rows.Next() {
-		if err := rows.Scan(&resp.Timestamp, &resp.Version); err != nil {
+func (r *SQLiteRepository) SetAttachment(a Attachment) (string, error) {
+	/* Store attachment of an existing event, replacing previous one, within a single
+	 * transaction. Returns key of the replaced blob, empty when there was none, so
+	 * caller can delete it. Returns ErrEventNotFound when the event does not exist.
+	 */
+	var previous string
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("SetAttachment")()
+
+	if err := r.HealthCheck(); err != nil {
+		return "", err
+	}
+
+	err := r.transaction(func(tx *sql.Tx) error {
+		var exists bool
+
+		previous = ""
+
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM events WHERE uuid = ?);", a.EventUUID).Scan(&exists)
+		if err != nil {
 			r.log.Error(err)
-			resp.Status = ResponseStatus{Common{ResponseStatusName}, false, err.Error()}
+			return err
+		}
 
-			return GetStatusResp{}, err
+		if !exists {
+			return fmt.Errorf("%w: %s", ErrEventNotFound, a.EventUUID)
+		}
+
+		err = tx.QueryRow("SELECT blob_key FROM attachments WHERE event_uuid = ?;", a.EventUUID).Scan(&previous)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			r.log.Error(err)
+			return err
 		}
+
+		_, err = tx.Exec(`
+		INSERT INTO attachments (event_uuid, blob_key, content_type, size, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (event_uuid) DO UPDATE SET blob_key = excluded.blob_key, content_type = excluded.content_type,
+			size = excluded.size, created_at = excluded.created_at;`,
+			a.EventUUID, a.Key, a.ContentType, a.Size, r.clock.Now().Unix())
+		if err != nil {
+			r.log.Error(err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return previous, nil
+}
+
+func (r *SQLiteRepository) SetUserSettings(user, settings string) error {
+	/* Store settings JSON of the user, replacing previously stored one */
+	r.acquire()
+	defer r.release()
+	defer r.timed("SetUserSettings")()
+
+	if err := r.HealthCheck(); err != nil {
+		return err
 	}
 
-	resp.Status = ResponseStatus{Common{ResponseStatusName}, true, ""}
+	_, err := r.exec(`
+		INSERT INTO user_settings (username, settings, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (username) DO UPDATE SET settings = excluded.settings, updated_at = excluded.updated_at;`,
+		user, settings, r.clock.Now().Unix())
+	if err != nil {
+		r.log.Error(err)
+		return err
+	}
 
-	return resp, nil
+	return nil
 }
 
-func (r *SQLiteRepository) InsertEvent(e *EventData) (*EventData, error) {
-	/* Insert new event into database, or update existing one.
-	 * Event will be updated if database contains different event with same UUID.
-	 * Event will be inserted is event UUID is unique in database.
+//nolint:gosec // Names of truncated tables are constants, never user input
+func (r *SQLiteRepository) Truncate() error {
+	/* Delete all events, users, settings and status records within a single transaction,
+	 * e.g. to reset state between tests. Append-only audit log is left intact.
+	 */
+	r.acquire()
+	defer r.release()
+	defer r.timed("Truncate")()
+
+	if err := r.HealthCheck(); err != nil {
+		return err
+	}
+
+	return r.transaction(func(tx *sql.Tx) error {
+		for _, table := range []string{"events", "users", "user_settings", "attachments", "status"} {
+			if _, err := tx.Exec("DELETE FROM " + table + ";"); err != nil {
+				r.log.Error(err)
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *SQLiteRepository) UpdateFlags(p *UpdateFlagsReq) ([]string, error) {
+	/* Set flags present in the request on events with provided UUIDs within a single transaction.
+	 * Returns UUIDs of events updated, unknown UUIDs are ignored.
 	 */
 	var (
-		err     error
-		dbEvent EventData
+		assignments []string
+		args        []any
 	)
 
-	rows, err := r.db.Query("SELECT * FROM events WHERE uuid = ?", e.UUID)
+	for _, flag := range []struct {
+		column string
+		value  *bool
+	}{
+		{"done", p.Done},
+		{"important", p.Important},
+		{"urgent", p.Urgent},
+	} {
+		if flag.value != nil {
+			assignments = append(assignments, flag.column+" = ?")
+			args = append(args, encodeBool(BackendSQLite, *flag.value))
+		}
+	}
+
+	if len(p.UUIDs) == 0 || len(assignments) == 0 {
+		return nil, nil
+	}
+
+	args = append(args, r.clock.Now().Unix())
+	for _, uuid := range p.UUIDs {
+		args = append(args, uuid)
+	}
+
+	r.acquire()
+	defer r.release()
+	defer r.timed("UpdateFlags")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	var changes []EventChange
+
+	err := r.transaction(func(tx *sql.Tx) (err error) {
+		//nolint:gosec // Only whitelisted columns and placeholders are concatenated, values are passed as arguments
+		changes, err = r.queryChanges(tx, AuditActionUpdate,
+			"UPDATE events SET "+strings.Join(assignments, ", ")+", updated_at = ? WHERE uuid IN (?"+
+				strings.Repeat(", ?", len(p.UUIDs)-1)+") RETURNING uuid;", args...)
+
+		return err
+	})
 	if err != nil {
-		r.log.Error(err)
-		return e, err
+		return nil, err
 	}
 
-	if rows.Next() {
-		/* Event exist in database. Check if update is needed */
-		dbEvent, err = convertRawEventRecordToEventData(rows)
+	r.bus.publish(changes...)
+
+	updated := changedUUIDs(changes)
+	if len(updated) > 0 {
+		err = r.updateStatus()
 		if err != nil {
 			r.log.Error(err)
-			return e, err
+			return updated, err
 		}
+	}
 
-		rows.Close()
+	return updated, nil
+}
 
-		e.ID = dbEvent.ID
+func (r *SQLiteRepository) ReassignEvents(fromUser, toUser string) ([]string, error) {
+	/* Transfer all events of fromUser to toUser within a single transaction, both users
+	 * have to be registered and toUser may not exceed cfg.MaxEventsPerUser with them.
+	 * Returns UUIDs of events moved.
+	 */
+	var changes []EventChange
 
-		/* Check if passed event has some changes that requires update */
-		if dbEvent.Sha256() == e.Sha256() {
-			return e, nil
+	r.acquire()
+	defer r.release()
+	defer r.timed("ReassignEvents")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	err := r.transaction(func(tx *sql.Tx) (err error) {
+		var exists bool
+
+		for _, user := range []string{fromUser, toUser} {
+			err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE username = ?);", user).Scan(&exists)
+			if err != nil {
+				r.log.Error(err)
+				return err
+			}
+
+			if !exists {
+				return fmt.Errorf("%w: %s", ErrUserNotFound, user)
+			}
 		}
 
-		//nolint:govet //Event returned is same event that is passed with additional data like ID
-		e, err := r.updateEvent(e)
+		if r.cfg.MaxEventsPerUser > 0 {
+			var moved, owned int
+
+			err = tx.QueryRow("SELECT COUNT(CASE WHEN owner = ? THEN 1 END), COUNT(CASE WHEN owner = ? THEN 1 END) FROM events;",
+				fromUser, toUser).Scan(&moved, &owned)
+			if err != nil {
+				r.log.Error(err)
+				return err
+			}
+
+			if owned+moved > r.cfg.MaxEventsPerUser {
+				return fmt.Errorf("%w: %s owns %d events, %d more would exceed %d",
+					ErrQuotaExceeded, toUser, owned, moved, r.cfg.MaxEventsPerUser)
+			}
+		}
+
+		changes, err = r.queryChanges(tx, AuditActionUpdate,
+			"UPDATE events SET owner = ?, updated_at = ? WHERE owner = ? RETURNING uuid;",
+			toUser, r.clock.Now().Unix(), fromUser)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.bus.publish(changes...)
+
+	uuids := make([]string, 0, len(changes))
+	for _, change := range changes {
+		uuids = append(uuids, change.UUID)
+	}
+
+	return uuids, nil
+}
+
+func (r *SQLiteRepository) MergeEvents(primary, secondary string) (*EventData, error) {
+	/* Fill empty fields of the primary event with values of the secondary one and delete
+	 * the secondary, both within a single transaction. Attachment of the secondary is moved
+	 * to the primary when it has none, otherwise it is deleted. Returns ErrEventNotFound
+	 * when either of the events does not exist.
+	 */
+	r.acquire()
+	defer r.release()
+	defer r.timed("MergeEvents")()
+
+	if err := r.HealthCheck(); err != nil {
+		return nil, err
+	}
+
+	var (
+		action  string
+		changes []EventChange
+		keys    []string
+		result  *EventData
+	)
+
+	err := r.transaction(func(tx *sql.Tx) (err error) {
+		events := make([]EventData, 2)
+
+		for i, uuid := range []string{primary, secondary} {
+			rows, err := tx.Query(selectEventsSQL+" WHERE uuid = ?", uuid)
+			if err != nil {
+				r.log.Error(err)
+				return err
+			}
+
+			if !rows.Next() {
+				rows.Close()
+				return fmt.Errorf("%w: %s", ErrEventNotFound, uuid)
+			}
+
+			events[i], err = r.readEvent(rows)
+			rows.Close()
+
+			if err != nil {
+				r.log.Error(err)
+				return err
+			}
+		}
+
+		merged := events[0]
+		merged.FillFrom(&events[1])
+
+		if result, action, err = r.upsertEvent(tx, &merged); err != nil {
+			return err
+		}
+
+		changes, err = r.queryChanges(tx, AuditActionDelete, "DELETE FROM events WHERE uuid = ? RETURNING uuid;", secondary)
+		if err != nil {
+			return err
+		}
+
+		moved, err := tx.Exec(`UPDATE attachments SET event_uuid = ? WHERE event_uuid = ?
+			AND NOT EXISTS (SELECT 1 FROM attachments WHERE event_uuid = ?);`, primary, secondary, primary)
 		if err != nil {
 			r.log.Error(err)
-			return e, err
+			return err
 		}
 
-		return e, nil
+		/* Primary gained the attachment, so it changed even when its fields did not */
+		if n, _ := moved.RowsAffected(); n > 0 && action == "" {
+			action = AuditActionUpdate
+		}
+
+		keys, err = r.deleteAttachments(tx, "event_uuid = ?", secondary)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.deleteBlobs(keys)
+
+	if action != "" {
+		changes = append(changes, EventChange{UUID: result.UUID, Action: action})
+	}
+
+	r.bus.publish(changes...)
+
+	err = r.updateStatus()
+	if err != nil {
+		r.log.Error(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+//nolint:gosec // Table and column names are constants passed by Migrate, never user input
+func (r *SQLiteRepository) addColumnIfMissing(table, column, definition string) error {
+	/* Add column to a table created by older version of the application */
+	var found bool
+
+	rows, err := r.query(fmt.Sprintf("PRAGMA table_info(%s);", table))
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
+		)
+
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+
+		found = found || name == column
 	}
 
 	rows.Close()
 
-	return r.insertEvent(e)
+	if found {
+		return nil
+	}
+
+	r.log.Info(fmt.Sprintf("Adding column '%s' to table '%s'.", column, table))
+
+	_, err = r.exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, column, definition))
+
+	return err
+}
+
+func (r *SQLiteRepository) configureJournal() error {
+	/* With cfg.SQLiteWAL switch file database to write-ahead log, so readers do not block
+	 * the writer and the other way round, and wait cfg.SQLiteBusyTimeout for locks instead
+	 * of failing with `database is locked`. Journal mode persists in the file, busy timeout
+	 * belongs to the connection, so it is set again after Reconnect.
+	 */
+	var mode string
+
+	if !r.cfg.SQLiteWAL || isInMemory(r.cfg.DatabaseFile) {
+		return nil
+	}
+
+	/* PRAGMA does not accept bound parameters */
+	if _, err := r.exec(fmt.Sprintf("PRAGMA busy_timeout = %d;", r.cfg.SQLiteBusyTimeout.Milliseconds())); err != nil {
+		return err
+	}
+
+	if err := r.queryRow("PRAGMA journal_mode = WAL;").Scan(&mode); err != nil {
+		return err
+	}
+
+	/* SQLite keeps previous mode when WAL is not supported, e.g. on network filesystems */
+	if !strings.EqualFold(mode, "wal") {
+		r.log.Warning("Write-ahead log is not supported by the database, journal mode stays ", mode)
+		return nil
+	}
+
+	r.log.Info("Write-ahead log enabled.")
+
+	return nil
 }
 
 func (r *SQLiteRepository) Migrate() error {
@@ -435,13 +2972,48 @@ func (r *SQLiteRepository) Migrate() error {
 			done INTEGER,
 			important INTEGER,
 			urgent INTEGER,
-			source VARCHAR(255))
+			source VARCHAR(255),
+			category VARCHAR(64) DEFAULT '',
+			color VARCHAR(7) DEFAULT '',
+			updated_at INTEGER DEFAULT 0,
+			content_hash VARCHAR(64) DEFAULT '',
+			created_at INTEGER DEFAULT 0,
+			last_modified_by VARCHAR(64) DEFAULT '',
+			owner VARCHAR(64) DEFAULT '',
+			reminded_start INTEGER DEFAULT 0)
+		`
+		/* Creation time of events stored by older versions is unknown, last update is the best guess */
+		backfillCreatedAtSQL = `
+		UPDATE events SET created_at = updated_at WHERE created_at = 0;
+		`
+		/* Owner of events stored by older versions is unknown, last modifier is the best guess */
+		backfillOwnerSQL = `
+		UPDATE events SET owner = last_modified_by WHERE owner = '';
+		`
+		createOwnerIndexSQL = `
+		CREATE INDEX IF NOT EXISTS events_owner ON events (owner);
+		`
+		createContentHashIndexSQL = `
+		CREATE INDEX IF NOT EXISTS events_content_hash ON events (content_hash);
+		`
+		createUUIDIndexSQL = `
+		CREATE UNIQUE INDEX IF NOT EXISTS events_uuid ON events (uuid);
 		`
 		createUsersSQL = `
 		CREATE TABLE IF NOT EXISTS users (
 			id INTEGER PRIMARY KEY,
-			username VARCHAR(64),
-			password VARCHAR(64));
+			username VARCHAR(64) UNIQUE,
+			password VARCHAR(64),
+			email VARCHAR(255) DEFAULT '');
+		`
+		/* Tables created by older versions have no UNIQUE constraint on username.
+		 * Keep only the latest row of every user and enforce uniqueness with an index.
+		 */
+		dedupUsersSQL = `
+		DELETE FROM users WHERE id NOT IN (SELECT MAX(id) FROM users GROUP BY username);
+		`
+		createUsersIndexSQL = `
+		CREATE UNIQUE INDEX IF NOT EXISTS users_username ON users (username);
 		`
 		createStatusSQL = `
 		CREATE TABLE IF NOT EXISTS status (
@@ -449,45 +3021,113 @@ func (r *SQLiteRepository) Migrate() error {
 			timestamp INTEGER,
 			version VARCHAR(64));
 		`
-		statement *sql.Stmt
+		createAuditSQL = `
+		CREATE TABLE IF NOT EXISTS audit (
+			id INTEGER PRIMARY KEY,
+			timestamp INTEGER,
+			username VARCHAR(64),
+			action VARCHAR(16),
+			uuid VARCHAR(32));
+		`
+		/* Audit log is append-only, reject any modification of existing entries */
+		protectAuditSQL = []string{`
+		CREATE TRIGGER IF NOT EXISTS audit_no_update BEFORE UPDATE ON audit
+		BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+		`, `
+		CREATE TRIGGER IF NOT EXISTS audit_no_delete BEFORE DELETE ON audit
+		BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+		`}
+		createUserSettingsSQL = `
+		CREATE TABLE IF NOT EXISTS user_settings (
+			username VARCHAR(64) PRIMARY KEY,
+			settings TEXT NOT NULL,
+			updated_at INTEGER DEFAULT 0);
+		`
+		/* Content of attachments is kept in BlobStore, only its key is stored here */
+		createAttachmentsSQL = `
+		CREATE TABLE IF NOT EXISTS attachments (
+			event_uuid VARCHAR(32) PRIMARY KEY,
+			blob_key VARCHAR(64) NOT NULL,
+			content_type VARCHAR(255) NOT NULL,
+			size INTEGER NOT NULL,
+			created_at INTEGER DEFAULT 0);
+		`
 	)
 
-	statement, err = r.db.Prepare(createEventsSQL)
-	if err != nil {
-		r.log.Critical("Failed to create table 'events'." + err.Error())
+	if err = r.configureJournal(); err != nil {
+		r.log.Critical("Failed to configure journal. " + err.Error())
 		return err
 	}
 
-	_, err = statement.Exec()
+	_, err = r.exec(createEventsSQL)
 	if err != nil {
 		r.log.Critical("Failed to create table 'events'." + err.Error())
 		return err
 	}
 
-	r.log.Info("Successfully created table 'events'.")
+	/* Tables created by older versions lack modification time, labels, content hash, metadata, owner and reminder state */
+	for _, column := range [][2]string{
+		{"updated_at", "INTEGER DEFAULT 0"},
+		{"category", "VARCHAR(64) DEFAULT ''"},
+		{"color", "VARCHAR(7) DEFAULT ''"},
+		{"content_hash", "VARCHAR(64) DEFAULT ''"},
+		{"created_at", "INTEGER DEFAULT 0"},
+		{"last_modified_by", "VARCHAR(64) DEFAULT ''"},
+		{"owner", "VARCHAR(64) DEFAULT ''"},
+		{"reminded_start", "INTEGER DEFAULT 0"},
+	} {
+		err = r.addColumnIfMissing("events", column[0], column[1])
+		if err != nil {
+			r.log.Critical("Failed to migrate table 'events'." + err.Error())
+			return err
+		}
+	}
+
+	err = r.dedupEvents()
+
+	for _, eventsSQL := range []string{createUUIDIndexSQL, createContentHashIndexSQL, createOwnerIndexSQL,
+		backfillCreatedAtSQL, backfillOwnerSQL} {
+		if err == nil {
+			_, err = r.exec(eventsSQL)
+		}
+	}
+
+	if err == nil {
+		err = r.backfillContentHashes()
+	}
 
-	statement, err = r.db.Prepare(createUsersSQL)
 	if err != nil {
-		r.log.Critical("Failed to create table 'users'." + err.Error())
+		r.log.Critical("Failed to migrate table 'events'." + err.Error())
 		return err
 	}
 
-	_, err = statement.Exec()
+	r.log.Info("Successfully created table 'events'.")
+
+	_, err = r.exec(createUsersSQL)
 	if err != nil {
 		r.log.Critical("Failed to create table 'users'." + err.Error())
 
 		return err
 	}
 
-	r.log.Info("Successfully created table 'users'.")
+	for _, usersSQL := range []string{dedupUsersSQL, createUsersIndexSQL} {
+		_, err = r.exec(usersSQL)
+		if err != nil {
+			r.log.Critical("Failed to enforce unique users." + err.Error())
+			return err
+		}
+	}
 
-	statement, err = r.db.Prepare(createStatusSQL)
+	/* Tables created by older versions lack reminder recipient */
+	err = r.addColumnIfMissing("users", "email", "VARCHAR(255) DEFAULT ''")
 	if err != nil {
-		r.log.Critical("Failed to create table 'status'." + err.Error())
+		r.log.Critical("Failed to migrate table 'users'." + err.Error())
 		return err
 	}
 
-	_, err = statement.Exec()
+	r.log.Info("Successfully created table 'users'.")
+
+	_, err = r.exec(createStatusSQL)
 	if err != nil {
 		r.log.Error(err)
 
@@ -496,6 +3136,40 @@ func (r *SQLiteRepository) Migrate() error {
 
 	r.log.Info("Successfully created table 'status'.")
 
+	for _, auditSQL := range append([]string{createAuditSQL}, protectAuditSQL...) {
+		_, err = r.exec(auditSQL)
+		if err != nil {
+			r.log.Critical("Failed to create table 'audit'." + err.Error())
+			return err
+		}
+	}
+
+	r.log.Info("Successfully created table 'audit'.")
+
+	_, err = r.exec(createUserSettingsSQL)
+	if err != nil {
+		r.log.Critical("Failed to create table 'user_settings'." + err.Error())
+		return err
+	}
+
+	r.log.Info("Successfully created table 'user_settings'.")
+
+	_, err = r.exec(createAttachmentsSQL)
+	if err != nil {
+		r.log.Critical("Failed to create table 'attachments'." + err.Error())
+		return err
+	}
+
+	r.log.Info("Successfully created table 'attachments'.")
+
+	/* PRAGMA does not accept bound parameters */
+	_, err = r.exec(fmt.Sprintf("PRAGMA user_version = %d;", SchemaVersion))
+	if err != nil {
+		r.log.Error(err)
+
+		return err
+	}
+
 	err = r.updateStatus()
 	if err != nil {
 		r.log.Error(err)
@@ -5,26 +5,39 @@ package v1rest
 // Created: August 18, 2024
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"eventshub/config"
+	logger "eventshub/logging"
+	"fmt"
+	"io"
 	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
 	TestEvent1 = EventData{
-		Common{EventDataStructName},
+		Common{Type: EventDataStructName},
 		0, "1.1.1", "e0b2dd0f43614138995beafa87b6356b", "Ur. Mr X",
-		DateTime{Common{DateTimeStructName}, 2021, 1, 12, 0, 0},
-		DateTime{Common{DateTimeStructName}, 2021, 1, 12, 0, 0},
-		"Warszawa, ul. Okrężna 26", "Likes beer", 7, false, true, false, "APP"}
+		DateTime{Common{Type: DateTimeStructName}, 2021, 1, 12, 0, 0},
+		DateTime{Common{Type: DateTimeStructName}, 2021, 1, 12, 0, 0},
+		"Warszawa, ul. Okrężna 26", "Likes beer", 7, false, true, false, "APP", "", "", ""}
 	TestEvent2 = EventData{
-		Common{EventDataStructName},
+		Common{Type: EventDataStructName},
 		0, "1.1.1", "5bd8fa795fa04bf79c37dd1b9583709f", "Im. Miss Y",
-		DateTime{Common{DateTimeStructName}, 2024, 2, 13, 12, 0},
-		DateTime{Common{DateTimeStructName}, 2024, 2, 13, 12, 0},
-		"Łódź, ul. Rzgowska 65", "Likes flowers", 7, false, true, false, "WEB"}
+		DateTime{Common{Type: DateTimeStructName}, 2024, 2, 13, 12, 0},
+		DateTime{Common{Type: DateTimeStructName}, 2024, 2, 13, 12, 0},
+		"Łódź, ul. Rzgowska 65", "Likes flowers", 7, false, true, false, "WEB", "", "", ""}
 )
 
 func Test_NewSqliteRepository(t *testing.T) {
@@ -37,9 +50,11 @@ func Test_NewSqliteRepository(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	sut := NewSQLiteRepository(db)
+	sut := NewSQLiteRepository(db, config.Default())
 
-	assert.NotNil(t, sut.db)
+	assert.NotNil(t, sut.handle())
+
+	sut.Shutdown()
 }
 
 func Test_Migrate(t *testing.T) {
@@ -58,13 +73,67 @@ func Test_Migrate(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	sut := NewSQLiteRepository(db)
+	sut := NewSQLiteRepository(db, config.Default())
 
-	assert.NotNil(t, sut.db)
+	assert.NotNil(t, sut.handle())
 	err = sut.Migrate()
 	assert.NoError(t, err)
 
-	sut.Close()
+	version, err := sut.GetSchemaVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, SchemaVersion, version)
+
+	sut.Shutdown()
+}
+
+func Test_MigrateMovesDuplicatedEventsOnce(t *testing.T) {
+	/* GIVEN database of schema older than DedupEventsSchemaVersion with two copies of one UUID
+	 * WHEN Migrate() is called twice
+	 * THEN the latest copy should be kept
+	 * AND the older one should be moved to events_duplicates only once
+	 */
+	db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	assert.NoError(t, sut.Migrate())
+
+	event := TestEvent1
+	_, err = sut.InsertEvent(&event)
+	assert.NoError(t, err)
+
+	for _, query := range []string{
+		"DROP INDEX events_uuid",
+		`INSERT INTO events (version, uuid, title, start, end, address, info, reminder, done, important, urgent, source)
+		SELECT version, uuid, 'Latest copy', start, end, address, info, reminder, done, important, urgent, source FROM events`,
+		fmt.Sprintf("PRAGMA user_version = %d", DedupEventsSchemaVersion-1),
+	} {
+		_, err = sut.handle().Exec(query)
+		assert.NoError(t, err)
+	}
+
+	count := func(table string) (n int) {
+		assert.NoError(t, sut.handle().QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, sut.Migrate())
+		assert.Equal(t, 1, count("events"))
+		assert.Equal(t, 1, count("events_duplicates"))
+	}
+
+	var title string
+
+	assert.NoError(t, sut.handle().QueryRow("SELECT title FROM events").Scan(&title))
+	assert.Equal(t, "Latest copy", title)
+
+	assert.NoError(t, sut.handle().QueryRow("SELECT title FROM events_duplicates").Scan(&title))
+	assert.Equal(t, TestEvent1.Title, title)
+
+	sut.Shutdown()
 }
 
 func Test_GetAllEvents(t *testing.T) {
@@ -83,8 +152,8 @@ func Test_GetAllEvents(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	sut := NewSQLiteRepository(db)
-	assert.NotNil(t, sut.db)
+	sut := NewSQLiteRepository(db, config.Default())
+	assert.NotNil(t, sut.handle())
 	err = sut.Migrate()
 	assert.NoError(t, err)
 
@@ -108,5 +177,1506 @@ func Test_GetAllEvents(t *testing.T) {
 	assert.Equalf(t, result[1].ID, TestEvent2.ID, "Event ID should be populated with database value, %d != %d", result[1].ID, TestEvent2.ID)
 	assert.NotEqualf(t, result[0].ID, result[1].ID, "Retrieved events should have different ID's")
 
+	sut.Shutdown()
+}
+
+func Test_InMemoryDataSurvivesCloseUntilShutdown(t *testing.T) {
+	/* GIVEN a repository of shared-cache in-memory database with an event
+	 * WHEN the repository is closed and the database is opened again
+	 * THEN the event should still be there, kept alive by the sentinel connection
+	 * AND after Shutdown a reopened database should be empty
+	 */
+	cfg := config.Default()
+	cfg.DatabaseFile = inMemoryDatabaseFile(t.Name())
+
+	open := func() *SQLiteRepository {
+		db, err := sql.Open("sqlite3", cfg.DatabaseFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		repo := NewSQLiteRepository(db, cfg)
+		assert.NoError(t, repo.Migrate())
+
+		return repo
+	}
+
+	sut := open()
+
+	_, err := sut.InsertEvent(&TestEvent1)
+	assert.NoError(t, err)
+
 	sut.Close()
+
+	sut = open()
+
+	events, err := sut.GetAllEvents()
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	sut.Shutdown()
+
+	sut = open()
+
+	events, err = sut.GetAllEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	sut.Shutdown()
+}
+
+func Test_RepositoryRecoversAfterHandleIsClosed(t *testing.T) {
+	/* GIVEN a migrated SQLiteRepository
+	 * WHEN its database handle is closed out from under it
+	 * THEN subsequent repository calls should reconnect
+	 * AND succeed without returning an error
+	 */
+	var (
+		err error
+		db  *sql.DB
+	)
+
+	db, err = sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	db.Close()
+
+	err = sut.HealthCheck()
+	assert.NoError(t, err)
+	assert.NotEqual(t, db, sut.handle())
+
+	event := TestEvent1
+	event.UUID = "c0b2dd0f43614138995beafa87b6356b"
+
+	_, err = sut.InsertEvent(&event)
+	assert.NoError(t, err)
+
+	result, err := sut.GetEventByUUID(event.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, event.UUID, result.UUID)
+
+	sut.Shutdown()
+}
+
+func Test_FindEventsByUUIDPrefix(t *testing.T) {
+	/* GIVEN SQLiteRepository with two events
+	 * WHEN FindEventsByUUIDPrefix() is called with a prefix of TestEvent1 UUID
+	 * THEN only TestEvent1 should be returned
+	 * AND wildcard prefix should not match anything
+	 */
+	var (
+		err error
+		db  *sql.DB
+	)
+
+	db, err = sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	_, err = sut.InsertEvent(&TestEvent1)
+	assert.NoError(t, err)
+
+	_, err = sut.InsertEvent(&TestEvent2)
+	assert.NoError(t, err)
+
+	result, err := sut.FindEventsByUUIDPrefix(TestEvent1.UUID[:4])
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, TestEvent1.UUID, result[0].UUID)
+
+	result, err = sut.FindEventsByUUIDPrefix("%")
+	assert.NoError(t, err)
+	assert.Len(t, result, 0)
+
+	sut.Shutdown()
+}
+
+func Test_InsertEventNormalizesTextFields(t *testing.T) {
+	/* GIVEN an event with surrounding whitespace in text fields
+	 * WHEN it is inserted directly into repository
+	 * THEN stored event should have trimmed text fields and uppercased source
+	 */
+	var (
+		err error
+		db  *sql.DB
+	)
+
+	db, err = sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	event := TestEvent2
+	event.UUID = "d0b2dd0f43614138995beafa87b6356b"
+	event.Title = " Im. Miss Y "
+	event.Source = "web"
+
+	_, err = sut.InsertEvent(&event)
+	assert.NoError(t, err)
+
+	result, err := sut.GetEventByUUID(event.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Im. Miss Y", result.Title)
+	assert.Equal(t, "WEB", result.Source)
+
+	sut.Shutdown()
+}
+
+func Test_ConcurrentInsertsDoNotFailWithLock(t *testing.T) {
+	/* GIVEN SQLiteRepository with higher concurrency limit
+	 * WHEN many events are inserted concurrently
+	 * THEN none of inserts should fail
+	 * AND all events should be stored
+	 */
+	var (
+		err  error
+		db   *sql.DB
+		errs = make(chan error, 50)
+		wg   sync.WaitGroup
+	)
+
+	db, err = sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.DBMaxConcurrency = 4
+
+	sut := NewSQLiteRepository(db, cfg)
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			event := TestEvent1
+			event.UUID = fmt.Sprintf("f%031d", i)
+
+			_, err := sut.InsertEvent(&event)
+			errs <- err
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	result, err := sut.FindEventsByUUIDPrefix("f000")
+	assert.NoError(t, err)
+	assert.Len(t, result, cap(errs))
+
+	sut.Shutdown()
+}
+
+func Test_StatusTimestampComesFromClock(t *testing.T) {
+	/* GIVEN a repository with fixed clock
+	 * WHEN an event is inserted
+	 * THEN status timestamp should be taken from the clock
+	 */
+	db, err := sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	clock := &fixedClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	sut.clock = clock
+
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	clock.now = clock.now.Add(time.Hour)
+
+	event := TestEvent1
+	_, err = sut.InsertEvent(&event)
+	assert.NoError(t, err)
+
+	status, err := sut.GetStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, clock.now.Unix(), status.Timestamp)
+
+	sut.Shutdown()
+}
+
+func Test_CategoryAndColorRoundTrip(t *testing.T) {
+	/* GIVEN an event with category and color
+	 * WHEN it is inserted and read back
+	 * THEN category and color should be preserved
+	 * AND events should be filtered by category
+	 */
+	db, err := sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	labeled := TestEvent1
+	labeled.Category = "work"
+	labeled.Color = "#1E90FF"
+
+	_, err = sut.InsertEvent(&labeled)
+	assert.NoError(t, err)
+
+	other := TestEvent2
+
+	_, err = sut.InsertEvent(&other)
+	assert.NoError(t, err)
+
+	stored, err := sut.GetEventByUUID(labeled.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "work", stored.Category)
+	assert.Equal(t, "#1e90ff", stored.Color)
+	assert.Equal(t, labeled.Sha256(), stored.Sha256())
+
+	result, err := sut.GetEventsByCategory("work")
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, labeled.UUID, result[0].UUID)
+
+	result, err = sut.GetEventsByCategory("")
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, other.UUID, result[0].UUID)
+
+	sut.Shutdown()
+}
+
+func Test_BooleanFlagsRoundTrip(t *testing.T) {
+	/* GIVEN events with every combination of done, important and urgent flags
+	 * WHEN they are inserted into SQLite and read back
+	 * THEN flags of every event should be preserved
+	 */
+	db, err := sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	for combination := 0; combination < 8; combination++ {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("b%031d", combination)
+		event.Done = combination&1 != 0
+		event.Important = combination&2 != 0
+		event.Urgent = combination&4 != 0
+
+		_, err = sut.InsertEvent(&event)
+		assert.NoError(t, err)
+
+		stored, err := sut.GetEventByUUID(event.UUID)
+		assert.NoError(t, err)
+		assert.Equal(t, event.Done, stored.Done, event.UUID)
+		assert.Equal(t, event.Important, stored.Important, event.UUID)
+		assert.Equal(t, event.Urgent, stored.Urgent, event.UUID)
+	}
+
+	sut.Shutdown()
+}
+
+func Test_SubscriberIsNotifiedAboutInsert(t *testing.T) {
+	/* GIVEN a repository publishing changes to an event bus
+	 * AND a subscriber of the bus
+	 * WHEN an event is inserted and deleted
+	 * THEN the subscriber should receive insert and delete changes of the event
+	 */
+	db, err := sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	sut.bus = NewEventBus()
+
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	changes := sut.bus.Subscribe()
+	defer sut.bus.Unsubscribe(changes)
+
+	event := TestEvent2
+	event.UUID = "b1b2dd0f43614138995beafa87b6356b"
+
+	_, err = sut.InsertEvent(&event)
+	assert.NoError(t, err)
+
+	_, err = sut.DeleteEvents([]string{event.UUID})
+	assert.NoError(t, err)
+
+	for _, action := range []string{AuditActionInsert, AuditActionDelete} {
+		select {
+		case change := <-changes:
+			assert.Equal(t, EventChange{UUID: event.UUID, Action: action}, change)
+		case <-time.After(time.Second):
+			t.Fatalf("%s change was not published", action)
+		}
+	}
+
+	sut.Shutdown()
+}
+
+func Test_BuildEventQuery(t *testing.T) {
+	/* GIVEN different EventQueryOptions
+	 * WHEN query is built
+	 * THEN values should be passed as arguments and only whitelisted columns used
+	 * AND unknown sort fields and negative pagination should be rejected
+	 */
+	t.Parallel()
+
+	done := true
+
+	query, args, err := buildEventQuery(BackendSQLite, EventQueryOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, selectEventsSQL+" ORDER BY start ASC, uuid ASC", query)
+	assert.Empty(t, args)
+
+	query, args, err = buildEventQuery(BackendSQLite, EventQueryOptions{
+		Limit: 10, Offset: 20, SortBy: "Title", Descending: true, Done: &done, Source: " web ",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, selectEventsSQL+" WHERE done = ? AND source = ? ORDER BY title DESC, uuid DESC LIMIT ? OFFSET ?", query)
+	assert.Equal(t, []any{1, "WEB", 10, 20}, args)
+
+	_, args, err = buildEventQuery(BackendSQLite, EventQueryOptions{Offset: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{-1, 5}, args)
+
+	_, _, err = buildEventQuery(BackendSQLite, EventQueryOptions{SortBy: "start; DROP TABLE events"})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+
+	_, _, err = buildEventQuery(BackendSQLite, EventQueryOptions{Limit: -1})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+
+	query, args = buildCountQuery(BackendSQLite, EventQueryOptions{Limit: 10, SortBy: "title", Done: &done, Source: " web "})
+	assert.Equal(t, "SELECT COUNT(*) FROM events WHERE done = ? AND source = ?", query)
+	assert.Equal(t, []any{1, "WEB"}, args)
+}
+
+func Test_GetAllEventsFiltered(t *testing.T) {
+	/* GIVEN events with different sources, flags and starts
+	 * WHEN GetAllEventsFiltered is called with several option combinations
+	 * THEN only matching events should be returned in requested order and page
+	 */
+	db, err := sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	for i := 0; i < 6; i++ {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("c%031d", i)
+		event.Start.Day = int32(i + 1)
+		event.End.Day = int32(i + 1)
+		event.Done = i%2 == 0
+		event.Source = []string{"APP", "WEB"}[i%3/2]
+		event.Category = []string{"home", "work"}[i%2]
+
+		_, err = sut.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	uuids := func(events []EventData) []string {
+		var result []string
+		for _, e := range events {
+			result = append(result, e.UUID[len(e.UUID)-1:])
+		}
+
+		return result
+	}
+
+	done, notDone := true, false
+	work, title, other := "work", TestEvent1.Title, "Other"
+	never := int64(math.MaxInt64)
+
+	for _, tc := range []struct {
+		opts     EventQueryOptions
+		expected []string
+	}{
+		{EventQueryOptions{}, []string{"0", "1", "2", "3", "4", "5"}},
+		{EventQueryOptions{Descending: true, Limit: 2}, []string{"5", "4"}},
+		{EventQueryOptions{Limit: 2, Offset: 2}, []string{"2", "3"}},
+		{EventQueryOptions{Done: &done}, []string{"0", "2", "4"}},
+		{EventQueryOptions{Done: &notDone, Source: "web"}, []string{"5"}},
+		{EventQueryOptions{Source: "WEB", SortBy: "uuid", Descending: true}, []string{"5", "2"}},
+		{EventQueryOptions{Category: &work, ExcludeUUID: fmt.Sprintf("c%031d", 3)}, []string{"1", "5"}},
+		{EventQueryOptions{Title: &title, UUIDPrefix: "c00", Limit: 1}, []string{"0"}},
+		{EventQueryOptions{Title: &other}, nil},
+		{EventQueryOptions{CreatedFrom: &never}, nil},
+		{EventQueryOptions{CreatedTo: &never, SortBy: "created_at"}, []string{"0", "1", "2", "3", "4", "5"}},
+	} {
+		result, err := sut.GetAllEventsFiltered(tc.opts)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, uuids(result), fmt.Sprintf("%+v", tc.opts))
+	}
+
+	_, err = sut.GetAllEventsFiltered(EventQueryOptions{SortBy: "info"})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+
+	sut.Shutdown()
+}
+
+func Test_QueryEventsFailsOnUnreadableEvent(t *testing.T) {
+	/* GIVEN an event whose info was stored encrypted
+	 * AND repository without the encryption key
+	 * WHEN events are listed, searched or filtered
+	 * THEN the error should be returned instead of dropping the event from the result
+	 */
+	cfg := config.Default()
+	cfg.FieldEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+
+	db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer := NewSQLiteRepository(db, cfg)
+	assert.NoError(t, writer.Migrate())
+
+	event := TestEvent1
+	_, err = writer.InsertEvent(&event)
+	assert.NoError(t, err)
+
+	sut := NewSQLiteRepository(db, config.Default())
+
+	_, err = sut.GetAllEventsFiltered(EventQueryOptions{})
+	assert.ErrorIs(t, err, ErrFieldDecryption)
+
+	_, err = sut.GetEventsByTitle(TestEvent1.Title)
+	assert.ErrorIs(t, err, ErrFieldDecryption)
+
+	writer.Shutdown()
+}
+
+func Test_CountEventsFilteredMatchesFetch(t *testing.T) {
+	/* GIVEN events with different sources and flags
+	 * WHEN CountEventsFiltered is called with several filters
+	 * THEN count should equal number of events GetAllEventsFiltered returns for them
+	 */
+	db, err := sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	for i := 0; i < 6; i++ {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("d%031d", i)
+		event.Done = i%2 == 0
+		event.Important = i%3 == 0
+		event.Source = []string{"APP", "WEB"}[i%3/2]
+
+		_, err = sut.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	done, notDone := true, false
+
+	for _, opts := range []EventQueryOptions{
+		{},
+		{Done: &done},
+		{Done: &notDone, Important: &done},
+		{Source: "web"},
+		{Urgent: &done},
+	} {
+		events, err := sut.GetAllEventsFiltered(opts)
+		assert.NoError(t, err)
+
+		count, err := sut.CountEventsFiltered(opts)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(events)), count, fmt.Sprintf("%+v", opts))
+	}
+
+	/* Pagination limits fetched page only, not the count */
+	count, err := sut.CountEventsFiltered(EventQueryOptions{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), count)
+
+	sut.Shutdown()
+}
+
+func Test_EventQueryOptionsRangeAndText(t *testing.T) {
+	/* GIVEN events lasting an hour on consecutive days with different titles
+	 * WHEN events are fetched and counted by time range and title text
+	 * THEN events overlapping the range, bounds included, and titles containing text should match
+	 * AND count should equal number of fetched events
+	 */
+	cfg := config.Default()
+
+	db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, cfg)
+	assert.NoError(t, sut.Migrate())
+
+	defer sut.Shutdown()
+
+	var starts, ends []int64
+
+	for i, title := range []string{"Team sync", "Dentist", "TEAM 50% review", "Team 5 review"} {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("f%031d", i)
+		event.Title = title
+		event.Start.Day = int32(i + 1)
+		event.End.Day = int32(i + 1)
+		event.End.Hour = 1
+
+		_, err = sut.InsertEvent(&event)
+		assert.NoError(t, err)
+
+		start, err := dateTimeToUnix(&event.Start, cfg.TimeZone)
+		assert.NoError(t, err)
+
+		end, err := dateTimeToUnix(&event.End, cfg.TimeZone)
+		assert.NoError(t, err)
+
+		starts, ends = append(starts, start), append(ends, end)
+	}
+
+	at := func(unix int64) *int64 { return &unix }
+
+	for _, tc := range []struct {
+		opts     EventQueryOptions
+		expected []string
+	}{
+		{EventQueryOptions{Start: at(ends[1]), End: at(starts[2])}, []string{"1", "2"}},
+		{EventQueryOptions{Start: at(ends[1] + 1), End: at(starts[2] - 1)}, nil},
+		{EventQueryOptions{Start: at(starts[1] + 1), End: at(ends[1] - 1)}, []string{"1"}},
+		{EventQueryOptions{Start: at(starts[3])}, []string{"3"}},
+		{EventQueryOptions{End: at(ends[0])}, []string{"0"}},
+		{EventQueryOptions{Text: "team"}, []string{"0", "2", "3"}},
+		{EventQueryOptions{Text: "50%"}, []string{"2"}},
+		{EventQueryOptions{Text: "review", Start: at(ends[3])}, []string{"3"}},
+	} {
+		events, err := sut.GetAllEventsFiltered(tc.opts)
+		assert.NoError(t, err)
+
+		var uuids []string
+		for _, e := range events {
+			uuids = append(uuids, e.UUID[len(e.UUID)-1:])
+		}
+
+		assert.Equal(t, tc.expected, uuids, fmt.Sprintf("%+v", tc.opts))
+
+		count, err := sut.CountEventsFiltered(tc.opts)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(tc.expected)), count, fmt.Sprintf("%+v", tc.opts))
+	}
+}
+
+func Test_SourceAllowlistAppliesToNewEventsOnly(t *testing.T) {
+	/* GIVEN an event stored with a source before the allowlist was narrowed
+	 * WHEN it is updated and new events with the same source are inserted
+	 * THEN the update should be stored
+	 * AND both upsert and insert-only of a new event should fail with ErrInvalidEvent
+	 */
+	cfg := config.Default()
+	cfg.AllowedSources = config.ParseSources("LEGACY")
+
+	db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, cfg)
+	assert.NoError(t, sut.Migrate())
+
+	defer sut.Shutdown()
+
+	event := TestEvent1
+	event.Source = "LEGACY"
+
+	_, err = sut.InsertEvent(&event)
+	assert.NoError(t, err)
+
+	cfg.AllowedSources = config.ParseSources("APP,WEB")
+
+	event.Title = "Renamed"
+
+	_, err = sut.InsertEvent(&event)
+	assert.NoError(t, err)
+
+	stored, err := sut.GetEventByUUID(event.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", stored.Title)
+	assert.Equal(t, "LEGACY", stored.Source)
+
+	event.UUID = TestEvent2.UUID
+
+	_, err = sut.InsertEvent(&event)
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+
+	inserted, err := sut.InsertIfAbsent(&event)
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	assert.False(t, inserted)
+
+	_, err = sut.GetEventByUUID(TestEvent2.UUID)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+}
+
+func Test_GetEventByUUIDDistinguishesMissingEvent(t *testing.T) {
+	/* GIVEN a database with one event
+	 * WHEN GetEventByUUID is called for it and for an unknown UUID
+	 * THEN the stored event should be returned for the known one
+	 * AND ErrEventNotFound with zero event for the unknown one
+	 */
+	db, err := sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	event := TestEvent1
+	event.UUID = "d0000000000000000000000000000001"
+
+	_, err = sut.InsertEvent(&event)
+	assert.NoError(t, err)
+
+	stored, err := sut.GetEventByUUID(event.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, event.Sha256(), stored.Sha256())
+
+	missing, err := sut.GetEventByUUID("d0000000000000000000000000000009")
+	assert.ErrorIs(t, err, ErrEventNotFound)
+	assert.Equal(t, EventData{}, missing)
+
+	sut.Shutdown()
+}
+
+func Test_ImportDedupSkipsContentDuplicate(t *testing.T) {
+	/* GIVEN a repository with import deduplication enabled and one stored event
+	 * WHEN an event with other UUID but the same content is inserted
+	 * THEN it should be rejected with ErrDuplicateEvent and not stored
+	 * AND FindEventByContentHash should return the original event
+	 * AND events with different content should still be inserted
+	 */
+	db, err := sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.ImportDedup = true
+
+	sut := NewSQLiteRepository(db, cfg)
+	err = sut.Migrate()
+	assert.NoError(t, err)
+
+	original := TestEvent1
+	original.UUID = "d2000000000000000000000000000001"
+
+	_, err = sut.InsertEvent(&original)
+	assert.NoError(t, err)
+
+	duplicate := original
+	duplicate.UUID = "d2000000000000000000000000000002"
+	duplicate.Info = "Imported again"
+
+	_, err = sut.InsertEvent(&duplicate)
+	assert.ErrorIs(t, err, ErrDuplicateEvent)
+
+	_, err = sut.GetEventByUUID(duplicate.UUID)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+
+	found, err := sut.FindEventByContentHash(duplicate.ContentHash())
+	assert.NoError(t, err)
+	assert.Equal(t, original.UUID, found.UUID)
+
+	other := TestEvent2
+	other.UUID = "d2000000000000000000000000000003"
+
+	_, err = sut.InsertEvent(&other)
+	assert.NoError(t, err)
+
+	/* Updating stored event is not affected by deduplication */
+	original.Info = "Updated"
+
+	_, err = sut.InsertEvent(&original)
+	assert.NoError(t, err)
+
+	sut.Shutdown()
+}
+
+func Test_TruncateIsolatesSequentialTests(t *testing.T) {
+	/* GIVEN two sequential tests sharing the in-memory database
+	 * WHEN each truncates the repository in its setup and inserts an event
+	 * THEN each should see only its own event
+	 * AND databases of different names should not share events
+	 */
+	db, err := sql.Open("sqlite3", SQLFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	assert.NoError(t, sut.Migrate())
+
+	for _, event := range []EventData{TestEvent1, TestEvent2} {
+		event := event
+
+		t.Run(event.UUID, func(t *testing.T) {
+			assert.NoError(t, sut.Truncate())
+
+			_, err := sut.InsertEvent(&event)
+			assert.NoError(t, err)
+
+			events, err := sut.GetAllEvents()
+			assert.NoError(t, err)
+
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, event.UUID, events[0].UUID)
+			}
+		})
+	}
+
+	sut.Shutdown()
+
+	other, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fresh := NewSQLiteRepository(other, config.Default())
+	assert.NoError(t, fresh.Migrate())
+
+	events, err := fresh.GetAllEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	fresh.Shutdown()
+}
+
+func Test_InsertRespectsPerUserQuota(t *testing.T) {
+	/* GIVEN a repository limiting users to two events
+	 * WHEN a user inserts events up to and beyond the quota
+	 * THEN events up to the quota should be stored
+	 * AND the one beyond it rejected with ErrQuotaExceeded
+	 * AND updates of owned events and inserts of other users should not be affected
+	 */
+	db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.MaxEventsPerUser = 2
+
+	sut := NewSQLiteRepository(db, cfg)
+	assert.NoError(t, sut.Migrate())
+
+	defer sut.Shutdown()
+
+	insert := func(owner, uuid, title string) error {
+		e := TestEvent1
+		e.UUID = uuid
+		e.Title = title
+		e.Owner = owner
+
+		_, err := sut.InsertEvent(&e)
+
+		return err
+	}
+
+	assert.NoError(t, insert("alice", "q1000000000000000000000000000001", "First"))
+	assert.NoError(t, insert("alice", "q1000000000000000000000000000002", "Second"))
+	assert.ErrorIs(t, insert("alice", "q1000000000000000000000000000003", "Third"), ErrQuotaExceeded)
+
+	_, err = sut.GetEventByUUID("q1000000000000000000000000000003")
+	assert.ErrorIs(t, err, ErrEventNotFound)
+
+	assert.NoError(t, insert("alice", "q1000000000000000000000000000002", "Second, renamed"))
+	assert.NoError(t, insert("bob", "q1000000000000000000000000000004", "Bob's"))
+
+	updated, err := sut.GetEventByUUID("q1000000000000000000000000000002")
+	assert.NoError(t, err)
+	assert.Equal(t, "Second, renamed", updated.Title)
+}
+
+func Test_LoginRehashesPasswordOfOldAlgorithm(t *testing.T) {
+	/* GIVEN a user whose password hash was produced by an algorithm other than configured
+	 * WHEN the user authenticates with correct and wrong password
+	 * THEN the correct password should be accepted and stored again using configured algorithm
+	 * AND the wrong one should be rejected without touching the stored hash
+	 */
+	registerLegacyTestHasher(t)
+
+	db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	assert.NoError(t, sut.Migrate())
+
+	defer sut.Shutdown()
+
+	storedHash := func() string {
+		var hash string
+
+		assert.NoError(t, sut.handle().QueryRow("SELECT password FROM users WHERE username = ?;", "alice").Scan(&hash))
+
+		return hash
+	}
+
+	assert.NoError(t, sut.UpsertUser("alice", "$legacy$S3cret!", true))
+
+	authenticated, err := sut.AuthenticateUser("alice", "wrong")
+	assert.NoError(t, err)
+	assert.False(t, authenticated)
+	assert.Equal(t, "$legacy$S3cret!", storedHash())
+
+	authenticated, err = sut.AuthenticateUser("alice", "S3cret!")
+	assert.NoError(t, err)
+	assert.True(t, authenticated)
+	assert.True(t, bcryptHasher{}.Owns(storedHash()))
+
+	authenticated, err = sut.AuthenticateUser("alice", "S3cret!")
+	assert.NoError(t, err)
+	assert.True(t, authenticated)
+}
+
+func Test_LoginMigratesBetweenBcryptAndArgon2id(t *testing.T) {
+	/* GIVEN a user with bcrypt hash and argon2id configured, and the other way round
+	 * WHEN the user authenticates with correct password
+	 * THEN the stored hash should be verified by its own algorithm
+	 * AND stored again using configured one
+	 */
+	for _, tc := range []struct {
+		from, to string
+	}{
+		{from: "bcrypt", to: "argon2id"},
+		{from: "argon2id", to: "bcrypt"},
+	} {
+		t.Run(tc.from+"To"+tc.to, func(t *testing.T) {
+			db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			cfg := config.Default()
+			cfg.PasswordAlgo = tc.to
+
+			sut := NewSQLiteRepository(db, cfg)
+			assert.NoError(t, sut.Migrate())
+
+			defer sut.Shutdown()
+
+			from, err := passwordHasher(tc.from)
+			assert.NoError(t, err)
+
+			to, err := passwordHasher(tc.to)
+			assert.NoError(t, err)
+
+			oldHash, err := from.Hash("S3cret!")
+			assert.NoError(t, err)
+			assert.NoError(t, sut.UpsertUser("alice", oldHash, true))
+
+			authenticated, err := sut.AuthenticateUser("alice", "S3cret!")
+			assert.NoError(t, err)
+			assert.True(t, authenticated)
+
+			var newHash string
+
+			assert.NoError(t, sut.handle().QueryRow("SELECT password FROM users WHERE username = ?;", "alice").Scan(&newHash))
+			assert.True(t, to.Owns(newHash))
+			assert.True(t, to.Verify("S3cret!", newHash))
+
+			authenticated, err = sut.AuthenticateUser("alice", "S3cret!")
+			assert.NoError(t, err)
+			assert.True(t, authenticated)
+		})
+	}
+}
+
+func Test_DeletingEventRemovesItsAttachment(t *testing.T) {
+	/* GIVEN an event with an attachment stored in a blob store
+	 * WHEN the event is deleted by any of the delete paths
+	 * THEN its attachment row and blob should be gone
+	 * AND an event created later with the same UUID should have no attachment
+	 */
+	for name, remove := range map[string]func(sut *SQLiteRepository, uuid string) error{
+		"DeleteEvent": func(sut *SQLiteRepository, uuid string) error {
+			_, err := sut.DeleteEvent(&EventData{UUID: uuid})
+			return err
+		},
+		"DeleteEvents": func(sut *SQLiteRepository, uuid string) error {
+			_, err := sut.DeleteEvents([]string{uuid})
+			return err
+		},
+		"DeleteEventsEndedBefore": func(sut *SQLiteRepository, uuid string) error {
+			_, err := sut.DeleteEventsEndedBefore(sut.clock.Now().Unix())
+			return err
+		},
+		"MergeEvents": func(sut *SQLiteRepository, uuid string) error {
+			/* Attachment of the secondary is moved to primary without one, so give it one */
+			primary := TestEvent2
+			if _, err := sut.InsertEvent(&primary); err != nil {
+				return err
+			}
+
+			if _, err := sut.SetAttachment(Attachment{EventUUID: primary.UUID, ContentType: "text/plain", Size: 1, Key: "blob2"}); err != nil {
+				return err
+			}
+
+			_, err := sut.MergeEvents(primary.UUID, uuid)
+
+			return err
+		},
+	} {
+		remove := remove
+
+		t.Run(name, func(t *testing.T) {
+			db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			sut := NewSQLiteRepository(db, config.Default())
+			assert.NoError(t, sut.Migrate())
+
+			defer sut.Shutdown()
+
+			root := t.TempDir()
+
+			sut.blobs, err = NewLocalBlobStore(root)
+			assert.NoError(t, err)
+
+			e := TestEvent1
+			_, err = sut.InsertEvent(&e)
+			assert.NoError(t, err)
+
+			assert.NoError(t, sut.blobs.Put("blob1", strings.NewReader("meeting notes")))
+
+			_, err = sut.SetAttachment(Attachment{EventUUID: e.UUID, ContentType: "text/plain", Size: 13, Key: "blob1"})
+			assert.NoError(t, err)
+
+			assert.NoError(t, remove(sut, e.UUID))
+
+			_, err = sut.GetAttachment(e.UUID)
+			assert.ErrorIs(t, err, ErrAttachmentNotFound)
+
+			_, err = os.Stat(filepath.Join(root, "blob1"))
+			assert.ErrorIs(t, err, os.ErrNotExist)
+
+			e = TestEvent1
+			_, err = sut.InsertEvent(&e)
+			assert.NoError(t, err)
+
+			_, err = sut.GetAttachment(e.UUID)
+			assert.ErrorIs(t, err, ErrAttachmentNotFound)
+		})
+	}
+}
+
+func Test_MergeEventsMovesAttachmentToPrimaryWithout(t *testing.T) {
+	/* GIVEN primary event without attachment and secondary event with one
+	 * WHEN they are merged
+	 * THEN the attachment should belong to the primary event with its blob kept
+	 */
+	db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	assert.NoError(t, sut.Migrate())
+
+	defer sut.Shutdown()
+
+	root := t.TempDir()
+
+	sut.blobs, err = NewLocalBlobStore(root)
+	assert.NoError(t, err)
+
+	primary, secondary := TestEvent1, TestEvent2
+
+	for _, e := range []*EventData{&primary, &secondary} {
+		_, err = sut.InsertEvent(e)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, sut.blobs.Put("blob1", strings.NewReader("meeting notes")))
+
+	_, err = sut.SetAttachment(Attachment{EventUUID: secondary.UUID, ContentType: "text/plain", Size: 13, Key: "blob1"})
+	assert.NoError(t, err)
+
+	_, err = sut.MergeEvents(primary.UUID, secondary.UUID)
+	assert.NoError(t, err)
+
+	a, err := sut.GetAttachment(primary.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "blob1", a.Key)
+
+	_, err = sut.GetAttachment(secondary.UUID)
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+
+	_, err = os.Stat(filepath.Join(root, "blob1"))
+	assert.NoError(t, err)
+}
+
+func Test_GetDueRemindersOfUsersWithEmail(t *testing.T) {
+	/* GIVEN events of users with and without email, some of them due for a reminder
+	 * WHEN due reminders are read before and after one is marked as delivered
+	 * THEN only upcoming, not done events within their reminder period owned by users with email should be returned
+	 * AND the delivered one should not be returned again until the event is moved
+	 */
+	db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.TimeZone = "UTC"
+
+	sut := NewSQLiteRepository(db, cfg)
+	assert.NoError(t, sut.Migrate())
+
+	defer sut.Shutdown()
+
+	assert.NoError(t, sut.AddUser("alice", "$2a$alice", true))
+	assert.NoError(t, sut.AddUser("bob", "$2a$bob", true))
+	assert.NoError(t, sut.SetUserEmail("alice", "alice@example.com"))
+
+	insert := func(owner, uuid string, day int32, done bool) {
+		e := TestEvent1
+		e.UUID = uuid
+		e.Owner = owner
+		e.Done = done
+		e.Start = DateTime{Common{Type: DateTimeStructName}, 2021, 1, day, 0, 0}
+		e.End = e.Start
+
+		_, err := sut.InsertEvent(&e)
+		assert.NoError(t, err)
+	}
+
+	/* TestEvent1 is reminded 7 days before start */
+	insert("alice", "r1000000000000000000000000000001", 12, false)
+	insert("alice", "r1000000000000000000000000000002", 12, true)
+	insert("alice", "r1000000000000000000000000000003", 30, false)
+	insert("alice", "r1000000000000000000000000000004", 1, false)
+	insert("bob", "r1000000000000000000000000000005", 12, false)
+
+	now := time.Date(2021, 1, 6, 0, 0, 0, 0, time.UTC).Unix()
+
+	due, err := sut.GetDueReminders(now)
+	assert.NoError(t, err)
+
+	if assert.Len(t, due, 1) {
+		assert.Equal(t, "r1000000000000000000000000000001", due[0].Event.UUID)
+		assert.Equal(t, "alice@example.com", due[0].Email)
+	}
+
+	assert.NoError(t, sut.MarkReminded("r1000000000000000000000000000001"))
+
+	due, err = sut.GetDueReminders(now)
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+
+	insert("alice", "r1000000000000000000000000000001", 11, false)
+
+	due, err = sut.GetDueReminders(now)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+}
+
+func Test_InsertIfAbsentNeverOverwrites(t *testing.T) {
+	/* GIVEN an empty repository
+	 * WHEN an event is inserted if absent twice with different titles
+	 * THEN the first call should store it and report insertion
+	 * AND the second should report no insertion and leave stored event untouched
+	 */
+	db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	assert.NoError(t, sut.Migrate())
+
+	defer sut.Shutdown()
+
+	e := TestEvent1
+	e.UUID = "i1000000000000000000000000000001"
+
+	inserted, err := sut.InsertIfAbsent(&e)
+	assert.NoError(t, err)
+	assert.True(t, inserted)
+	assert.NotEqual(t, int64(0), e.ID)
+
+	changed := e
+	changed.Title = "Overwritten"
+
+	inserted, err = sut.InsertIfAbsent(&changed)
+	assert.NoError(t, err)
+	assert.False(t, inserted)
+
+	stored, err := sut.GetEventByUUID(e.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, TestEvent1.Title, stored.Title)
+}
+
+func Test_FieldEncryptionRoundTrip(t *testing.T) {
+	/* GIVEN repositories with and without field encryption key
+	 * WHEN an event is inserted and read back
+	 * THEN info and address should be read as they were inserted
+	 * AND with the key, the encrypted columns should not hold plain text
+	 * AND content hash should be the same, so deduplication still works
+	 */
+	for _, tc := range []struct {
+		name    string
+		key     []byte
+		address bool
+	}{
+		{"plain", nil, false},
+		{"info", []byte("0123456789abcdef0123456789abcdef"), false},
+		{"info and address", []byte("0123456789abcdef"), true},
+	} {
+		var storedAddress, storedInfo, storedHash string
+
+		cfg := config.Default()
+		cfg.FieldEncryptionKey = tc.key
+		cfg.EncryptAddress = tc.address
+
+		db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()+tc.name))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		sut := NewSQLiteRepository(db, cfg)
+		assert.NoError(t, sut.Migrate(), tc.name)
+
+		e := TestEvent1
+		_, err = sut.InsertEvent(&e)
+		assert.NoError(t, err, tc.name)
+
+		stored, err := sut.GetEventByUUID(e.UUID)
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, TestEvent1.Info, stored.Info, tc.name)
+		assert.Equal(t, TestEvent1.Address, stored.Address, tc.name)
+
+		err = sut.handle().QueryRow("SELECT address, info, content_hash FROM events WHERE uuid = ?;", e.UUID).
+			Scan(&storedAddress, &storedInfo, &storedHash)
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.key == nil, storedInfo == TestEvent1.Info, tc.name)
+		assert.Equal(t, !tc.address, storedAddress == TestEvent1.Address, tc.name)
+		assert.Equal(t, TestEvent1.ContentHash(), storedHash, tc.name)
+
+		locations, err := sut.GetLocations("warszawa", 10)
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, []string{TestEvent1.Address}, locations, tc.name)
+
+		sut.Shutdown()
+	}
+}
+
+func Test_SlowOperationIsLogged(t *testing.T) {
+	/* GIVEN a repository with slow query threshold of 1ms logging to a pipe
+	 * WHEN an operation takes longer and another one is measured with high threshold
+	 * THEN a warning naming only the slow operation should be logged
+	 */
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	/* Logger binds standard output when created */
+	stdout := os.Stdout
+	os.Stdout = writer
+	slowLog := logger.NewConsoleLogger("TEST", logger.WARNING)
+	os.Stdout = stdout
+
+	cfg := config.Default()
+	cfg.SlowQuery = time.Millisecond
+
+	sut := &SQLiteRepository{cfg: cfg, log: slowLog}
+
+	done := sut.timed("GetAllEvents")
+	time.Sleep(5 * time.Millisecond)
+	done()
+
+	cfg.SlowQuery = time.Hour
+	sut.timed("GetStatus")()
+
+	writer.Close()
+
+	output, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), "WARNING: Slow database operation GetAllEvents took")
+	assert.NotContains(t, string(output), "GetStatus")
+}
+
+func Test_BusyDatabaseIsRetried(t *testing.T) {
+	/* GIVEN a repository allowing 2 busy retries
+	 * WHEN an operation fails once with locked database and then succeeds
+	 * THEN the successful result should be returned after one retry
+	 * AND other errors should be returned without retrying
+	 * AND a database busy for longer should fail after all retries
+	 */
+	cfg := config.Default()
+	cfg.SQLiteBusyRetries = 2
+	cfg.SQLiteBusyBackoff = time.Millisecond
+
+	sut := &SQLiteRepository{cfg: cfg, log: logger.NewConsoleLogger("TEST", logger.CRITICAL)}
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+
+	failing := func(failures int, err error) (func() error, *int) {
+		calls := 0
+
+		return func() error {
+			calls++
+			if calls <= failures {
+				return err
+			}
+
+			return nil
+		}, &calls
+	}
+
+	op, calls := failing(1, busy)
+	assert.NoError(t, sut.retryBusy(op))
+	assert.Equal(t, 2, *calls)
+
+	op, calls = failing(1, errors.New("UNIQUE constraint failed: events.uuid"))
+	assert.Error(t, sut.retryBusy(op))
+	assert.Equal(t, 1, *calls)
+
+	op, calls = failing(1, fmt.Errorf("wrapped: %w", sqlite3.Error{Code: sqlite3.ErrLocked}))
+	assert.NoError(t, sut.retryBusy(op))
+	assert.Equal(t, 2, *calls)
+
+	op, calls = failing(1, errors.New("database is locked"))
+	assert.Error(t, sut.retryBusy(op))
+	assert.Equal(t, 1, *calls)
+
+	op, calls = failing(10, busy)
+	assert.ErrorIs(t, sut.retryBusy(op), busy)
+	assert.Equal(t, 3, *calls)
+}
+
+func Test_WritesAreRetriedWhileAnotherConnectionHoldsLock(t *testing.T) {
+	/* GIVEN a file database locked for writing by another connection for a short while
+	 * WHEN events are written by standalone and transactional repository operations
+	 * THEN the operations should fail with busy database without retries
+	 * AND succeed once retried after the lock is released
+	 */
+	file := filepath.Join(t.TempDir(), "events.db")
+
+	cfg := config.Default()
+	cfg.SQLiteBusyRetries = 0
+	cfg.SQLiteBusyBackoff = 10 * time.Millisecond
+
+	/* Driver would otherwise wait for the lock itself */
+	db, err := sql.Open("sqlite3", "file:"+file+"?_busy_timeout=0")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, cfg)
+	sut.log = logger.NewConsoleLogger("TEST", logger.CRITICAL)
+	assert.NoError(t, sut.Migrate())
+
+	defer sut.Shutdown()
+
+	other, err := sql.Open("sqlite3", "file:"+file)
+	assert.NoError(t, err)
+
+	defer other.Close()
+
+	lock := func() func() {
+		conn, err := other.Conn(context.Background())
+		assert.NoError(t, err)
+
+		_, err = conn.ExecContext(context.Background(), "BEGIN IMMEDIATE;")
+		assert.NoError(t, err)
+
+		return func() {
+			_, err := conn.ExecContext(context.Background(), "ROLLBACK;")
+			assert.NoError(t, err)
+			assert.NoError(t, conn.Close())
+		}
+	}
+
+	unlock := lock()
+
+	e := TestEvent1
+	_, err = sut.InsertEvent(&e)
+	assert.True(t, isBusyError(err))
+
+	_, err = sut.DeleteEvents([]string{e.UUID})
+	assert.True(t, isBusyError(err))
+
+	unlock()
+
+	cfg.SQLiteBusyRetries = 5
+
+	for _, write := range []func() error{
+		func() error {
+			e := TestEvent1
+			_, err := sut.InsertEvent(&e)
+
+			return err
+		},
+		func() error {
+			_, err := sut.DeleteEvents([]string{TestEvent1.UUID})
+			return err
+		},
+	} {
+		unlock = lock()
+
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			unlock()
+		}()
+
+		assert.NoError(t, write())
+	}
+
+	_, err = sut.GetEventByUUID(TestEvent1.UUID)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+}
+
+func Test_WALAllowsReadDuringWrite(t *testing.T) {
+	/* GIVEN a file database migrated with write-ahead log enabled
+	 * WHEN a write transaction is open
+	 * THEN journal mode and busy timeout should be applied
+	 * AND other connection should read committed events without waiting for the writer
+	 */
+	var (
+		mode    string
+		timeout int64
+		count   int
+	)
+
+	cfg := config.Default()
+	cfg.DatabaseFile = "file:" + filepath.Join(t.TempDir(), "events.db")
+	cfg.SQLiteWAL = true
+	cfg.SQLiteBusyTimeout = 250 * time.Millisecond
+
+	db, err := sql.Open("sqlite3", cfg.DatabaseFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, cfg)
+	assert.NoError(t, sut.Migrate())
+
+	defer sut.Shutdown()
+
+	assert.NoError(t, sut.handle().QueryRow("PRAGMA journal_mode;").Scan(&mode))
+	assert.Equal(t, "wal", mode)
+	assert.NoError(t, sut.handle().QueryRow("PRAGMA busy_timeout;").Scan(&timeout))
+	assert.Equal(t, int64(250), timeout)
+
+	e := TestEvent1
+	_, err = sut.InsertEvent(&e)
+	assert.NoError(t, err)
+
+	tx, err := sut.handle().Begin()
+	assert.NoError(t, err)
+
+	_, err = tx.Exec("UPDATE events SET title = 'Uncommitted' WHERE uuid = ?;", e.UUID)
+	assert.NoError(t, err)
+
+	reader, err := sql.Open("sqlite3", cfg.DatabaseFile)
+	assert.NoError(t, err)
+
+	defer reader.Close()
+
+	err = reader.QueryRow("SELECT COUNT(*) FROM events WHERE title = ?;", TestEvent1.Title).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	assert.NoError(t, tx.Rollback())
+}
+
+func Test_ForEachEventVisitsEveryEventOnce(t *testing.T) {
+	/* GIVEN seven events and batch size of three
+	 * WHEN events are iterated with ForEachEvent
+	 * THEN the callback should see every event exactly once
+	 * AND iteration should stop with the first error of the callback
+	 */
+	db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sut := NewSQLiteRepository(db, config.Default())
+	assert.NoError(t, sut.Migrate())
+
+	defer sut.Shutdown()
+
+	for i := 0; i < 7; i++ {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("e%031d", i)
+
+		_, err = sut.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	seen := map[string]int{}
+
+	err = sut.ForEachEvent(3, func(e EventData) error {
+		seen[e.UUID]++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, seen, 7)
+
+	for uuid, count := range seen {
+		assert.Equal(t, 1, count, uuid)
+	}
+
+	stop := errors.New("stop")
+	visited := 0
+
+	err = sut.ForEachEvent(3, func(e EventData) error {
+		visited++
+		if visited == 4 {
+			return stop
+		}
+
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 4, visited)
+
+	assert.ErrorIs(t, sut.ForEachEvent(0, func(EventData) error { return nil }), ErrInvalidQuery)
 }
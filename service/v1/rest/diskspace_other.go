@@ -0,0 +1,12 @@
+//go:build !unix
+
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+func diskFreeBytes(string) (uint64, error) {
+	/* Free space is not checked on platforms without statfs, see readyz */
+	return 0, errDiskSpaceUnsupported
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import "syscall"
+
+func diskFreeBytes(dir string) (uint64, error) {
+	/* Space available to unprivileged writers, root reserved blocks do not count */
+	var stat syscall.Statfs_t
+
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+
+	//nolint:gosec // Block size is never negative
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
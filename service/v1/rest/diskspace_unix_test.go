@@ -0,0 +1,28 @@
+//go:build unix
+
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiskFreeBytesOfExistingDirectory(t *testing.T) {
+	/* GIVEN an existing and a missing directory
+	 * WHEN their free space is checked
+	 * THEN some space should be reported for the first one and an error for the second one
+	 */
+	t.Parallel()
+
+	free, err := diskFreeBytes(t.TempDir())
+	assert.NoError(t, err)
+	assert.Positive(t, free)
+
+	_, err = diskFreeBytes("/nonexistent/eventshub")
+	assert.Error(t, err)
+}
@@ -0,0 +1,448 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Errors returned while fetching and parsing remote feeds. ErrFeedURLNotAllowed
+// is caused by the request, the others by the remote server or its content.
+// ErrImportTooLarge is returned by importers reading more than cfg.MaxImportBytes.
+var (
+	ErrFeedURLNotAllowed = errors.New("feed URL not allowed")
+	ErrFeedFetch         = errors.New("failed to fetch feed")
+	ErrFeedMalformed     = errors.New("malformed feed")
+	ErrImportTooLarge    = errors.New("import too large")
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// cgnatPrefix is the shared address space (RFC 6598) not covered by netip.Addr.IsPrivate.
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// XMLFeed is the root of the XML calendar export read by xmlparser and importFromURL.
+type XMLFeed struct {
+	XMLName xml.Name       `xml:"root"`
+	Events  []XMLFeedEvent `xml:"event"`
+}
+
+type XMLFeedEvent struct {
+	XMLName   xml.Name `xml:"event"`
+	Version   string   `xml:"ver,attr"`
+	UUID      string   `xml:"uuid,attr"`
+	Start     string   `xml:"start,attr"`
+	End       string   `xml:"end,attr"`
+	Remind    string   `xml:"remind,attr"`
+	Done      string   `xml:"done,attr"`
+	Urgent    string   `xml:"urgent,attr"`
+	Important string   `xml:"important,attr"`
+	Title     string   `xml:"title,attr"`
+	Address   string   `xml:"address,attr"`
+	Info      string   `xml:"info,attr"`
+}
+
+func (xe *XMLFeedEvent) EventData(layouts []string) (EventData, error) {
+	// EventData converts event of the XML export, which dates are local
+	// `YYYY-MM-DD hh:mm` strings and flags `Yes`/`No` values.
+	// Exports of other dialects may leave optional attributes out, missing text
+	// is empty, missing flags are false and missing reminder is zero.
+	//
+	// Parameter: XMLFeedEvent object (self), additional date layouts of other exports.
+	// Return type: EventData with XML source, error naming the malformed attribute.
+	var (
+		event EventData
+		err   error
+	)
+
+	malformed := func(attr, value string) error {
+		return fmt.Errorf("%w: attribute %s %q of event %s", ErrFeedMalformed, attr, value, xe.UUID)
+	}
+
+	event.Version = xe.Version
+	event.UUID = xe.UUID
+	event.Title = xe.Title
+	event.Address = xe.Address
+	event.Info = xe.Info
+	event.Source = "XML"
+
+	for _, flag := range []struct {
+		attr  string
+		value string
+		dest  *bool
+	}{
+		{"done", xe.Done, &event.Done},
+		{"important", xe.Important, &event.Important},
+		{"urgent", xe.Urgent, &event.Urgent},
+	} {
+		switch strings.ToLower(strings.TrimSpace(flag.value)) {
+		case "", "no", "false", "0":
+			*flag.dest = false
+		case "yes", "true", "1":
+			*flag.dest = true
+		default:
+			return event, malformed(flag.attr, flag.value)
+		}
+	}
+
+	if event.Start, err = ParseXMLDate(xe.Start, layouts); err != nil {
+		return event, malformed("start", xe.Start)
+	}
+
+	if event.End, err = ParseXMLDate(xe.End, layouts); err != nil {
+		return event, malformed("end", xe.End)
+	}
+
+	if remind := strings.TrimSpace(xe.Remind); remind != "" {
+		reminder, err := strconv.ParseInt(remind, 10, 32)
+		if err != nil || reminder < 0 {
+			return event, malformed("remind", xe.Remind)
+		}
+
+		event.Reminder = int32(reminder)
+	}
+
+	/* XML export has no labels, event is left without category and color */
+	return event, nil
+}
+
+// XMLDateLayout is the date format of the XML export, always tried first.
+const XMLDateLayout = "2006-01-02 15:04"
+
+// ParseXMLDate converts local date of an XML export to DateTime. XMLDateLayout
+// is tried first and then additional time.Parse layouts, e.g. `02/01/2006 15:04`
+// of exports made with other locale. Date matching none of them is an error.
+func ParseXMLDate(s string, layouts []string) (DateTime, error) {
+	s = strings.TrimSpace(s)
+
+	for _, layout := range append([]string{XMLDateLayout}, layouts...) {
+		if t, err := time.Parse(layout, s); err == nil {
+			return timeToDateTime(t), nil
+		}
+	}
+
+	return DateTime{}, fmt.Errorf("%w: date %q matches none of %d layouts", ErrFeedMalformed, s, len(layouts)+1)
+}
+
+//nolint:gosec // Only integers used for date are for conversion so no integer overflow possible
+func timeToDateTime(t time.Time) DateTime {
+	/* Convert wall clock of t to DateTime, without changing its location */
+	return DateTime{
+		Common: Common{Type: DateTimeStructName},
+		Year:   int32(t.Year()),
+		Month:  int32(t.Month()),
+		Day:    int32(t.Day()),
+		Hour:   int32(t.Hour()),
+		Minute: int32(t.Minute()),
+	}
+}
+
+func parseXMLFeed(data []byte) ([]*EventData, error) {
+	/* Parse all events of the XML export */
+	var root XMLFeed
+
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFeedMalformed, err)
+	}
+
+	events := make([]*EventData, 0, len(root.Events))
+
+	for i := range root.Events {
+		event, err := root.Events[i].EventData(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+func parseICalFeed(data []byte, timeZone string) ([]*EventData, error) {
+	/* Parse VEVENT components of an iCalendar (RFC 5545) feed. Times are converted to
+	 * timeZone, floating ones are taken as they are. Only properties having
+	 * EventData counterpart are read, others are ignored.
+	 */
+	var (
+		events []*EventData
+		event  map[string]icalProperty
+	)
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range unfoldICalLines(data) {
+		prop, ok := parseICalProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case prop.name == "BEGIN" && prop.value == "VEVENT":
+			event = map[string]icalProperty{}
+		case prop.name == "END" && prop.value == "VEVENT" && event != nil:
+			e, err := icalEventData(event, loc)
+			if err != nil {
+				return nil, err
+			}
+
+			events = append(events, e)
+			event = nil
+		case event != nil:
+			event[prop.name] = prop
+		}
+	}
+
+	return events, nil
+}
+
+type icalProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func unfoldICalLines(data []byte) []string {
+	/* Split content to lines, joining folded ones which continue with a leading space or tab */
+	var lines []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func parseICalProperty(line string) (icalProperty, bool) {
+	/* Split `NAME;PARAM=value:VALUE` line, colons inside quoted parameter values are skipped */
+	quoted := false
+
+	for i, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ':' && !quoted:
+			parts := strings.Split(line[:i], ";")
+			prop := icalProperty{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[i+1:]}
+
+			for _, param := range parts[1:] {
+				key, value, _ := strings.Cut(param, "=")
+				prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+			}
+
+			return prop, true
+		}
+	}
+
+	return icalProperty{}, false
+}
+
+func icalEventData(props map[string]icalProperty, loc *time.Location) (*EventData, error) {
+	/* Convert properties of a single VEVENT to EventData */
+	uid := props["UID"].value
+	if uid == "" {
+		return nil, fmt.Errorf("%w: event without UID", ErrFeedMalformed)
+	}
+
+	start, err := icalTime(props["DTSTART"], loc)
+	if err != nil {
+		return nil, err
+	}
+
+	end := start
+	if prop, ok := props["DTEND"]; ok {
+		if end, err = icalTime(prop, loc); err != nil {
+			return nil, err
+		}
+	}
+
+	return &EventData{
+		Version: props["SEQUENCE"].value,
+		UUID:    icalUUID(uid),
+		Title:   icalText(props["SUMMARY"].value),
+		Start:   timeToDateTime(start),
+		End:     timeToDateTime(end),
+		Address: icalText(props["LOCATION"].value),
+		Info:    icalText(props["DESCRIPTION"].value),
+		Source:  "ICAL",
+	}, nil
+}
+
+func icalTime(prop icalProperty, loc *time.Location) (time.Time, error) {
+	/* Parse DATE or DATE-TIME value, UTC and TZID ones are converted to loc */
+	var (
+		t   time.Time
+		err error
+	)
+
+	switch value := prop.value; {
+	case len(value) == len("20060102"):
+		t, err = time.ParseInLocation("20060102", value, loc)
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+	case prop.params["TZID"] != "":
+		var tz *time.Location
+
+		if tz, err = time.LoadLocation(prop.params["TZID"]); err == nil {
+			t, err = time.ParseInLocation("20060102T150405", value, tz)
+		}
+	default:
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+	}
+
+	if err != nil {
+		return t, fmt.Errorf("%w: %s %q: %v", ErrFeedMalformed, prop.name, prop.value, err)
+	}
+
+	return t.In(loc), nil
+}
+
+func icalText(value string) string {
+	/* Unescape TEXT value */
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+func icalUUID(uid string) string {
+	/* Use UID when it already is a UUID, otherwise derive a stable one,
+	 * so importing the feed again updates the same events.
+	 */
+	if normalized := strings.ToLower(strings.ReplaceAll(uid, "-", "")); uuidPattern.MatchString(normalized) {
+		return normalized
+	}
+
+	sum := sha256.Sum256([]byte(uid))
+
+	return hex.EncodeToString(sum[:16])
+}
+
+func (srv *HTTPRestServer) fetchFeed(ctx context.Context, rawURL string) ([]byte, error) {
+	/* Download feed over http(s), at most cfg.MaxImportBytes bytes within ImportURLTimeout.
+	 * Every connection, redirects included, is checked by feedDialControl, so host names
+	 * resolving to internal addresses can not be used to reach internal services.
+	 */
+	feedURL, err := url.Parse(rawURL)
+	if err != nil || (feedURL.Scheme != "http" && feedURL.Scheme != "https") || feedURL.Host == "" {
+		return nil, fmt.Errorf("%w: only absolute http and https URLs are accepted", ErrFeedURLNotAllowed)
+	}
+
+	dialer := &net.Dialer{Timeout: ImportURLTimeout, Control: srv.feedDialControl}
+	client := &http.Client{
+		Timeout:   ImportURLTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s", ErrFeedURLNotAllowed, req.URL.Scheme)
+			}
+
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+
+			return nil
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL.String(), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFeedURLNotAllowed, err)
+	}
+
+	resp, err := client.Do(req)
+	if errors.Is(err, ErrFeedURLNotAllowed) {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFeedFetch, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: server responded %s", ErrFeedFetch, resp.Status)
+	}
+
+	data, err := ReadImport(resp.Body, srv.cfg.MaxImportBytes)
+	if err != nil && !errors.Is(err, ErrImportTooLarge) {
+		return nil, fmt.Errorf("%w: %v", ErrFeedFetch, err)
+	}
+
+	return data, err
+}
+
+// ReadImport reads whole import source, failing with ErrImportTooLarge as soon as
+// it exceeds limit bytes, so oversized feeds and files are never held in memory.
+func ReadImport(r io.Reader, limit int) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > limit {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrImportTooLarge, limit)
+	}
+
+	return data, nil
+}
+
+func (srv *HTTPRestServer) feedDialControl(_, address string, _ syscall.RawConn) error {
+	/* Refuse connections to loopback, private, link-local and other internal addresses,
+	 * unless they belong to cfg.ImportAllowedNets.
+	 */
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	addr = addr.Unmap()
+
+	for _, prefix := range srv.cfg.ImportAllowedNets {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() || cgnatPrefix.Contains(addr) {
+		return fmt.Errorf("%w: %s is an internal address", ErrFeedURLNotAllowed, addr)
+	}
+
+	return nil
+}
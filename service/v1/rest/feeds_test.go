@@ -0,0 +1,148 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"context"
+	"errors"
+	"eventshub/config"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseICalFeed(t *testing.T) {
+	/* GIVEN an iCalendar feed with UTC, zoned, floating and all-day events
+	 * AND folded and escaped lines
+	 * WHEN it is parsed for Europe/Warsaw
+	 * THEN times should be converted to the server time zone
+	 * AND text should be unfolded and unescaped
+	 * AND UIDs should map to stable UUIDs
+	 */
+	feed := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:0B2DD0F4-3614-1389-95BE-AFA87B6356B0\r\n" +
+		"DTSTART:20240301T090000Z\r\n" +
+		"DTEND:20240301T100000Z\r\n" +
+		"SUMMARY:Dentist\\, checkup\r\n" +
+		"DESCRIPTION:Bring the\r\n" +
+		"  card\\nand ID\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:meeting@example.com\r\n" +
+		"DTSTART;TZID=\"America/New_York\":20240701T080000\r\n" +
+		"LOCATION:Room 1\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:holiday@example.com\r\n" +
+		"DTSTART;VALUE=DATE:20241224\r\n" +
+		"DTEND;VALUE=DATE:20241225\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := parseICalFeed([]byte(feed), "Europe/Warsaw")
+
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	assert.Equal(t, "0b2dd0f43614138995beafa87b6356b0", events[0].UUID)
+	assert.Equal(t, "Dentist, checkup", events[0].Title)
+	assert.Equal(t, "Bring the card\nand ID", events[0].Info)
+	assert.Equal(t, "ICAL", events[0].Source)
+	assert.Equal(t, DateTime{Common: Common{Type: DateTimeStructName}, Year: 2024, Month: 3, Day: 1, Hour: 10}, events[0].Start)
+	assert.Equal(t, int32(11), events[0].End.Hour)
+
+	assert.Equal(t, icalUUID("meeting@example.com"), events[1].UUID)
+	assert.Len(t, events[1].UUID, 32)
+	assert.Equal(t, "Room 1", events[1].Address)
+	assert.Equal(t, int32(14), events[1].Start.Hour)
+	assert.Equal(t, events[1].Start, events[1].End)
+
+	assert.Equal(t, int32(24), events[2].Start.Day)
+	assert.Equal(t, int32(0), events[2].Start.Hour)
+	assert.Equal(t, int32(25), events[2].End.Day)
+
+	_, err = parseICalFeed([]byte("BEGIN:VEVENT\r\nUID:x\r\nDTSTART:tomorrow\r\nEND:VEVENT\r\n"), "Europe/Warsaw")
+	assert.True(t, errors.Is(err, ErrFeedMalformed))
+}
+
+func Test_FetchFeedRefusesInternalAddresses(t *testing.T) {
+	/* GIVEN feed URLs with unsupported schemes or internal addresses
+	 * WHEN they are fetched
+	 * THEN they should be refused before any data is read
+	 */
+	srv := &HTTPRestServer{cfg: config.Default()}
+
+	for _, url := range []string{
+		"file:///etc/passwd",
+		"ftp://example.com/feed.xml",
+		"/relative/feed.xml",
+		"http://127.0.0.1:1/feed.xml",
+		"http://[::1]:1/feed.xml",
+		"http://10.1.2.3:1/feed.xml",
+		"http://169.254.169.254/latest/meta-data",
+	} {
+		_, err := srv.fetchFeed(context.Background(), url)
+		assert.True(t, errors.Is(err, ErrFeedURLNotAllowed), url)
+	}
+}
+
+func Test_ParseXMLDateTriesConfiguredLayouts(t *testing.T) {
+	/* GIVEN dates in the default export format, in `DD/MM/YYYY HH:MM` and in an unknown one
+	 * WHEN they are parsed with the alternative layout configured
+	 * THEN the first two should be converted to the same DateTime
+	 * AND the unknown one should fail with ErrFeedMalformed instead of yielding a zero date
+	 */
+	expected := DateTime{Common{Type: DateTimeStructName}, 2024, 2, 13, 12, 5}
+	layouts := []string{"02/01/2006 15:04"}
+
+	date, err := ParseXMLDate("2024-02-13 12:05", layouts)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, date)
+
+	date, err = ParseXMLDate("13/02/2024 12:05", layouts)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, date)
+
+	_, err = ParseXMLDate("13/02/2024 12:05", nil)
+	assert.ErrorIs(t, err, ErrFeedMalformed)
+
+	_, err = ParseXMLDate("Feb 13", layouts)
+	assert.ErrorIs(t, err, ErrFeedMalformed)
+}
+
+func Test_FetchFeedRefusesOversizedFeed(t *testing.T) {
+	/* GIVEN a feed server on an allowed network and maximum import size of 64 bytes
+	 * WHEN feeds of the maximum size and one byte more are fetched
+	 * THEN the first should be read whole
+	 * AND the second should be refused with ErrImportTooLarge
+	 */
+	size := 64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", size)))
+	}))
+	defer server.Close()
+
+	cfg := config.Default()
+	cfg.MaxImportBytes = 64
+	cfg.ImportAllowedNets = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+
+	srv := &HTTPRestServer{cfg: cfg}
+
+	data, err := srv.fetchFeed(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Len(t, data, 64)
+
+	size = 65
+
+	_, err = srv.fetchFeed(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrImportTooLarge)
+}
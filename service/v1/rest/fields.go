@@ -0,0 +1,93 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EncryptedFieldPrefix marks column values encrypted with the field encryption key,
+// so values stored before the key was configured are still read as plain text.
+// Plain values starting with it are refused, they would be read back as ciphertext.
+const EncryptedFieldPrefix = "enc:v1:"
+
+// ErrFieldDecryption is returned when stored value can not be decrypted, e.g. with other key.
+var ErrFieldDecryption = errors.New("failed to decrypt field")
+
+func fieldCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func encryptField(key []byte, plain, uuid string) (string, error) {
+	/* Encrypt value with AES-GCM under random nonce stored in front of the ciphertext.
+	 * UUID of the event is authenticated as additional data, so ciphertext copied to
+	 * another row fails to decrypt. Without key, and for empty values which carry nothing
+	 * to hide, value is kept as is, unless it could be mistaken for ciphertext.
+	 */
+	if len(key) == 0 || plain == "" {
+		if strings.HasPrefix(plain, EncryptedFieldPrefix) {
+			return "", fmt.Errorf("%w: value must not start with %q", ErrInvalidEvent, EncryptedFieldPrefix)
+		}
+
+		return plain, nil
+	}
+
+	aead, err := fieldCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plain), []byte(uuid))
+
+	return EncryptedFieldPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptField(key []byte, value, uuid string) (string, error) {
+	/* Decrypt value written by encryptField for event with the UUID, values without the prefix are plain text */
+	if !strings.HasPrefix(value, EncryptedFieldPrefix) {
+		return value, nil
+	}
+
+	if len(key) == 0 {
+		return "", fmt.Errorf("%w: no key configured", ErrFieldDecryption)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedFieldPrefix))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrFieldDecryption, err)
+	}
+
+	aead, err := fieldCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("%w: value too short", ErrFieldDecryption)
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(uuid))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrFieldDecryption, err)
+	}
+
+	return string(plain), nil
+}
@@ -0,0 +1,77 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EncryptFieldRoundTrip(t *testing.T) {
+	/* GIVEN a value encrypted with and without a key
+	 * WHEN it is decrypted
+	 * THEN the original value should be returned
+	 * AND with the key, the stored value should be prefixed ciphertext differing every time
+	 * AND decryption without the key, with other key or for other event should fail
+	 */
+	key := []byte("0123456789abcdef0123456789abcdef")
+	uuid := TestEvent1.UUID
+
+	plain, err := encryptField(nil, "Likes beer", uuid)
+	assert.NoError(t, err)
+	assert.Equal(t, "Likes beer", plain)
+
+	decrypted, err := decryptField(key, plain, uuid)
+	assert.NoError(t, err)
+	assert.Equal(t, "Likes beer", decrypted)
+
+	first, err := encryptField(key, "Likes beer", uuid)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(first, EncryptedFieldPrefix))
+	assert.NotContains(t, first, "beer")
+
+	second, err := encryptField(key, "Likes beer", uuid)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	decrypted, err = decryptField(key, first, uuid)
+	assert.NoError(t, err)
+	assert.Equal(t, "Likes beer", decrypted)
+
+	empty, err := encryptField(key, "", uuid)
+	assert.NoError(t, err)
+	assert.Equal(t, "", empty)
+
+	_, err = decryptField(nil, first, uuid)
+	assert.ErrorIs(t, err, ErrFieldDecryption)
+
+	_, err = decryptField([]byte("fedcba9876543210fedcba9876543210"), first, uuid)
+	assert.ErrorIs(t, err, ErrFieldDecryption)
+
+	_, err = decryptField(key, first, TestEvent2.UUID)
+	assert.ErrorIs(t, err, ErrFieldDecryption)
+}
+
+func Test_PlainFieldMustNotLookEncrypted(t *testing.T) {
+	/* GIVEN a value starting with EncryptedFieldPrefix
+	 * WHEN it is stored without a key
+	 * THEN it should be refused as invalid event instead of being read back as ciphertext
+	 * AND with a key it should be encrypted and decrypted as any other value
+	 */
+	key := []byte("0123456789abcdef0123456789abcdef")
+	value := EncryptedFieldPrefix + "not really"
+
+	_, err := encryptField(nil, value, TestEvent1.UUID)
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+
+	sealed, err := encryptField(key, value, TestEvent1.UUID)
+	assert.NoError(t, err)
+
+	decrypted, err := decryptField(key, sealed, TestEvent1.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, value, decrypted)
+}
@@ -0,0 +1,453 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"runtime/debug"
+
+	v1grpc "eventshub/service/v1/grpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCTokenMetadata is the metadata key gRPC clients pass their token in,
+// the counterpart of the Token header of HTTP requests.
+const GRPCTokenMetadata = "token"
+
+// grpcServer serves the EventsHub gRPC service on top of the same repository,
+// lockout and configuration as the HTTP handlers.
+type grpcServer struct {
+	v1grpc.UnimplementedEventsHubServer
+	srv *HTTPRestServer
+}
+
+func (srv *HTTPRestServer) newGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	/* Create gRPC server with every method but Login guarded by the token interceptor.
+	 * Interceptors mirror the HTTP middleware chain, first one is the outermost.
+	 */
+	opts = append(opts, grpc.ChainUnaryInterceptor(
+		srv.grpcRecoveryInterceptor,
+		srv.grpcReadinessInterceptor,
+		srv.grpcAuthInterceptor,
+		srv.grpcTimeoutInterceptor,
+		srv.grpcQueryBudgetInterceptor,
+	))
+
+	server := grpc.NewServer(opts...)
+	v1grpc.RegisterEventsHubServer(server, &grpcServer{srv: srv})
+
+	return server
+}
+
+func (srv *HTTPRestServer) serveGRPC() error {
+	/* Listen on the gRPC port and serve it as a goroutine until Stop() */
+	listener, err := net.Listen("tcp", srv.cfg.Host+":"+srv.cfg.GRPCPort)
+	if err != nil {
+		return err
+	}
+
+	srv.log.Info("Serving gRPC on ", listener.Addr())
+
+	go func() {
+		if err := srv.grpc.Serve(listener); err != nil {
+			srv.log.Error("gRPC server error while listening. ", err)
+		}
+
+		srv.log.Warning("Stopped serving new gRPC connections")
+	}()
+
+	return nil
+}
+
+func (srv *HTTPRestServer) grpcAuthInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	/* Validate token from metadata and pass its user to the handler, Login is the only open method */
+	if info.FullMethod == v1grpc.EventsHub_Login_FullMethodName {
+		return handler(ctx, req)
+	}
+
+	var raw string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(GRPCTokenMetadata)) > 0 {
+		raw = md.Get(GRPCTokenMetadata)[0]
+	}
+
+	if raw == "" {
+		return nil, status.Error(codes.Unauthenticated, ErrTokenMissing.Error())
+	}
+
+	claims, err := parseToken(srv.cfg, srv.clock, raw)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	user, err := claimsUsername(claims)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return handler(context.WithValue(ctx, grpcUserKey, user), req)
+}
+
+func (srv *HTTPRestServer) grpcRecoveryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (resp any, err error) {
+	/* Answer panicking method with Internal instead of crashing the process, see recoveryMiddleware */
+	if !srv.cfg.RecoverPanics {
+		return handler(ctx, req)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			srv.log.Critical(fmt.Sprintf("gRPC call %s panicked: %v\n%s", info.FullMethod, recovered, debug.Stack()))
+			resp, err = nil, status.Error(codes.Internal, "Internal server error.")
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+func (srv *HTTPRestServer) grpcReadinessInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	/* Refuse calls with Unavailable until Configure has succeeded, see readinessMiddleware */
+	if !srv.ready.Load() {
+		return nil, status.Error(codes.Unavailable, "Server is starting, try again later.")
+	}
+
+	return handler(ctx, req)
+}
+
+func (srv *HTTPRestServer) grpcTimeoutInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	/* Answer calls not processed within cfg.HandlerTimeout with DeadlineExceeded, see timeoutMiddleware.
+	 * Method keeps running in its goroutine with cancelled context, its panic is passed on to the caller.
+	 */
+	if srv.cfg.HandlerTimeout <= 0 {
+		return handler(ctx, req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, srv.cfg.HandlerTimeout)
+	defer cancel()
+
+	type result struct {
+		resp      any
+		err       error
+		recovered any
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		var res result
+
+		defer func() {
+			res.recovered = recover()
+			done <- res
+		}()
+
+		res.resp, res.err = handler(ctx, req)
+	}()
+
+	select {
+	case res := <-done:
+		if res.recovered != nil {
+			panic(res.recovered)
+		}
+
+		return res.resp, res.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, status.Errorf(codes.DeadlineExceeded, "Request not processed within %s.", srv.cfg.HandlerTimeout)
+		}
+
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (srv *HTTPRestServer) grpcQueryBudgetInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	/* Give every call a budget of cfg.QueryBudget database statements, charged by srv.contextRepo.
+	 * Call whose statement was refused fails with Internal, see queryBudgetMiddleware.
+	 */
+	if srv.cfg.QueryBudget <= 0 {
+		return handler(ctx, req)
+	}
+
+	budget := &queryBudget{limit: int64(srv.cfg.QueryBudget)}
+
+	resp, err := handler(context.WithValue(ctx, budgetKey, budget), req)
+	if !budget.exceeded() {
+		return resp, err
+	}
+
+	srv.log.Error(fmt.Sprintf("gRPC call %s ran %d database statements, query budget is %d.",
+		info.FullMethod, budget.used.Load(), srv.cfg.QueryBudget))
+
+	if budget.refused.Load() {
+		return nil, status.Errorf(codes.Internal, "Request exceeded query budget of %d database statements.", srv.cfg.QueryBudget)
+	}
+
+	return resp, err
+}
+
+func grpcUser(ctx context.Context) string {
+	/* Return user whose token was validated by grpcAuthInterceptor */
+	user, _ := ctx.Value(grpcUserKey).(string)
+	return user
+}
+
+func (srv *HTTPRestServer) grpcRecordAudit(ctx context.Context, action, uuid string) {
+	/* Store mutating call in the audit log under the token user, same as recordAudit */
+	if err := srv.contextRepo(ctx).RecordAudit(grpcUser(ctx), action, uuid); err != nil {
+		srv.log.Error("Failed to record audit entry. ", err)
+	}
+}
+
+func grpcClientIP(ctx context.Context) string {
+	/* Return address of the gRPC peer without port, it keys the lockout of addresses */
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+func (g *grpcServer) Login(ctx context.Context, req *v1grpc.User) (*v1grpc.TokenMsg, error) {
+	/* Issue token for valid credentials, failures count towards the same lockout as HTTP logins */
+	srv := g.srv
+	ip := grpcClientIP(ctx)
+
+	if remaining := srv.lockout.addresses.locked(ip, srv.clock.Now()); remaining > 0 {
+		srv.log.Warning("Login from locked address ", ip)
+		return nil, status.Errorf(codes.ResourceExhausted, "Too many failed logins from this address, retry after %d seconds.",
+			int(math.Ceil(remaining.Seconds())))
+	}
+
+	if remaining := srv.lockout.accounts.locked(accountKey(req.GetUsername(), ip), srv.clock.Now()); remaining > 0 {
+		srv.log.Warning("Login of locked account ", req.GetUsername(), " from ", ip)
+		return nil, status.Errorf(codes.ResourceExhausted, "Account is temporarily locked for this address, retry after %d seconds.",
+			int(math.Ceil(remaining.Seconds())))
+	}
+
+	authenticated, err := srv.contextRepo(ctx).AuthenticateUser(req.GetUsername(), req.GetPassword())
+	if err != nil {
+		srv.log.Error(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if !authenticated {
+		if srv.lockout.addresses.fail(ip, srv.clock.Now(), srv.cfg.LoginMaxIPFailures, srv.cfg.LoginLockout) {
+			srv.log.Warning("Locking address ", ip, " after too many failed logins.")
+		}
+
+		if srv.lockout.accounts.fail(accountKey(req.GetUsername(), ip), srv.clock.Now(), srv.cfg.LoginMaxFailures, srv.cfg.LoginLockout) {
+			srv.log.Warning("Locking account ", req.GetUsername(), " for ", ip, " after too many failed logins.")
+		}
+
+		srv.log.Info("Not enough mana!")
+
+		return nil, status.Error(codes.Unauthenticated, "Not enough mana!")
+	}
+
+	/* Failures of the address are kept, own account must not launder guesses at others */
+	srv.lockout.accounts.reset(accountKey(req.GetUsername(), ip))
+
+	token, err := createJWT(srv.cfg, srv.clock, req.GetUsername())
+	if err != nil {
+		srv.log.Error(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &v1grpc.TokenMsg{Token: token}, nil
+}
+
+func (g *grpcServer) InsertEvent(ctx context.Context, req *v1grpc.AddEventReq) (*v1grpc.AddEventResp, error) {
+	/* Insert or update event on behalf of the token user, same as insertEvent handler in upsert mode */
+	srv := g.srv
+
+	if req.GetEvent() == nil {
+		return nil, status.Error(codes.InvalidArgument, "failed to obtain event")
+	}
+
+	event := eventFromGRPC(req.GetEvent())
+	event.Normalize()
+	event.Owner = grpcUser(ctx)
+
+	/* Source of a new event is checked by repository once it is known not to be stored */
+	if err := event.Validate(srv.cfg); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := srv.contextRepo(ctx).InsertEvent(event)
+	if errors.Is(err, ErrInvalidEvent) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if errors.Is(err, ErrDuplicateEvent) {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	} else if errors.Is(err, ErrQuotaExceeded) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	} else if err != nil {
+		srv.log.Error(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if result.UUID == event.UUID {
+		srv.grpcRecordAudit(ctx, AuditActionInsert, result.UUID)
+	}
+
+	return &v1grpc.AddEventResp{Status: &v1grpc.ResponseStatus{Success: true}}, nil
+}
+
+func (g *grpcServer) GetEventsWithinTimeRange(ctx context.Context, req *v1grpc.GetEventsReq) (*v1grpc.GetEventsResp, error) {
+	/* Return events within time range, bounded the same way as getEventsWithinTimeRange handler */
+	srv := g.srv
+
+	if req.GetStart() == nil || req.GetEnd() == nil {
+		return nil, status.Error(codes.InvalidArgument, "failed to obtain start and end")
+	}
+
+	start := dateTimeFromGRPC(req.GetStart())
+	end := dateTimeFromGRPC(req.GetEnd())
+
+	startUnix, err := dateTimeToUnix(&start, srv.cfg.TimeZone)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Start data error.")
+	}
+
+	endUnix, err := dateTimeToUnix(&end, srv.cfg.TimeZone)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "End data error.")
+	}
+
+	if startUnix > endUnix {
+		return nil, status.Error(codes.InvalidArgument, InvertedTimeRangeMsg)
+	}
+
+	if rangeExceedsDays(startUnix, endUnix, srv.cfg.MaxRangeDays) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf(RangeTooLargeMsg, srv.cfg.MaxRangeDays))
+	}
+
+	events, err := srv.contextRepo(ctx).GetEventsByTimeRange(startUnix, endUnix)
+	if err != nil {
+		srv.log.Error(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &v1grpc.GetEventsResp{
+		Events: make([]*v1grpc.EventData, 0, len(events)),
+		Status: &v1grpc.ResponseStatus{Success: true},
+	}
+
+	for i := range events {
+		resp.Events = append(resp.Events, eventToGRPC(&events[i]))
+	}
+
+	return resp, nil
+}
+
+func (g *grpcServer) GetStatus(ctx context.Context, req *v1grpc.GetStatusReq) (*v1grpc.GetStatusResp, error) {
+	/* Return present server status, with number of events changed since req.Since when it is set */
+	srv := g.srv
+
+	result, err := srv.contextRepo(ctx).GetStatus()
+	if err != nil {
+		srv.log.Error(err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &v1grpc.GetStatusResp{
+		Timestamp: result.Timestamp,
+		Status:    &v1grpc.ResponseStatus{Success: true},
+		Version:   result.Version,
+		Instance:  srv.cfg.InstanceName,
+	}
+
+	if req.Since != nil {
+		changes, err := srv.contextRepo(ctx).CountEventsChangedSince(req.GetSince())
+		if err != nil {
+			srv.log.Error(err)
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		resp.Changes = &changes
+	}
+
+	return resp, nil
+}
+
+func dateTimeFromGRPC(d *v1grpc.DateTime) DateTime {
+	return DateTime{
+		Common: Common{Type: DateTimeStructName},
+		Year:   d.GetYear(),
+		Month:  d.GetMonth(),
+		Day:    d.GetDay(),
+		Hour:   d.GetHour(),
+		Minute: d.GetMinute(),
+	}
+}
+
+func dateTimeToGRPC(d DateTime) *v1grpc.DateTime {
+	return &v1grpc.DateTime{Year: d.Year, Month: d.Month, Day: d.Day, Hour: d.Hour, Minute: d.Minute}
+}
+
+func eventFromGRPC(e *v1grpc.EventData) *EventData {
+	/* Owner is never taken from the client, caller sets it to the token user */
+	return &EventData{
+		Common:    Common{Type: EventDataStructName},
+		ID:        e.GetId(),
+		Version:   e.GetVersion(),
+		UUID:      e.GetUuid(),
+		Title:     e.GetTitle(),
+		Start:     dateTimeFromGRPC(e.GetStart()),
+		End:       dateTimeFromGRPC(e.GetEnd()),
+		Address:   e.GetAddress(),
+		Info:      e.GetInfo(),
+		Reminder:  e.GetReminder(),
+		Done:      e.GetDone(),
+		Important: e.GetImportant(),
+		Urgent:    e.GetUrgent(),
+		Source:    e.GetSource(),
+		Category:  e.GetCategory(),
+		Color:     e.GetColor(),
+	}
+}
+
+func eventToGRPC(e *EventData) *v1grpc.EventData {
+	return &v1grpc.EventData{
+		Id:        e.ID,
+		Version:   e.Version,
+		Uuid:      e.UUID,
+		Title:     e.Title,
+		Start:     dateTimeToGRPC(e.Start),
+		End:       dateTimeToGRPC(e.End),
+		Address:   e.Address,
+		Info:      e.Info,
+		Reminder:  e.Reminder,
+		Done:      e.Done,
+		Important: e.Important,
+		Urgent:    e.Urgent,
+		Source:    e.Source,
+		Category:  e.Category,
+		Color:     e.Color,
+	}
+}
@@ -0,0 +1,210 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	v1grpc "eventshub/service/v1/grpc"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func dialGRPC(t *testing.T, srv *HTTPRestServer) v1grpc.EventsHubClient {
+	/* Serve gRPC of srv on a free local port and return client connected to it */
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.ready.Store(true)
+	srv.grpc = srv.newGRPCServer()
+
+	go func() { _ = srv.grpc.Serve(listener) }()
+
+	t.Cleanup(srv.grpc.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	return v1grpc.NewEventsHubClient(conn)
+}
+
+func Test_GRPCGetStatusRequiresToken(t *testing.T) {
+	/* GIVEN gRPC server backed by an in-memory repository
+	 * WHEN GetStatus is called without token, with a malformed one and with a valid one
+	 * THEN the first two calls should fail with Unauthenticated
+	 * AND the last one should return status with version and instance name
+	 */
+	srv, token := newTestServer(t)
+	srv.cfg.InstanceName = "eventshub-grpc"
+	client := dialGRPC(t, srv)
+
+	_, err := client.GetStatus(context.Background(), &v1grpc.GetStatusReq{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), GRPCTokenMetadata, "not-a-token")
+	_, err = client.GetStatus(ctx, &v1grpc.GetStatusReq{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), GRPCTokenMetadata, token)
+	since := int64(0)
+
+	resp, err := client.GetStatus(ctx, &v1grpc.GetStatusReq{Since: &since})
+	assert.NoError(t, err)
+	assert.True(t, resp.GetStatus().GetSuccess())
+	assert.NotEmpty(t, resp.GetVersion())
+	assert.Equal(t, "eventshub-grpc", resp.GetInstance())
+	assert.NotNil(t, resp.Changes)
+}
+
+func Test_GRPCLoginSharesLockout(t *testing.T) {
+	/* GIVEN gRPC server with a stored user and lockout after two failures
+	 * WHEN Login is called with a valid password, then twice with a wrong one and again with the valid one
+	 * THEN the first call should return a token accepted by other methods
+	 * AND wrong passwords should fail with Unauthenticated
+	 * AND the account should then be locked with ResourceExhausted
+	 */
+	srv, _ := newTestServer(t)
+	srv.cfg.LoginMaxFailures = 2
+	assert.NoError(t, srv.db.AddUser("alice", "S3cret!pass", false))
+
+	client := dialGRPC(t, srv)
+
+	tokenMsg, err := client.Login(context.Background(), &v1grpc.User{Username: "alice", Password: "S3cret!pass"})
+	assert.NoError(t, err)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), GRPCTokenMetadata, tokenMsg.GetToken())
+	_, err = client.GetStatus(ctx, &v1grpc.GetStatusReq{})
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = client.Login(context.Background(), &v1grpc.User{Username: "alice", Password: "wrong"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	_, err = client.Login(context.Background(), &v1grpc.User{Username: "alice", Password: "S3cret!pass"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func Test_GRPCInsertedEventIsReturnedWithinTimeRange(t *testing.T) {
+	/* GIVEN gRPC server and a valid token
+	 * WHEN an event is inserted and events of its day are requested over gRPC
+	 * THEN the event should be returned and owned by the token user
+	 * AND an inverted range or an invalid event should fail with InvalidArgument
+	 */
+	srv, token := newTestServer(t)
+	client := dialGRPC(t, srv)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), GRPCTokenMetadata, token)
+
+	event := eventToGRPC(&TestEvent2)
+
+	_, err := client.InsertEvent(ctx, &v1grpc.AddEventReq{Event: event})
+	assert.NoError(t, err)
+
+	dayStart := &v1grpc.DateTime{Year: 2024, Month: 2, Day: 13}
+	dayEnd := &v1grpc.DateTime{Year: 2024, Month: 2, Day: 13, Hour: 23, Minute: 59}
+
+	resp, err := client.GetEventsWithinTimeRange(ctx, &v1grpc.GetEventsReq{Start: dayStart, End: dayEnd})
+	assert.NoError(t, err)
+
+	if assert.Len(t, resp.GetEvents(), 1) {
+		assert.Equal(t, TestEvent2.UUID, resp.GetEvents()[0].GetUuid())
+		assert.Equal(t, TestEvent2.Title, resp.GetEvents()[0].GetTitle())
+	}
+
+	var owner string
+
+	assert.NoError(t, srv.db.(*SQLiteRepository).handle().QueryRow(
+		"SELECT owner FROM events WHERE uuid = ?", TestEvent2.UUID).Scan(&owner))
+	assert.Equal(t, "admin", owner)
+
+	_, err = client.GetEventsWithinTimeRange(ctx, &v1grpc.GetEventsReq{Start: dayEnd, End: dayStart})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	event.Color = "red"
+	_, err = client.InsertEvent(ctx, &v1grpc.AddEventReq{Event: event})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_GRPCAppliesServerLimits(t *testing.T) {
+	/* GIVEN gRPC server with query budget of 1
+	 * WHEN GetStatus counting changes runs more statements than that
+	 * THEN it should fail with Internal
+	 * AND InsertEvent, which writes first, should succeed and be audited under the token user
+	 * AND once the server is not ready calls should fail with Unavailable
+	 */
+	srv, token := newTestServer(t)
+	srv.cfg.QueryBudget = 1
+	client := dialGRPC(t, srv)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), GRPCTokenMetadata, token)
+
+	since := int64(0)
+
+	_, err := client.GetStatus(ctx, &v1grpc.GetStatusReq{Since: &since})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "query budget")
+
+	_, err = client.InsertEvent(ctx, &v1grpc.AddEventReq{Event: eventToGRPC(&TestEvent2)})
+	assert.NoError(t, err)
+
+	var user string
+
+	assert.NoError(t, srv.db.(*SQLiteRepository).handle().QueryRow(
+		"SELECT username FROM audit WHERE action = ? AND uuid = ?", AuditActionInsert, TestEvent2.UUID).Scan(&user))
+	assert.Equal(t, "admin", user)
+
+	srv.ready.Store(false)
+
+	_, err = client.GetStatus(ctx, &v1grpc.GetStatusReq{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func Test_GRPCInterceptorsHandleSlowAndPanickingMethods(t *testing.T) {
+	/* GIVEN server with handler timeout of 50ms and recovery of panics
+	 * WHEN a method outlives the timeout and another one panics, also behind the timeout interceptor
+	 * THEN the first call should fail with DeadlineExceeded
+	 * AND the panicking ones with Internal
+	 */
+	srv, _ := newTestServer(t)
+	srv.cfg.HandlerTimeout = 50 * time.Millisecond
+	srv.cfg.RecoverPanics = true
+
+	info := &grpc.UnaryServerInfo{FullMethod: v1grpc.EventsHub_GetStatus_FullMethodName}
+
+	slow := func(ctx context.Context, _ any) (any, error) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+
+		return "too late", nil
+	}
+
+	_, err := srv.grpcTimeoutInterceptor(context.Background(), nil, info, slow)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	panicking := func(context.Context, any) (any, error) { panic("boom") }
+
+	_, err = srv.grpcRecoveryInterceptor(context.Background(), nil, info, panicking)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	_, err = srv.grpcRecoveryInterceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return srv.grpcTimeoutInterceptor(ctx, req, info, panicking)
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
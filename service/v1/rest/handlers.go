@@ -5,62 +5,275 @@ package v1rest
 // Created: August 18, 2024
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"eventshub/config"
 	"fmt"
 	"io"
+	"math"
+	"mime"
 	"net/http"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
-// Send a JSON response to the client. It takes a response object and marshals it to JSON.
-// If the marshaling fails, it logs the error and returns.
+// Send a JSON response to the client with 200 OK status code.
+func (srv *HTTPRestServer) send(resp any, w http.ResponseWriter, r *http.Request) {
+	srv.sendWithStatus(resp, http.StatusOK, w, r)
+}
+
+// Send a JSON response to the client with provided status code. It takes a response object and marshals it to JSON.
+// Content-Type header is set before the status code is written, as headers set afterwards are not sent.
+// If the marshaling fails, it logs the error and responds with 500 Internal Server Error.
 // If the write to the client fails, it logs the error.
-func (srv *HTTPRestServer) send(resp any, w http.ResponseWriter, _ *http.Request) {
+func (srv *HTTPRestServer) sendWithStatus(resp any, code int, w http.ResponseWriter, r *http.Request) {
 	var (
 		byteResp []byte
 		err      error
 	)
 
-	byteResp, err = json.Marshal(resp)
+	w.Header().Set("Content-Type", JSONContentType)
+
+	byteResp, err = srv.marshal(resp)
 	if err != nil {
-		srv.log.Error("Marshaling data failed:", err)
+		srv.logger(r).Error("Marshaling data failed:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
 		return
 	}
 
+	w.WriteHeader(code)
+
 	_, err = w.Write(byteResp)
 	if err != nil {
-		srv.log.Error("Writing data failed:", err)
+		srv.logger(r).Error("Writing data failed:", err)
 	}
 }
 
-// invalidTokenResponse sends a JSON response to the client with a 401 Unauthorized status code.
-// The response body contains a JSON object with a single "status" field that describes the error.
-func (srv *HTTPRestServer) invalidTokenResponse(w http.ResponseWriter, r *http.Request, reason error) {
-	var (
-		resp InvalidTokenResp
-	)
+// marshal encodes response as JSON with the API version set. Legacy clients, predating
+// the `__type__` and `__version__` discriminators, receive responses without them.
+// DateTime values are sent as ISO 8601 strings when configured so.
+func (srv *HTTPRestServer) marshal(resp any) ([]byte, error) {
+	isoDateTimes := srv.cfg.DateTimeFormat == config.DateTimeFormatISO8601
+
+	data, err := json.Marshal(withVersion(resp))
+	if err != nil || (srv.cfg.Compat != config.CompatLegacy && !isoDateTimes) {
+		return data, err
+	}
+
+	var value any
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err = decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	/* Date times are recognized by their discriminator, so they are converted first */
+	if isoDateTimes {
+		value = withISODateTimes(value)
+	}
+
+	if srv.cfg.Compat == config.CompatLegacy {
+		value = withoutDiscriminators(value)
+	}
+
+	return json.Marshal(value)
+}
+
+func withISODateTimes(value any) any {
+	/* Replace DateTime objects of decoded JSON at every level with their ISO 8601 form */
+	switch v := value.(type) {
+	case map[string]any:
+		if v["__type__"] == DateTimeStructName {
+			var d DateTime
+
+			fields := map[string]*int32{"year": &d.Year, "month": &d.Month, "day": &d.Day, "hour": &d.Hour, "minute": &d.Minute}
+			for key, field := range fields {
+				if n, ok := v[key].(json.Number); ok {
+					i, _ := n.Int64()
+					*field = int32(i)
+				}
+			}
+
+			return d.ISO8601()
+		}
+
+		for key, item := range v {
+			v[key] = withISODateTimes(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = withISODateTimes(item)
+		}
+	}
+
+	return value
+}
+
+func withoutDiscriminators(value any) any {
+	/* Drop discriminator keys of decoded JSON at every level */
+	switch v := value.(type) {
+	case map[string]any:
+		delete(v, "__type__")
+		delete(v, "__version__")
 
-	w.WriteHeader(http.StatusUnauthorized)
-	w.Header().Set("Content-Type", "application/json")
+		for key, item := range v {
+			v[key] = withoutDiscriminators(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = withoutDiscriminators(item)
+		}
+	}
+
+	return value
+}
+
+// withVersion returns resp with the API version set on its embedded Common. Responses
+// are usually passed by value, so such a response is copied to an addressable one first.
+// Messages without Common are returned unchanged.
+func withVersion(resp any) any {
+	if v, ok := resp.(versioned); ok {
+		v.setVersion(Version)
+		return resp
+	}
+
+	value := reflect.ValueOf(resp)
+	if !value.IsValid() {
+		return resp
+	}
+
+	ptr := reflect.New(value.Type())
+	ptr.Elem().Set(value)
+
+	if v, ok := ptr.Interface().(versioned); ok {
+		v.setVersion(Version)
+		return ptr.Interface()
+	}
+
+	return resp
+}
+
+// decodeBody decodes JSON request body into v. Empty body results in ErrEmptyBody
+// and malformed one in a descriptive error, so handlers never operate on zero values.
+func decodeBody(r *http.Request, v any) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return ErrEmptyBody
+	}
 
-	resp = InvalidTokenResp{
+	if err != nil {
+		return fmt.Errorf("malformed request body: %w", err)
+	}
+
+	return nil
+}
+
+// invalidTokenResponse sends a JSON response to the client with a 401 Unauthorized status code.
+// The response body contains a machine-readable "code" telling whether the token
+// expired, is invalid or is missing, and a "status" field that describes the error.
+func (srv *HTTPRestServer) invalidTokenResponse(w http.ResponseWriter, r *http.Request, reason error) {
+	resp := InvalidTokenResp{
 		Common: Common{
 			Type: InvalidTokenRespName,
 		},
+		Code: tokenErrorCode(reason),
 		Status: ResponseStatus{
 			Success: false,
 			Message: fmt.Sprintf("%s", reason),
 		},
 	}
 
-	srv.send(resp, w, r)
+	srv.sendWithStatus(resp, http.StatusUnauthorized, w, r)
+}
+
+// tokenErrorCode maps token validation error to the machine readable code sent to clients.
+func tokenErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrTokenExpired):
+		return TokenExpiredCode
+	case errors.Is(err, ErrTokenMissing):
+		return TokenMissingCode
+	default:
+		return TokenInvalidCode
+	}
+}
+
+// requireAdmin wraps handler available to the configured admin only. Requests without
+// valid token are answered with 401, tokens of other users with 403. Every admin-only
+// endpoint is registered through it, so the privilege check lives in one place.
+func (srv *HTTPRestServer) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := tokenUsername(srv.cfg, srv.clock, r)
+		if err != nil {
+			srv.invalidTokenResponse(w, r, err)
+			return
+		}
+
+		if srv.cfg.AdminUsername == "" || user != srv.cfg.AdminUsername {
+			srv.logger(r).Warning("User ", user, " was denied access to ", r.URL.Path)
+			srv.sendWithStatus(ErrorResp{
+				Common:    Common{Type: ErrorRespName},
+				RequestID: requestID(r),
+				Status:    ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: "Available to admin only."},
+			}, http.StatusForbidden, w, r)
+
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// requestUser returns the user the request token was issued for, empty when it
+// can not be obtained. Handlers call it after the token was validated.
+func (srv *HTTPRestServer) requestUser(r *http.Request) string {
+	user, err := tokenUsername(srv.cfg, srv.clock, r)
+	if err != nil {
+		return ""
+	}
+
+	return user
+}
+
+// recordAudit stores mutating operation in the audit log under the token user.
+// Failures are only logged, so they never fail the operation itself.
+func (srv *HTTPRestServer) recordAudit(r *http.Request, action, uuid string) {
+	user, err := tokenUsername(srv.cfg, srv.clock, r)
+	if err != nil {
+		srv.logger(r).Error("Failed to obtain audit user. ", err)
+		return
+	}
+
+	if err = srv.repo(r).RecordAudit(user, action, uuid); err != nil {
+		srv.logger(r).Error("Failed to record audit entry. ", err)
+	}
 }
 
 /*
 loginHandler is an HTTP handler which handles login requests. It checks
 if the provided user credentials are valid and returns a JWT token if
 login is successful. Otherwise, it returns an error message.
+After cfg.LoginMaxFailures consecutive failures of a username, its login is refused
+with 423 Locked for cfg.LoginLockout. After cfg.LoginMaxIPFailures failures of any
+usernames from one address, logins from that address are refused with 429 Too Many
+Requests for the same time, so a single address can not lock out many accounts.
 
 Handler responds to POST requests only.
 
@@ -86,54 +299,75 @@ func (srv *HTTPRestServer) loginHandler(writer http.ResponseWriter, request *htt
 
 	switch request.Method {
 	case "POST":
-		err = json.NewDecoder(request.Body).Decode(&user)
-
+		err = decodeBody(request, &user)
 		if err != nil {
-			srv.log.Warning(err)
-			fmt.Fprintf(writer, "Invalid or corrupted request!")
+			srv.logger(request).Warning(err)
+			srv.sendWithStatus(ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: err.Error()},
+				http.StatusBadRequest, writer, request)
+
+			return
+		}
+
+		ip := srv.clientIP(request)
+
+		if remaining := srv.lockout.addresses.locked(ip, srv.clock.Now()); remaining > 0 {
+			srv.logger(request).Warning("Login from locked address ", ip)
+			writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+			srv.sendWithStatus(ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false,
+				Message: "Too many failed logins from this address."}, http.StatusTooManyRequests, writer, request)
+
+			return
+		}
+
+		if remaining := srv.lockout.accounts.locked(accountKey(user.Username, ip), srv.clock.Now()); remaining > 0 {
+			srv.logger(request).Warning("Login of locked account ", user.Username, " from ", ip)
+			writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+			srv.sendWithStatus(ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false,
+				Message: "Account is temporarily locked after too many failed logins from this address."}, http.StatusLocked, writer, request)
 
 			return
 		}
 
-		authenticated, err = srv.db.AuthenticateUser(user.Username, user.Password)
+		authenticated, err = srv.repo(request).AuthenticateUser(user.Username, user.Password)
+		if !authenticated && err == nil {
+			if srv.lockout.addresses.fail(ip, srv.clock.Now(), srv.cfg.LoginMaxIPFailures, srv.cfg.LoginLockout) {
+				srv.logger(request).Warning("Locking address ", ip, " after too many failed logins.")
+			}
+
+			if srv.lockout.accounts.fail(accountKey(user.Username, ip), srv.clock.Now(), srv.cfg.LoginMaxFailures, srv.cfg.LoginLockout) {
+				srv.logger(request).Warning("Locking account ", user.Username, " for ", ip, " after too many failed logins.")
+			}
+		}
+
 		if !authenticated {
-			srv.log.Info("Not enough mana!")
+			srv.logger(request).Info("Not enough mana!")
 			fmt.Fprintf(writer, "Not enough mana!")
 
 			return
 		} else if err != nil {
-			srv.log.Error(err)
+			srv.logger(request).Error(err)
 			fmt.Fprintf(writer, "%s", err)
 
 			return
 		}
 
-		writer.WriteHeader(http.StatusOK)
+		/* Failures of the address are kept, own account must not launder guesses at others */
+		srv.lockout.accounts.reset(accountKey(user.Username, ip))
 
-		token, err := createJWT(user.Username)
+		token, err := createJWT(srv.cfg, srv.clock, user.Username)
 		if err != nil {
-			srv.log.Error(err)
+			srv.logger(request).Error(err)
 			fmt.Fprintf(writer, "%s", err)
-		}
-
-		data := TokenMsg{Token: token}
 
-		jsonData, err := json.Marshal(data)
-		if err != nil {
-			srv.log.Error("Marshaling data failed:", err)
 			return
 		}
 
-		_, err = writer.Write(jsonData)
-		if err != nil {
-			srv.log.Error("Writing data failed:", err)
-			return
-		}
+		srv.send(TokenMsg{Token: token}, writer, request)
 
 		return
 
 	default:
-		srv.log.Error("Method not implemented!", request.Method)
+		srv.logger(request).Error("Method not implemented!", request.Method)
 		fmt.Fprintf(writer, "%s method not implemented!", request.Method)
 
 		return
@@ -144,16 +378,12 @@ func (srv *HTTPRestServer) loginHandler(writer http.ResponseWriter, request *htt
 /* Returns server version in JSON format. */
 /* If JWT token is invalid, returns 401 with error message. */
 func (srv *HTTPRestServer) serverVersionHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	err := validateJWT(w, r)
+	err := validateJWT(srv.cfg, srv.clock, w, r)
 	if err != nil {
 		srv.invalidTokenResponse(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-
 	resp := VersionResp{
 		Common: Common{
 			Type: VersionRespName,
@@ -169,206 +399,2525 @@ func (srv *HTTPRestServer) serverVersionHandler(w http.ResponseWriter, r *http.R
 }
 
 /*
-Get event check sum
-
-Handler responds to GET requests only.
+readyz handles a request to the /api/v1/readyz endpoint.
+Tells orchestrators whether the node should receive traffic: server finished starting,
+database responds and, for file-backed database, its filesystem has at least
+cfg.MinFreeBytes available, before writes start failing. Otherwise 503 with the reason
+is returned. Token is not required.
 
 Example request:
 
-	GET /api/v1/checksum?uuid=<uuid>
+	GET /api/v1/readyz
 
 Example response:
 
 	{
-		"sum": "0b2dd0f43614138995beafa87b6356b",
+		"__type__": "ReadyResp",
+		"free_bytes": 5368709120,
 		"status": {
-			"type": "ResponseStatus",
+			"__type__": "ResponseStatus",
 			"success": true,
 			"message": ""
 		}
 	}
 */
-func (srv *HTTPRestServer) getEventCheckSum(w http.ResponseWriter, r *http.Request) {
-	var (
-		err      error
-		event    EventData
-		response GetEventCheckSumResp
-	)
-
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
+func (srv *HTTPRestServer) readyz(w http.ResponseWriter, r *http.Request) {
+	var resp ReadyResp
+
+	notReady := func(reason string) {
+		srv.logger(r).Warning("Not ready: ", reason)
+		srv.sendWithStatus(NotReadyResp{
+			Common: Common{Type: NotReadyRespName},
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: reason},
+		}, http.StatusServiceUnavailable, w, r)
+	}
 
-	err = validateJWT(w, r)
-	if err != nil {
-		srv.invalidTokenResponse(w, r, err)
+	if !srv.ready.Load() {
+		notReady("Server is starting.")
+		return
+	}
 
+	if err := srv.repo(r).HealthCheck(); err != nil {
+		notReady(fmt.Sprintf("Database is unavailable: %s", err))
 		return
 	}
 
-	var msgData GetEventCheckSumReq
+	resp = ReadyResp{
+		Common: Common{Type: ReadyRespName},
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
 
-	err = json.NewDecoder(r.Body).Decode(&msgData)
-	if err != nil {
-		srv.log.Error(err)
+	if srv.cfg.MinFreeBytes > 0 && !isInMemory(srv.cfg.DatabaseFile) {
+		free, err := srv.freeBytes(databaseDir(srv.cfg.DatabaseFile))
+
+		switch {
+		case errors.Is(err, errDiskSpaceUnsupported):
+			/* Platform can not tell, readiness does not depend on what is unknowable */
+		case err != nil:
+			notReady(fmt.Sprintf("Free disk space unknown: %s", err))
+			return
+		case free < uint64(srv.cfg.MinFreeBytes):
+			notReady(fmt.Sprintf("Low disk space: %d bytes free, at least %d required.", free, srv.cfg.MinFreeBytes))
+			return
+		default:
+			resp.FreeBytes = &free
+		}
 	}
 
-	response.Common = Common{Type: GetEventCheckSumRespName}
+	srv.send(resp, w, r)
+}
 
-	event, err = srv.db.GetEventByUUID(msgData.UUID)
-	if err != nil {
-		srv.log.Error(err)
-		response.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: fmt.Sprintf("%s", err)}
-		response.Sum = fmt.Sprintf("%x", 0)
-	} else {
-		response.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""}
-		response.Sum = fmt.Sprintf("%x", event.Sha256())
+// errDiskSpaceUnsupported is returned by diskFreeBytes on platforms it can not check free space on.
+var errDiskSpaceUnsupported = errors.New("free disk space can not be checked on this platform")
+
+func (srv *HTTPRestServer) freeBytes(dir string) (uint64, error) {
+	/* Free space of the directory, reported by diskFreeBytes of the platform unless replaced */
+	if srv.diskFree != nil {
+		return srv.diskFree(dir)
 	}
 
-	srv.send(response, w, r)
+	return diskFreeBytes(dir)
 }
 
-// getStatus handles a request to the /api/v1/status endpoint.
-// Returns current server status in JSON format.
-// If any error occurs, returns 500 with error message
-func (srv *HTTPRestServer) getStatus(w http.ResponseWriter, r *http.Request) {
-	var (
-		err  error
-		resp GetStatusResp
-	)
+/*
+serverTime handles a request to the /api/v1/time endpoint.
+Returns configured time zone, its present UTC offset and current Unix time, so clients
+can compute day boundaries the same way dateTimeToUnix does. Token is not required.
 
-	responseWithError := func(w http.ResponseWriter, msg string) {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Header().Set("Content-Type", "application/json")
+Example request:
 
-		resp = GetStatusResp{
-			Common:    Common{Type: GetStatusRespName},
-			Timestamp: time.Now().Unix(),
-			Status:    ResponseStatus{Common: Common{ResponseStatusName}, Success: false, Message: msg},
-			Version:   Version,
+	GET /api/v1/time
+
+Example response:
+
+	{
+		"__type__": "ServerTimeResp",
+		"timezone": "Europe/Warsaw",
+		"utc_offset": 7200,
+		"timestamp": 1729080000,
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
 		}
+	}
+*/
+func (srv *HTTPRestServer) serverTime(w http.ResponseWriter, r *http.Request) {
+	var resp ServerTimeResp
 
-		srv.send(resp, w, r)
+	location, err := srv.cfg.Location()
+	if err != nil {
+		srv.logger(r).Error(err)
+		srv.sendWithStatus(ServerTimeResp{
+			Common: Common{Type: ServerTimeRespName},
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: fmt.Sprintf("%s", err)},
+		}, http.StatusInternalServerError, w, r)
+
+		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
+	now := srv.clock.Now().In(location)
+	_, offset := now.Zone()
 
-	resp, err = srv.db.GetStatus()
-	if err != nil {
-		srv.log.Error(err)
-		responseWithError(w, fmt.Sprintf("%s", err))
+	resp = ServerTimeResp{
+		Common:    Common{Type: ServerTimeRespName},
+		TimeZone:  location.String(),
+		UTCOffset: offset,
+		Timestamp: now.Unix(),
+		Status:    ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
 	}
 
 	srv.send(resp, w, r)
 }
 
 /*
-insertEvent handles a request to the /api/v1/insertEvent endpoint.
-Takes EventData as JSON, inserts it into database and returns
-response with inserted event UUID or error message.
+buildInfo handles a request to the /api/v1/buildInfo endpoint.
+
+Gathers diagnostics needed in support tickets in one place. VCS details are available only
+for binaries built from a repository checkout, otherwise they are reported as `unknown`.
+Available to admin only.
 
 Example request:
 
-	POST /api/v1/insertEvent
-	{
-		"event": {
-			"title": "New event",
-			"start": "2024-02-13T12:00:00Z",
-			"end": "2024-02-13T12:00:00Z",
-			"address": "Warszawa, ul. Okrężna 26",
-			"info": "Some event info",
-			"reminder": 7,
-			"done": false,
-			"important": true,
-			"urgent": false,
-			"source": "APP"
-		}
-	}
+	GET /api/v1/buildInfo
+	Token: <token>
 
 Example response:
 
 	{
-		"common": {
-			"type": "AddEventResp"
-		},
+		"__type__": "BuildInfoResp",
+		"version": "v1.1.0",
+		"go_version": "go1.19.13",
+		"module": "eventshub",
+		"db_driver": "sqlite3",
+		"schema_version": 1,
+		"vcs": {"system": "git", "revision": "849ad2b...", "time": "2026-10-16T10:00:00Z", "modified": false},
 		"status": {
-			"type": "ResponseStatus",
+			"__type__": "ResponseStatus",
 			"success": true,
 			"message": ""
 		}
 	}
 */
-func (srv *HTTPRestServer) insertEvent(w http.ResponseWriter, r *http.Request) {
-	var (
-		err  error
-		resp AddEventResp
-	)
-
-	responseWithError := func(w http.ResponseWriter, msg string) {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Header().Set("Content-Type", "application/json")
-
-		resp = AddEventResp{
-			Common: Common{Type: AddEventRespName},
-			Status: ResponseStatus{Common: Common{ResponseStatusName}, Success: false, Message: msg},
+func (srv *HTTPRestServer) buildInfo(w http.ResponseWriter, r *http.Request) {
+	var resp BuildInfoResp
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = BuildInfoResp{
+			Common:  Common{Type: BuildInfoRespName},
+			Version: Version,
+			Status:  ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
 		}
 
-		srv.send(resp, w, r)
+		srv.sendWithStatus(resp, code, w, r)
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-
-	err = validateJWT(w, r)
+	schemaVersion, err := srv.repo(r).GetSchemaVersion()
 	if err != nil {
-		srv.invalidTokenResponse(w, r, err)
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
 		return
 	}
 
-	var msgData AddEventReq
+	module, vcs := readBuildInfo()
+
+	resp = BuildInfoResp{
+		Common:        Common{Type: BuildInfoRespName},
+		Version:       Version,
+		GoVersion:     runtime.Version(),
+		Module:        module,
+		DBDriver:      BackendSQLite,
+		SchemaVersion: schemaVersion,
+		VCS:           vcs,
+		Status:        ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
 
-	err = json.NewDecoder(r.Body).Decode(&msgData)
-	if err != nil {
-		responseWithError(w, fmt.Sprintf("%s", err))
+	srv.send(resp, w, r)
+}
+
+/*
+validateToken handles a request to the /api/v1/validateToken endpoint.
+
+Checks the request token without touching the database, so clients and gateways can cheaply
+decide whether to refresh it. Invalid and expired tokens are reported with 200 and the reason
+code, a request without token is answered with 401 like any other endpoint.
+
+Example request:
+
+	GET /api/v1/validateToken
+	Token: <token>
+
+Example response:
+
+	{
+		"__type__": "ValidateTokenResp",
+		"valid": true,
+		"user": "admin",
+		"expires_at": 1729080000,
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) validateToken(w http.ResponseWriter, r *http.Request) {
+	resp := ValidateTokenResp{
+		Common: Common{Type: ValidateTokenRespName},
+		Status: ResponseStatus{
+			Common:  Common{Type: ResponseStatusName},
+			Success: true,
+		},
+	}
+
+	claims, err := parseJWT(srv.cfg, srv.clock, r)
+	if errors.Is(err, ErrTokenMissing) {
+		srv.invalidTokenResponse(w, r, err)
 		return
 	}
 
-	result, err := srv.db.InsertEvent(&msgData.Event)
 	if err != nil {
-		srv.log.Error(err)
-		responseWithError(w, fmt.Sprintf("%s", err))
+		resp.Reason = tokenErrorCode(err)
+		srv.send(resp, w, r)
 
 		return
 	}
 
-	resp.Common = Common{Type: AddEventRespName}
-	if result.UUID == msgData.Event.UUID {
-		resp.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""}
-	} else {
-		resp.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: fmt.Sprintf("%s", err)}
-	}
+	/* parseJWT has already verified exp claim is present */
+	exp, _ := claims["exp"].(float64)
+	user, _ := claims["user"].(string)
+
+	resp.Valid = true
+	resp.User = user
+	resp.ExpiresAt = int64(exp)
 
 	srv.send(resp, w, r)
 }
 
-/* getEventsWithinTimeRange handles a request to the /api/v1/getEventsWithinTimeRange endpoint.
- * Takes GetEventsReq as JSON, retrieves events within the specified time range and returns
- * response with events or error message.
- *
- * Example request:
- *
- *	POST /api/v1/getEventsWithinTimeRange
- *	{
- *		"start": "2024-02-13T12:00:00Z",
- *		"end": "2024-02-13T12:00:00Z"
- *	}
- *
- * Example response:
- *
- *	{
- *		"common": {
- *			"type": "GetEventsResp"
- *		},
+/*
+Get event check sum
+
+Handler responds to GET requests only. Returns 404 when there is no event with the UUID.
+
+Example request:
+
+	GET /api/v1/checksum?uuid=<uuid>
+
+Example response:
+
+	{
+		"sum": "0b2dd0f43614138995beafa87b6356b",
+		"status": {
+			"type": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) getEventCheckSum(w http.ResponseWriter, r *http.Request) {
+	var (
+		err      error
+		event    EventData
+		response GetEventCheckSumResp
+	)
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+
+		return
+	}
+
+	var msgData GetEventCheckSumReq
+
+	response.Common = Common{Type: GetEventCheckSumRespName}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		response.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: err.Error()}
+		response.Sum = fmt.Sprintf("%x", 0)
+
+		srv.sendWithStatus(response, http.StatusBadRequest, w, r)
+
+		return
+	}
+
+	event, err = srv.repo(r).GetEventByUUID(msgData.UUID)
+	if errors.Is(err, ErrEventNotFound) {
+		response.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false,
+			Message: fmt.Sprintf("Event %s does not exist.", msgData.UUID)}
+		response.Sum = fmt.Sprintf("%x", 0)
+
+		srv.sendWithStatus(response, http.StatusNotFound, w, r)
+
+		return
+	} else if err != nil {
+		srv.logger(r).Error(err)
+		response.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: fmt.Sprintf("%s", err)}
+		response.Sum = fmt.Sprintf("%x", 0)
+
+		srv.sendWithStatus(response, http.StatusInternalServerError, w, r)
+
+		return
+	}
+
+	response.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""}
+	response.Sum = fmt.Sprintf("%x", event.Sha256())
+
+	srv.send(response, w, r)
+}
+
+/*
+planSync handles a request to the /api/v1/planSync endpoint.
+Takes checksums of up to PlanSyncMaxUUIDs client events, computed like in getEventCheckSum,
+and tells which of them are not stored yet, stored with different content or unchanged,
+so a client uploads only what is needed. Every list is sorted.
+
+Example request:
+
+	POST /api/v1/planSync
+	{
+		"checksums": {
+			"e0b2dd0f43614138995beafa87b6356b": "5d41402abc4b2a76b9719d911017c592...",
+			"5bd8fa795fa04bf79c37dd1b9583709f": "7d793037a0760186574b0282f2f435e7..."
+		}
+	}
+
+Example response:
+
+	{
+		"__type__": "PlanSyncResp",
+		"new": ["5bd8fa795fa04bf79c37dd1b9583709f"],
+		"changed": [],
+		"unchanged": ["e0b2dd0f43614138995beafa87b6356b"],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) planSync(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData PlanSyncReq
+		resp    PlanSyncResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = PlanSyncResp{
+			Common: Common{Type: PlanSyncRespName},
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(msgData.Checksums) > PlanSyncMaxUUIDs {
+		responseWithError(w, http.StatusBadRequest, fmt.Sprintf("At most %d events can be compared at once.", PlanSyncMaxUUIDs))
+		return
+	}
+
+	uuids := make([]string, 0, len(msgData.Checksums))
+	for uuid := range msgData.Checksums {
+		uuids = append(uuids, uuid)
+	}
+
+	sort.Strings(uuids)
+
+	stored, err := srv.repo(r).GetEventsByUUIDs(uuids)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	sums := make(map[string]string, len(stored))
+	for i := range stored {
+		sums[stored[i].UUID] = fmt.Sprintf("%x", stored[i].Sha256())
+	}
+
+	resp = PlanSyncResp{
+		Common:    Common{Type: PlanSyncRespName},
+		New:       []string{},
+		Changed:   []string{},
+		Unchanged: []string{},
+		Status:    ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	for _, uuid := range uuids {
+		sum, ok := sums[uuid]
+
+		switch {
+		case !ok:
+			resp.New = append(resp.New, uuid)
+		case !strings.EqualFold(sum, msgData.Checksums[uuid]):
+			resp.Changed = append(resp.Changed, uuid)
+		default:
+			resp.Unchanged = append(resp.Unchanged, uuid)
+		}
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+getEventDetail handles a request to the /api/v1/getEventDetail endpoint.
+Returns event with provided UUID together with its creation and update timestamps
+and the user who last modified it. Returns 404 when there is no event with the UUID.
+
+Example request:
+
+	GET /api/v1/getEventDetail
+	{
+		"uuid": "e0b2dd0f43614138995beafa87b6356b"
+	}
+
+Example response:
+
+	{
+		"__type__": "GetEventDetailResp",
+		"event": {
+			"__type__": "EventData",
+			"uuid": "e0b2dd0f43614138995beafa87b6356b",
+			...
+			"created_at": 1723975200,
+			"updated_at": 1723978800,
+			"last_modified_by": "admin"
+		},
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) getEventDetail(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData GetEventDetailReq
+		resp    GetEventDetailResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetEventDetailResp{
+			Common: Common{Type: GetEventDetailRespName},
+			Event:  nil,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	detail, err := srv.repo(r).GetEventDetail(msgData.UUID)
+	if errors.Is(err, ErrEventNotFound) {
+		responseWithError(w, http.StatusNotFound, fmt.Sprintf("Event %s does not exist.", msgData.UUID))
+		return
+	} else if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = GetEventDetailResp{
+		Common: Common{Type: GetEventDetailRespName},
+		Event:  &detail,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+// getStatus handles a request to the /api/v1/status endpoint.
+// Returns current server status in JSON format.
+// If optional `since` Unix timestamp query parameter is provided, `changes` field
+// contains number of events created or modified since then, e.g. GET /api/v1/status?since=1723975200
+// If `since` is invalid, returns 400, if any other error occurs, returns 500 with error message
+func (srv *HTTPRestServer) getStatus(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		resp GetStatusResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetStatusResp{
+			Common:    Common{Type: GetStatusRespName},
+			Timestamp: srv.clock.Now().Unix(),
+			Status:    ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+			Version:   Version,
+			Instance:  srv.cfg.InstanceName,
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	resp, err = srv.repo(r).GetStatus()
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp.Instance = srv.cfg.InstanceName
+
+	if param := r.URL.Query().Get("since"); param != "" {
+		since, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			responseWithError(w, http.StatusBadRequest, "Since must be a Unix timestamp.")
+			return
+		}
+
+		changes, err := srv.repo(r).CountEventsChangedSince(since)
+		if err != nil {
+			srv.logger(r).Error(err)
+			responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+			return
+		}
+
+		resp.Changes = &changes
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+getStatusHistory handles a request to the /api/v1/statusHistory endpoint.
+Returns last `limit` status records, most recent first. Limit defaults to
+StatusHistoryDefaultLimit and may not exceed StatusHistoryMaxLimit.
+
+Example request:
+
+	GET /api/v1/statusHistory?limit=2
+
+Example response:
+
+	{
+		"__type__": "GetStatusHistoryResp",
+		"history": [
+			{"__type__": "GetStatusResp", "timestamp": 1723975200, "status": {...}, "version": "1.1.0"},
+			{"__type__": "GetStatusResp", "timestamp": 1723975100, "status": {...}, "version": "1.1.0"}
+		],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) getStatusHistory(w http.ResponseWriter, r *http.Request) {
+	var (
+		err   error
+		limit = StatusHistoryDefaultLimit
+		resp  GetStatusHistoryResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetStatusHistoryResp{
+			Common:  Common{Type: GetStatusHistoryRespName},
+			History: nil,
+			Status:  ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	if param := r.URL.Query().Get("limit"); param != "" {
+		limit, err = strconv.Atoi(param)
+		if err != nil || limit < 1 || limit > StatusHistoryMaxLimit {
+			responseWithError(w, http.StatusBadRequest,
+				fmt.Sprintf("Limit must be a number between 1 and %d.", StatusHistoryMaxLimit))
+
+			return
+		}
+	}
+
+	history, err := srv.repo(r).GetStatusHistory(limit)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = GetStatusHistoryResp{
+		Common:  Common{Type: GetStatusHistoryRespName},
+		History: history,
+		Status:  ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+getAudit handles a request to the /api/v1/audit endpoint.
+Available to the configured admin only, see requireAdmin.
+Returns last `limit` audit entries of mutating operations, most recent first.
+Limit defaults to AuditDefaultLimit and may not exceed AuditMaxLimit.
+
+Example request:
+
+	GET /api/v1/audit?limit=2
+
+Example response:
+
+	{
+		"__type__": "GetAuditResp",
+		"entries": [
+			{"__type__": "AuditEntry", "timestamp": 1723975200, "user": "admin", "action": "delete", "uuid": "e0b2dd0f43614138995beafa87b6356b"},
+			{"__type__": "AuditEntry", "timestamp": 1723975100, "user": "admin", "action": "insert", "uuid": "e0b2dd0f43614138995beafa87b6356b"}
+		],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) getAudit(w http.ResponseWriter, r *http.Request) {
+	var (
+		err   error
+		limit = AuditDefaultLimit
+		resp  GetAuditResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetAuditResp{
+			Common:  Common{Type: GetAuditRespName},
+			Entries: nil,
+			Status:  ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	if param := r.URL.Query().Get("limit"); param != "" {
+		limit, err = strconv.Atoi(param)
+		if err != nil || limit < 1 || limit > AuditMaxLimit {
+			responseWithError(w, http.StatusBadRequest,
+				fmt.Sprintf("Limit must be a number between 1 and %d.", AuditMaxLimit))
+
+			return
+		}
+	}
+
+	entries, err := srv.repo(r).GetAudit(limit)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = GetAuditResp{
+		Common:  Common{Type: GetAuditRespName},
+		Entries: entries,
+		Status:  ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+myReminders handles a request to the /api/v1/myReminders endpoint.
+Returns reminders of not done events owned by the authenticated user which fire
+within next `days` days, ordered by the moment they fire. Days default to
+MyRemindersDefaultDays and may not exceed MyRemindersMaxDays. Unlike reminder
+delivery it does not require an email address to be set.
+
+Example request:
+
+	GET /api/v1/myReminders?days=3
+
+Example response:
+
+	{
+		"__type__": "MyRemindersResp",
+		"reminders": [
+			{"__type__": "UserReminder", "remind_at": 1723975200, "event": {"__type__": "EventData", ...}}
+		],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) myReminders(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		days = MyRemindersDefaultDays
+		resp MyRemindersResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = MyRemindersResp{
+			Common:    Common{Type: MyRemindersRespName},
+			Reminders: nil,
+			Status:    ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	user, err := tokenUsername(srv.cfg, srv.clock, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	if param := r.URL.Query().Get("days"); param != "" {
+		days, err = strconv.Atoi(param)
+		if err != nil || days < 1 || days > MyRemindersMaxDays {
+			responseWithError(w, http.StatusBadRequest,
+				fmt.Sprintf("Days must be a number between 1 and %d.", MyRemindersMaxDays))
+
+			return
+		}
+	}
+
+	now := srv.clock.Now()
+
+	reminders, err := srv.repo(r).GetUserReminders(user, now.Unix(), now.AddDate(0, 0, days).Unix())
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = MyRemindersResp{
+		Common:    Common{Type: MyRemindersRespName},
+		Reminders: reminders,
+		Status:    ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+settings handles a request to the /api/v1/settings endpoint.
+GET returns settings of the authenticated user, an empty object when none were stored.
+PUT replaces them with the request body, which must be a JSON object
+not larger than UserSettingsMaxSize bytes.
+
+Example request:
+
+	PUT /api/v1/settings
+	{"theme": "dark", "weekStartsOn": "monday"}
+
+Example response:
+
+	{
+		"__type__": "UserSettingsResp",
+		"settings": {"theme": "dark", "weekStartsOn": "monday"},
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) settings(w http.ResponseWriter, r *http.Request) {
+	var (
+		err      error
+		resp     UserSettingsResp
+		settings string
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = UserSettingsResp{
+			Common:   Common{Type: UserSettingsRespName},
+			Settings: nil,
+			Status:   ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	user, err := tokenUsername(srv.cfg, srv.clock, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		settings, err = srv.repo(r).GetUserSettings(user)
+	case http.MethodPut:
+		body, readErr := io.ReadAll(io.LimitReader(r.Body, int64(UserSettingsMaxSize)+1))
+		if readErr != nil {
+			responseWithError(w, http.StatusBadRequest, fmt.Sprintf("%s", readErr))
+			return
+		}
+
+		if len(body) > UserSettingsMaxSize {
+			responseWithError(w, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("Settings may not exceed %d bytes.", UserSettingsMaxSize))
+
+			return
+		}
+
+		var object map[string]json.RawMessage
+
+		if len(bytes.TrimSpace(body)) == 0 {
+			responseWithError(w, http.StatusBadRequest, ErrEmptyBody.Error())
+			return
+		} else if err = json.Unmarshal(body, &object); err != nil || object == nil {
+			responseWithError(w, http.StatusBadRequest, "Settings must be a well-formed JSON object.")
+			return
+		}
+
+		settings = string(body)
+		err = srv.repo(r).SetUserSettings(user, settings)
+	default:
+		responseWithError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s method not implemented!", r.Method))
+		return
+	}
+
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = UserSettingsResp{
+		Common:   Common{Type: UserSettingsRespName},
+		Settings: json.RawMessage(settings),
+		Status:   ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+userEmail handles a request to the /api/v1/email endpoint.
+GET returns the address reminders of the authenticated user are sent to.
+PUT replaces it, an empty address stops reminders of the user.
+
+Example request:
+
+	PUT /api/v1/email
+	{"email": "alice@example.com"}
+
+Example response:
+
+	{
+		"__type__": "UserEmailResp",
+		"email": "alice@example.com",
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) userEmail(w http.ResponseWriter, r *http.Request) {
+	var (
+		err   error
+		email string
+		resp  UserEmailResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = UserEmailResp{
+			Common: Common{Type: UserEmailRespName},
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	user, err := tokenUsername(srv.cfg, srv.clock, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		email, err = srv.repo(r).GetUserEmail(user)
+	case http.MethodPut:
+		var req UserEmailReq
+
+		if err = decodeBody(r, &req); err != nil {
+			responseWithError(w, http.StatusBadRequest, fmt.Sprintf("%s", err))
+			return
+		}
+
+		email = strings.TrimSpace(req.Email)
+
+		if email != "" {
+			/* Bare address only, display name would end up in the recipient list */
+			address, parseErr := mail.ParseAddress(email)
+			if parseErr != nil || address.Address != email || len(email) > UserEmailMaxLength {
+				responseWithError(w, http.StatusBadRequest, "Email must be a plain address, e.g. alice@example.com.")
+				return
+			}
+		}
+
+		err = srv.repo(r).SetUserEmail(user, email)
+	default:
+		responseWithError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s method not implemented!", r.Method))
+		return
+	}
+
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = UserEmailResp{
+		Common: Common{Type: UserEmailRespName},
+		Email:  email,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+backup handles a request to the /api/v1/backup endpoint.
+Available to the configured admin only, see requireAdmin. Writes consistent snapshot of the
+database to a temporary file and streams it to the client as a download.
+In-memory database can not be backed up and results in 501.
+
+Example request:
+
+	GET /api/v1/backup
+
+Example response:
+
+	Content-Type: application/vnd.sqlite3
+	Content-Disposition: attachment; filename="eventshub-1723975200.db"
+
+	<SQLite database file>
+*/
+func (srv *HTTPRestServer) backup(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		resp BackupResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = BackupResp{
+			Common: Common{Type: BackupRespName},
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	dir, err := os.MkdirTemp("", "eventshub-backup-")
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "snapshot.db")
+
+	err = srv.repo(r).Backup(path)
+	if errors.Is(err, ErrBackupNotSupported) {
+		responseWithError(w, http.StatusNotImplemented, err.Error())
+		return
+	} else if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	snapshot, err := os.Open(path)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	defer snapshot.Close()
+
+	w.Header().Set("Content-Type", BackupContentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"%s-%d.db\"", srv.cfg.InstanceName, srv.clock.Now().Unix()))
+	w.WriteHeader(http.StatusOK)
+
+	/* Slow client may take longer than the socket write timeout to download the whole snapshot */
+	if _, err = io.Copy(deadlineWriter{w: w, r: r, d: srv.cfg.WriteTimeout}, snapshot); err != nil {
+		srv.logger(r).Error("Writing backup failed: ", err)
+	}
+}
+
+/*
+attachment handles a request to the /api/v1/attachment endpoint.
+Event is selected with `uuid` query parameter, it may have a single attachment.
+GET downloads the attachment, PUT uploads the request body as the attachment,
+replacing previous one, and DELETE removes it. Uploads are limited to
+cfg.MaxAttachmentBytes, larger ones are refused with 413, and to media types of
+cfg.AttachmentTypes, others are refused with 415. Content is kept in BlobStore,
+database stores only its key. Attachments are available to the owner of the event
+and to the configured admin only, others are refused with 403. Returns 404 when
+attachments are disabled or the event does not exist.
+
+Example request:
+
+	PUT /api/v1/attachment?uuid=e0b2dd0f43614138995beafa87b6356b
+	Content-Type: application/pdf
+
+	<PDF document>
+
+Example response:
+
+	{
+		"__type__": "AttachmentResp",
+		"attachment": {"__type__": "Attachment", "uuid": "e0b2dd0f43614138995beafa87b6356b", "content_type": "application/pdf", "size": 5120},
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) attachment(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		a    Attachment
+		resp AttachmentResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = AttachmentResp{
+			Common:     Common{Type: AttachmentRespName},
+			Attachment: nil,
+			Status:     ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	if srv.blobs == nil {
+		responseWithError(w, http.StatusNotFound, "Attachments are disabled.")
+		return
+	}
+
+	uuid := r.URL.Query().Get("uuid")
+	if uuid == "" {
+		responseWithError(w, http.StatusBadRequest, "Missing uuid query parameter.")
+		return
+	}
+
+	/* Checked up front, so content of unknown or foreign events is not even stored */
+	owner, err := srv.repo(r).GetEventOwner(uuid)
+	if errors.Is(err, ErrEventNotFound) {
+		responseWithError(w, http.StatusNotFound, err.Error())
+		return
+	} else if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	if user := srv.requestUser(r); user != owner && (srv.cfg.AdminUsername == "" || user != srv.cfg.AdminUsername) {
+		responseWithError(w, http.StatusForbidden, fmt.Sprintf("Event %s belongs to another user.", uuid))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		srv.downloadAttachment(w, r, uuid, responseWithError)
+		return
+	case http.MethodPut:
+		a, err = srv.uploadAttachment(w, r, uuid)
+	case http.MethodDelete:
+		a, err = srv.repo(r).DeleteAttachment(uuid)
+		if err == nil {
+			/* Row is gone already, blob left behind on failure only takes space */
+			if blobErr := srv.blobs.Delete(a.Key); blobErr != nil {
+				srv.logger(r).Error(blobErr)
+			}
+		}
+	default:
+		responseWithError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s method not implemented!", r.Method))
+		return
+	}
+
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, errUnsupportedAttachment):
+		responseWithError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	case errors.As(err, &maxBytesErr):
+		responseWithError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Attachment too large, at most %d bytes allowed.", srv.cfg.MaxAttachmentBytes))
+
+		return
+	case errors.Is(err, ErrEventNotFound), errors.Is(err, ErrAttachmentNotFound):
+		responseWithError(w, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	srv.recordAudit(r, AuditActionUpdate, uuid)
+
+	resp = AttachmentResp{
+		Common:     Common{Type: AttachmentRespName},
+		Attachment: &a,
+		Status:     ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+// errUnsupportedAttachment is returned by uploadAttachment for media types outside cfg.AttachmentTypes.
+var errUnsupportedAttachment = errors.New("unsupported attachment type")
+
+func (srv *HTTPRestServer) uploadAttachment(w http.ResponseWriter, r *http.Request, uuid string) (Attachment, error) {
+	/* Store request body under a new blob key and only then point the event to it,
+	 * so a failed upload never replaces the previous attachment.
+	 */
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || !srv.cfg.AttachmentTypeAllowed(mediaType) {
+		return Attachment{}, fmt.Errorf("%w %q, allowed are %s",
+			errUnsupportedAttachment, r.Header.Get("Content-Type"), strings.Join(srv.cfg.AttachmentTypes, ", "))
+	}
+
+	key, err := newBlobKey()
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	body := &countingReader{r: http.MaxBytesReader(w, r.Body, int64(srv.cfg.MaxAttachmentBytes))}
+	if err = srv.blobs.Put(key, body); err != nil {
+		return Attachment{}, err
+	}
+
+	a := Attachment{
+		Common:      Common{Type: AttachmentStructName},
+		EventUUID:   uuid,
+		ContentType: mediaType,
+		Size:        body.n,
+		Key:         key,
+	}
+
+	previous, err := srv.repo(r).SetAttachment(a)
+	if err != nil {
+		_ = srv.blobs.Delete(key)
+		return Attachment{}, err
+	}
+
+	if previous != "" {
+		if err = srv.blobs.Delete(previous); err != nil {
+			srv.logger(r).Error(err)
+		}
+	}
+
+	return a, nil
+}
+
+func (srv *HTTPRestServer) downloadAttachment(w http.ResponseWriter, r *http.Request, uuid string,
+	responseWithError func(w http.ResponseWriter, code int, msg string),
+) {
+	/* Stream attachment of the event with its media type. Media type comes from the
+	 * uploader, so browsers are told not to sniff or render it inline.
+	 */
+	a, err := srv.repo(r).GetAttachment(uuid)
+	if errors.Is(err, ErrAttachmentNotFound) {
+		responseWithError(w, http.StatusNotFound, err.Error())
+		return
+	} else if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	blob, err := srv.blobs.Get(a.Key)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", uuid))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = io.Copy(deadlineWriter{w: w, r: r, d: srv.cfg.WriteTimeout}, blob); err != nil {
+		srv.logger(r).Error("Writing attachment failed: ", err)
+	}
+}
+
+// countingReader counts bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
+// exportCSVHeader names columns of exportCSV, in order of csvRecord fields.
+var exportCSVHeader = []string{
+	"uuid", "title", "start", "end", "address", "info",
+	"reminder", "done", "important", "urgent", "source",
+}
+
+/*
+exportCSV handles a request to the /api/v1/export.csv endpoint.
+Streams all events as RFC 4180 CSV for spreadsheets, header row first and one line
+per event. Dates are local `YYYY-MM-DD hh:mm` like in the XML export. Events are read
+in batches of ExportBatchSize, so the table is never held in memory whole. Output is
+gzip compressed while streaming when enabled and the client sends `Accept-Encoding: gzip`.
+
+Example request:
+
+	GET /api/v1/export.csv
+	Accept-Encoding: gzip
+
+Example response:
+
+	Content-Type: text/csv; charset=utf-8
+	Content-Encoding: gzip
+	Content-Disposition: attachment; filename="eventshub-1723975200.csv"
+
+	uuid,title,start,end,address,info,reminder,done,important,urgent,source
+	e0b2dd0f43614138995beafa87b6356b,Ur. Mr X,2021-01-12 00:00,2021-01-12 00:00,"Warszawa, ul. Okrężna 26",Likes beer,7,false,true,false,APP
+*/
+func (srv *HTTPRestServer) exportCSV(w http.ResponseWriter, r *http.Request) {
+	err := validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", ExportCSVContentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"%s-%d.csv\"", srv.cfg.InstanceName, srv.clock.Now().Unix()))
+	var body io.Writer = deadlineWriter{w: w, r: r, d: srv.cfg.WriteTimeout}
+
+	if srv.cfg.Gzip {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	if srv.cfg.Gzip && acceptsGzip(r) {
+		w.Header().Set("Content-Encoding", "gzip")
+
+		zw := gzip.NewWriter(body)
+		defer func() {
+			if err := zw.Close(); err != nil {
+				srv.logger(r).Error("Closing compressed CSV export failed: ", err)
+			}
+		}()
+
+		body = zw
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	/* Response is already started, failures can only be logged and cut the download short */
+	out := csv.NewWriter(body)
+
+	err = out.Write(exportCSVHeader)
+	if err == nil {
+		err = srv.repo(r).ForEachEvent(ExportBatchSize, func(e EventData) error {
+			return out.Write(csvRecord(e))
+		})
+	}
+
+	out.Flush()
+
+	if err == nil {
+		err = out.Error()
+	}
+
+	if err != nil {
+		srv.logger(r).Error("Writing CSV export failed: ", err)
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	/* Check whether Accept-Encoding of the request lists gzip with non-zero quality */
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(coding, ";")
+		if !strings.EqualFold(strings.TrimSpace(params[0]), "gzip") {
+			continue
+		}
+
+		for _, param := range params[1:] {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if q, err := strconv.ParseFloat(value, 64); found && name == "q" && err == nil && q == 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func csvRecord(e EventData) []string {
+	/* Convert event to CSV fields listed by exportCSVHeader */
+	date := func(d DateTime) string {
+		return fmt.Sprintf("%04d-%02d-%02d %02d:%02d", d.Year, d.Month, d.Day, d.Hour, d.Minute)
+	}
+
+	return []string{
+		csvText(e.UUID), csvText(e.Title), date(e.Start), date(e.End), csvText(e.Address), csvText(e.Info),
+		strconv.Itoa(int(e.Reminder)), strconv.FormatBool(e.Done),
+		strconv.FormatBool(e.Important), strconv.FormatBool(e.Urgent), csvText(e.Source),
+	}
+}
+
+func csvText(value string) string {
+	/* Prefix text spreadsheets would evaluate as a formula with `'`, so opening export
+	 * does not run formulas planted in event text, e.g. `=HYPERLINK(...)`.
+	 */
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+/*
+insertEvent handles a request to the /api/v1/insertEvent endpoint.
+Takes EventData as JSON, inserts it into database and returns
+response with inserted event UUID or error message.
+Event with already stored UUID is updated, unless `?mode=insert-only`
+is passed; then it is left untouched and 409 is returned. Event without
+reminder gets cfg.ReminderDays when `use_default_reminder` is set.
+Invalid event is rejected with 400 and ValidationErrorResp listing every
+invalid field, e.g. `"errors": {"color": "...", "title": "..."}`. Source outside
+GOCALENDAR_ALLOWED_SOURCES is rejected only when the event is inserted, not updated.
+
+Example request:
+
+	POST /api/v1/insertEvent
+	{
+		"event": {
+			"title": "New event",
+			"start": "2024-02-13T12:00:00Z",
+			"end": "2024-02-13T12:00:00Z",
+			"address": "Warszawa, ul. Okrężna 26",
+			"info": "Some event info",
+			"reminder": 7,
+			"done": false,
+			"important": true,
+			"urgent": false,
+			"source": "APP"
+		}
+	}
+
+Example response:
+
+	{
+		"common": {
+			"type": "AddEventResp"
+		},
+		"status": {
+			"type": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) insertEvent(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		resp AddEventResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = AddEventResp{
+			Common: Common{Type: AddEventRespName},
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	var insertOnly bool
+
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", "upsert":
+	case "insert-only":
+		insertOnly = true
+	default:
+		responseWithError(w, http.StatusBadRequest, fmt.Sprintf("Unknown mode %q, expected upsert or insert-only.", mode))
+		return
+	}
+
+	var msgData AddEventReq
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	msgData.applyDefaultReminder(srv.cfg.ReminderDays)
+
+	/* Validate before storing, so form-filling clients learn about every invalid field at once */
+	var invalid *ValidationError
+
+	msgData.Event.Normalize()
+
+	/* Upsert may update stored event, its source is checked by repository once it is known to be new */
+	validate := msgData.Event.Validate
+	if insertOnly {
+		validate = msgData.Event.ValidateNew
+	}
+
+	if errors.As(validate(srv.cfg), &invalid) {
+		srv.sendWithStatus(ValidationErrorResp{
+			Common: Common{Type: ValidationErrorRespName},
+			Errors: invalid.Fields,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: invalid.Error()},
+		}, http.StatusBadRequest, w, r)
+
+		return
+	}
+
+	resp, code := srv.addEvent(r, &msgData.Event, insertOnly)
+
+	srv.sendWithStatus(resp, code, w, r)
+}
+
+func (srv *HTTPRestServer) addEvent(r *http.Request, event *EventData, insertOnly bool) (AddEventResp, int) {
+	/* Insert event on behalf of the request user and return response with HTTP status code.
+	 * Shared by every path inserting single events, e.g. insertEvent and WebSocket messages.
+	 * Insert-only never overwrites event stored under the same UUID.
+	 */
+	var (
+		err    error
+		result = event
+	)
+
+	resp := AddEventResp{Common: Common{Type: AddEventRespName}}
+
+	event.Owner = srv.requestUser(r)
+
+	if insertOnly {
+		var inserted bool
+
+		inserted, err = srv.repo(r).InsertIfAbsent(event)
+		if err == nil && !inserted {
+			err = fmt.Errorf("%w: %s", ErrEventExists, event.UUID)
+		}
+	} else {
+		result, err = srv.repo(r).InsertEvent(event)
+	}
+
+	if errors.Is(err, ErrInvalidEvent) {
+		resp.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: err.Error()}
+		return resp, http.StatusBadRequest
+	} else if errors.Is(err, ErrDuplicateEvent) || errors.Is(err, ErrEventExists) {
+		resp.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: err.Error()}
+		return resp, http.StatusConflict
+	} else if errors.Is(err, ErrQuotaExceeded) {
+		resp.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: err.Error()}
+		return resp, http.StatusForbidden
+	} else if err != nil {
+		srv.logger(r).Error(err)
+		resp.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: fmt.Sprintf("%s", err)}
+
+		return resp, http.StatusInternalServerError
+	}
+
+	if result.UUID == event.UUID {
+		srv.recordAudit(r, AuditActionInsert, result.UUID)
+		resp.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""}
+	} else {
+		resp.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: fmt.Sprintf("%s", err)}
+	}
+
+	return resp, http.StatusOK
+}
+
+/*
+patchEvent handles a request to the /api/v1/patchEvent endpoint.
+Takes PatchEventReq with event UUID and only the fields to change.
+Fields absent from the request are preserved. Returns 404 if event does not exist.
+
+Example request:
+
+	POST /api/v1/patchEvent
+	{
+		"uuid": "e0b2dd0f43614138995beafa87b6356b",
+		"done": true
+	}
+
+Example response:
+
+	{
+		"__type__": "PatchEventResp",
+		"event": {...},
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) patchEvent(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData PatchEventReq
+		resp    PatchEventResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = PatchEventResp{
+			Common: Common{Type: PatchEventRespName},
+			Event:  nil,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := srv.repo(r).PatchEvent(&msgData)
+	if errors.Is(err, ErrInvalidEvent) {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	if result == nil {
+		responseWithError(w, http.StatusNotFound, fmt.Sprintf("Event %s does not exist.", msgData.UUID))
+		return
+	}
+
+	srv.recordAudit(r, AuditActionUpdate, result.UUID)
+
+	resp = PatchEventResp{
+		Common: Common{Type: PatchEventRespName},
+		Event:  result,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+deleteEvents handles a request to the /api/v1/deleteEvents endpoint.
+Takes DeleteEventsReq with up to DeleteEventsMaxUUIDs UUIDs, deletes matching
+events in a single transaction and returns number of events actually removed.
+
+Example request:
+
+	POST /api/v1/deleteEvents
+	{
+		"uuids": ["e0b2dd0f43614138995beafa87b6356b", "5bd8fa795fa04bf79c37dd1b9583709f"]
+	}
+
+Example response:
+
+	{
+		"__type__": "DeleteEventsResp",
+		"deleted": 2,
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) deleteEvents(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData DeleteEventsReq
+		resp    DeleteEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = DeleteEventsResp{
+			Common:  Common{Type: DeleteEventsRespName},
+			Deleted: 0,
+			Status:  ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(msgData.UUIDs) > DeleteEventsMaxUUIDs {
+		responseWithError(w, http.StatusBadRequest, fmt.Sprintf("At most %d UUIDs can be deleted at once.", DeleteEventsMaxUUIDs))
+		return
+	}
+
+	deleted, err := srv.repo(r).DeleteEvents(msgData.UUIDs)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	for _, uuid := range deleted {
+		srv.recordAudit(r, AuditActionDelete, uuid)
+	}
+
+	resp = DeleteEventsResp{
+		Common:  Common{Type: DeleteEventsRespName},
+		Deleted: int64(len(deleted)),
+		Status:  ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+updateFlags handles a request to the /api/v1/updateFlags endpoint.
+Takes UpdateFlagsReq with up to UpdateFlagsMaxUUIDs UUIDs and sets provided done,
+important and urgent flags of matching events in a single transaction. Flags absent
+from the request are left untouched. Returns number of events actually updated.
+
+Example request:
+
+	POST /api/v1/updateFlags
+	{
+		"uuids": ["e0b2dd0f43614138995beafa87b6356b", "5bd8fa795fa04bf79c37dd1b9583709f"],
+		"done": true
+	}
+
+Example response:
+
+	{
+		"__type__": "UpdateFlagsResp",
+		"updated": 2,
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) updateFlags(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData UpdateFlagsReq
+		resp    UpdateFlagsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = UpdateFlagsResp{
+			Common:  Common{Type: UpdateFlagsRespName},
+			Updated: 0,
+			Status:  ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(msgData.UUIDs) > UpdateFlagsMaxUUIDs {
+		responseWithError(w, http.StatusBadRequest, fmt.Sprintf("At most %d UUIDs can be updated at once.", UpdateFlagsMaxUUIDs))
+		return
+	}
+
+	if msgData.Done == nil && msgData.Important == nil && msgData.Urgent == nil {
+		responseWithError(w, http.StatusBadRequest, "At least one of done, important and urgent flags is required.")
+		return
+	}
+
+	updated, err := srv.repo(r).UpdateFlags(&msgData)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	for _, uuid := range updated {
+		srv.recordAudit(r, AuditActionUpdate, uuid)
+	}
+
+	resp = UpdateFlagsResp{
+		Common:  Common{Type: UpdateFlagsRespName},
+		Updated: int64(len(updated)),
+		Status:  ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+reassignEvents handles a request to the /api/v1/reassignEvents endpoint.
+Available to the configured admin only, see requireAdmin. Transfers all events
+owned by fromUser to toUser, e.g. when the first one leaves. Both users have to
+be registered, otherwise 404 is returned. Transfer which would exceed
+GOCALENDAR_MAX_EVENTS_PER_USER of toUser is rejected with 403 and moves nothing.
+Every moved event is recorded in the audit log. Returns number of events moved.
+
+Example request:
+
+	POST /api/v1/reassignEvents
+	{
+		"fromUser": "alice",
+		"toUser": "bob"
+	}
+
+Example response:
+
+	{
+		"__type__": "ReassignEventsResp",
+		"reassigned": 12,
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) reassignEvents(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData ReassignEventsReq
+		resp    ReassignEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = ReassignEventsResp{
+			Common:     Common{Type: ReassignEventsRespName},
+			Reassigned: 0,
+			Status:     ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if msgData.FromUser == "" || msgData.ToUser == "" {
+		responseWithError(w, http.StatusBadRequest, "Both fromUser and toUser are required.")
+		return
+	}
+
+	if msgData.FromUser == msgData.ToUser {
+		responseWithError(w, http.StatusBadRequest, "fromUser and toUser have to differ.")
+		return
+	}
+
+	reassigned, err := srv.repo(r).ReassignEvents(msgData.FromUser, msgData.ToUser)
+	if errors.Is(err, ErrUserNotFound) {
+		responseWithError(w, http.StatusNotFound, err.Error())
+		return
+	} else if errors.Is(err, ErrQuotaExceeded) {
+		responseWithError(w, http.StatusForbidden, err.Error())
+		return
+	} else if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	for _, uuid := range reassigned {
+		srv.recordAudit(r, AuditActionUpdate, uuid)
+	}
+
+	srv.logger(r).Info("Reassigned ", len(reassigned), " events of ", msgData.FromUser, " to ", msgData.ToUser)
+
+	resp = ReassignEventsResp{
+		Common:     Common{Type: ReassignEventsRespName},
+		Reassigned: int64(len(reassigned)),
+		Status:     ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+mergeEvents handles a request to the /api/v1/mergeEvents endpoint.
+Available to the configured admin only, see requireAdmin. Merges two records of
+the same real event, e.g. after messy imports: empty fields of the primary event
+are filled with values of the secondary one, which is then deleted. Both happen
+in a single transaction. Attachment of the secondary is moved to the primary when
+it has none. Returns 404 if either event does not exist.
+
+Example request:
+
+	POST /api/v1/mergeEvents
+	{
+		"primary": "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"secondary": "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	}
+
+Example response:
+
+	{
+		"__type__": "MergeEventsResp",
+		"event": {
+			"__type__": "EventData",
+			"uuid": "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			...
+		},
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) mergeEvents(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData MergeEventsReq
+		resp    MergeEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = MergeEventsResp{
+			Common: Common{Type: MergeEventsRespName},
+			Event:  nil,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if msgData.Primary == "" || msgData.Secondary == "" {
+		responseWithError(w, http.StatusBadRequest, "Both primary and secondary are required.")
+		return
+	}
+
+	if msgData.Primary == msgData.Secondary {
+		responseWithError(w, http.StatusBadRequest, "primary and secondary have to differ.")
+		return
+	}
+
+	merged, err := srv.repo(r).MergeEvents(msgData.Primary, msgData.Secondary)
+	if errors.Is(err, ErrEventNotFound) {
+		responseWithError(w, http.StatusNotFound, err.Error())
+		return
+	} else if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	srv.recordAudit(r, AuditActionUpdate, merged.UUID)
+	srv.recordAudit(r, AuditActionDelete, msgData.Secondary)
+
+	srv.logger(r).Info("Merged event ", msgData.Secondary, " into ", merged.UUID)
+
+	resp = MergeEventsResp{
+		Common: Common{Type: MergeEventsRespName},
+		Event:  merged,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+importFromURL handles a request to the /api/v1/importFromURL endpoint.
+Fetches XML export or iCalendar feed from the URL and upserts its events
+in a single transaction. Feed is limited by ImportURLTimeout and cfg.MaxImportBytes,
+larger one is refused with 413. URLs pointing to internal addresses are refused
+with 400, feeds which can not be fetched or parsed are reported with 502.
+
+Example request:
+
+	POST /api/v1/importFromURL
+	{
+		"url": "https://calendar.example.com/export.ics",
+		"format": "ical"
+	}
+
+Example response:
+
+	{
+		"__type__": "ImportFromURLResp",
+		"received": 3,
+		"imported": 2,
+		"skipped": 0,
+		"failed": 1,
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) importFromURL(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		events  []*EventData
+		msgData ImportFromURLReq
+		resp    = ImportFromURLResp{Common: Common{Type: ImportFromURLRespName}}
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg}
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if msgData.Format != "xml" && msgData.Format != "ical" {
+		responseWithError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported format %q, expected xml or ical.", msgData.Format))
+		return
+	}
+
+	data, err := srv.fetchFeed(r.Context(), msgData.URL)
+	if errors.Is(err, ErrFeedURLNotAllowed) {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if errors.Is(err, ErrImportTooLarge) {
+		srv.logger(r).Warning("Refused feed of ", msgData.URL, ". ", err)
+		responseWithError(w, http.StatusRequestEntityTooLarge, err.Error())
+
+		return
+	} else if err != nil {
+		srv.logger(r).Warning(err)
+		responseWithError(w, http.StatusBadGateway, err.Error())
+
+		return
+	}
+
+	if msgData.Format == "xml" {
+		events, err = parseXMLFeed(data)
+	} else {
+		events, err = parseICalFeed(data, srv.cfg.TimeZone)
+	}
+
+	if err != nil {
+		responseWithError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	resp.Received = len(events)
+
+	owner := srv.requestUser(r)
+	for _, e := range events {
+		e.Owner = owner
+	}
+
+	errs, err := srv.repo(r).InsertEvents(events)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	for i := range events {
+		switch {
+		case errs[i] == nil:
+			resp.Imported++
+			srv.recordAudit(r, AuditActionInsert, events[i].UUID)
+		case errors.Is(errs[i], ErrDuplicateEvent):
+			resp.Skipped++
+		default:
+			resp.Failed++
+			srv.logger(r).Info("Failed to import event ", events[i].UUID, ": ", errs[i])
+		}
+	}
+
+	resp.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""}
+
+	srv.send(resp, w, r)
+}
+
+/*
+importStream handles a request to the /api/v1/importStream endpoint.
+Takes newline-delimited JSON (NDJSON) with one EventData object per line,
+upserts events in transaction batches of ImportStreamBatchSize and streams
+back one ImportStreamLineResp per non-empty line. Malformed lines are
+reported and do not abort the stream.
+
+Example request:
+
+	POST /api/v1/importStream
+	{"uuid": "e0b2dd0f43614138995beafa87b6356b", "title": "New event", ...}
+	{"uuid": "5bd8fa795fa04bf79c37dd1b9583709f", "title": "Other event", ...}
+
+Example response:
+
+	{"__type__": "ImportStreamLineResp", "line": 1, "uuid": "e0b2dd0f43614138995beafa87b6356b", "status": {"success": true, "message": ""}}
+	{"__type__": "ImportStreamLineResp", "line": 2, "uuid": "5bd8fa795fa04bf79c37dd1b9583709f", "status": {"success": true, "message": ""}}
+*/
+func (srv *HTTPRestServer) importStream(w http.ResponseWriter, r *http.Request) {
+	var (
+		batch   []*EventData
+		pending []ImportStreamLineResp
+		reader  = bufio.NewReader(r.Body)
+	)
+
+	w.Header().Set("Content-Type", NDJSONContentType)
+
+	err := validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	owner := srv.requestUser(r)
+
+	w.WriteHeader(http.StatusOK)
+
+	// flush stores collected batch and writes results of all pending lines.
+	flush := func() {
+		if len(batch) > 0 {
+			errs, err := srv.repo(r).InsertEvents(batch)
+
+			for i, j := 0, 0; i < len(pending); i++ {
+				if !pending[i].Status.Success {
+					continue
+				}
+
+				switch {
+				case err != nil:
+					pending[i].Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: err.Error()}
+				case errs[j] != nil:
+					pending[i].Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: errs[j].Error()}
+				}
+
+				j++
+			}
+
+			for i := range pending {
+				if pending[i].Status.Success {
+					srv.recordAudit(r, AuditActionInsert, pending[i].UUID)
+				}
+			}
+		}
+
+		for i := range pending {
+			line, err := srv.marshal(pending[i])
+			if err == nil {
+				_, err = w.Write(append(line, '\n'))
+			}
+
+			if err != nil {
+				srv.logger(r).Error("Writing data failed:", err)
+			}
+		}
+
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		batch = batch[:0]
+		pending = pending[:0]
+	}
+
+	for lineNo := 1; ; lineNo++ {
+		line, readErr := reader.ReadBytes('\n')
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			var event EventData
+
+			resp := ImportStreamLineResp{
+				Common: Common{Type: ImportStreamLineRespName},
+				Line:   lineNo,
+				Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+			}
+
+			if err := json.NewDecoder(bytes.NewReader(line)).Decode(&event); err != nil {
+				resp.Status = ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: err.Error()}
+			} else {
+				resp.UUID = event.UUID
+				event.Owner = owner
+				batch = append(batch, &event)
+			}
+
+			pending = append(pending, resp)
+		}
+
+		if len(batch) >= ImportStreamBatchSize {
+			flush()
+		}
+
+		if readErr != nil {
+			if readErr != io.EOF {
+				srv.logger(r).Error(readErr)
+			}
+
+			break
+		}
+	}
+
+	flush()
+}
+
+// acquireStream takes one of cfg.MaxStreamConns slots shared by event streams and
+// WebSockets, so long-lived connections can not exhaust file descriptors. When none
+// is free the client receives 503 and false is returned. Zero cap means no limit.
+func (srv *HTTPRestServer) acquireStream(w http.ResponseWriter, r *http.Request) bool {
+	open := srv.streams.Add(1)
+	if srv.cfg.MaxStreamConns == 0 || open <= int64(srv.cfg.MaxStreamConns) {
+		return true
+	}
+
+	srv.streams.Add(-1)
+	srv.logger(r).Warning("Stream connection refused, ", srv.cfg.MaxStreamConns, " connections are already open.")
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(EventStreamRetry.Seconds())))
+	srv.sendWithStatus(ErrorResp{
+		Common:    Common{Type: ErrorRespName},
+		RequestID: requestID(r),
+		Status:    ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: "Too many stream connections, try again later."},
+	}, http.StatusServiceUnavailable, w, r)
+
+	return false
+}
+
+// releaseStream frees the slot taken by acquireStream once the connection ends.
+func (srv *HTTPRestServer) releaseStream() {
+	srv.streams.Add(-1)
+}
+
+/*
+streamEvents handles a request to the /api/v1/events/stream endpoint.
+Keeps the connection open and pushes Server-Sent Events with every inserted,
+updated or deleted event. Token is validated once, when client connects.
+Keep-alive comments are sent every EventStreamKeepAlive, so proxies
+do not close idle connection.
+
+Example request:
+
+	GET /api/v1/events/stream
+
+Example response:
+
+	Content-Type: text/event-stream
+
+	retry: 3000
+	: connected
+
+	event: change
+	data: {"uuid": "e0b2dd0f43614138995beafa87b6356b", "action": "insert"}
+
+	: keep-alive
+*/
+func (srv *HTTPRestServer) streamEvents(w http.ResponseWriter, r *http.Request) {
+	err := validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	if !srv.acquireStream(w, r) {
+		return
+	}
+
+	defer srv.releaseStream()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		srv.sendWithStatus(ErrorResp{
+			Common:    Common{Type: ErrorRespName},
+			RequestID: requestID(r),
+			Status:    ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: "Streaming is not supported."},
+		}, http.StatusInternalServerError, w, r)
+
+		return
+	}
+
+	changes := srv.bus.Subscribe()
+	defer srv.bus.Unsubscribe(changes)
+
+	keepAlive := time.NewTicker(EventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	// write sends a chunk of the stream, false means client is gone.
+	write := func(chunk string) bool {
+		extendWriteDeadline(r, 2*EventStreamKeepAlive)
+
+		if _, err := io.WriteString(w, chunk); err != nil {
+			srv.logger(r).Info("Event stream closed. ", err)
+			return false
+		}
+
+		flusher.Flush()
+
+		return true
+	}
+
+	w.Header().Set("Content-Type", EventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	if !write(fmt.Sprintf("retry: %d\n: connected\n\n", EventStreamRetry.Milliseconds())) {
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-srv.stopping:
+			return
+		case <-keepAlive.C:
+			if !write(": keep-alive\n\n") {
+				return
+			}
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+
+			data, err := srv.marshal(change)
+			if err != nil {
+				srv.logger(r).Error("Marshaling data failed:", err)
+				continue
+			}
+
+			if !write(fmt.Sprintf("event: change\ndata: %s\n\n", data)) {
+				return
+			}
+		}
+	}
+}
+
+/*
+websocket handles a request to the /api/v1/ws endpoint.
+Upgrades connection to WebSocket, which pushes EventChangeMsg with every inserted,
+updated or deleted event and accepts AddEventReq messages, each answered with
+AddEventResp. Browsers can not set headers, so token may be passed as `token`
+query parameter. Token is validated once, when client connects. Messages larger
+than WebSocketMaxMessageSize or sent more often than WebSocketMaxMessageRate
+per second close the connection, so do malformed frames (1002). Server pings every
+EventStreamKeepAlive, client silent for WebSocketReadTimeout is disconnected.
+Browsers may connect from the same origin only.
+
+Example request:
+
+	GET /api/v1/ws?token=eyJhbGciOiJIUzUxMiIsInR5cCI6IkpXVCJ9...
+	Connection: Upgrade
+	Upgrade: websocket
+
+Example messages:
+
+	-> {"event": {"uuid": "e0b2dd0f43614138995beafa87b6356b", "title": "New event", ...}}
+	<- {"__type__": "AddEventResp", "status": {"__type__": "ResponseStatus", "success": true, "message": ""}}
+	<- {"__type__": "EventChangeMsg", "uuid": "e0b2dd0f43614138995beafa87b6356b", "action": "insert"}
+*/
+func (srv *HTTPRestServer) websocket(w http.ResponseWriter, r *http.Request) {
+	if token := r.URL.Query().Get("token"); token != "" && r.Header.Get("Token") == "" {
+		r.Header.Set("Token", token)
+	}
+
+	err := validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	if !srv.acquireStream(w, r) {
+		return
+	}
+
+	defer srv.releaseStream()
+
+	conn, err := srv.upgradeWebSocket(w, r, WebSocketReadTimeout)
+	if err != nil {
+		srv.logger(r).Warning("WebSocket handshake failed. ", err)
+		return
+	}
+
+	changes := srv.bus.Subscribe()
+	defer srv.bus.Unsubscribe(changes)
+
+	// send writes JSON message, false means client is gone.
+	send := func(msg any) bool {
+		data, err := srv.marshal(msg)
+		if err == nil {
+			err = conn.WriteText(data)
+		}
+
+		if err != nil {
+			srv.logger(r).Info("WebSocket closed. ", err)
+			return false
+		}
+
+		return true
+	}
+
+	/* Close code the server still has to send, zero when the library already closed the connection */
+	closing := make(chan int, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		windowStart, received := srv.clock.Now(), 0
+
+		for {
+			message, err := conn.ReadMessage()
+			if err != nil {
+				srv.logger(r).Info("WebSocket read ended. ", err)
+				closing <- 0
+
+				return
+			}
+
+			if now := srv.clock.Now(); now.Sub(windowStart) >= time.Second {
+				windowStart, received = now, 0
+			}
+
+			if received++; received > WebSocketMaxMessageRate {
+				closing <- websocket.ClosePolicyViolation
+				return
+			}
+
+			var msgData AddEventReq
+
+			if err = json.Unmarshal(message, &msgData); err != nil {
+				send(AddEventResp{
+					Common: Common{Type: AddEventRespName},
+					Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: fmt.Sprintf("malformed message: %s", err)},
+				})
+
+				continue
+			}
+
+			msgData.applyDefaultReminder(srv.cfg.ReminderDays)
+
+			resp, _ := srv.addEvent(r, &msgData.Event, false)
+			send(resp)
+		}
+	}()
+
+	keepAlive := time.NewTicker(EventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	code := websocket.CloseNormalClosure
+
+loop:
+	for {
+		select {
+		case <-done:
+			code = <-closing
+			break loop
+		case <-srv.stopping:
+			code = websocket.CloseGoingAway
+			break loop
+		case <-keepAlive.C:
+			if err = conn.Ping(); err != nil {
+				break loop
+			}
+		case change, ok := <-changes:
+			if !ok || !send(EventChangeMsg{Common: Common{Type: EventChangeMsgName}, EventChange: change}) {
+				break loop
+			}
+		}
+	}
+
+	/* Closing the connection stops the reading goroutine as well */
+	conn.Close(code)
+	<-done
+}
+
+/* getEventsWithinTimeRange handles a request to the /api/v1/getEventsWithinTimeRange endpoint.
+ * Takes GetEventsReq as JSON, retrieves events within the specified time range and returns
+ * response with events or error message.
+ *
+ * Example request:
+ *
+ *	POST /api/v1/getEventsWithinTimeRange
+ *	{
+ *		"start": "2024-02-13T12:00:00Z",
+ *		"end": "2024-02-13T12:00:00Z"
+ *	}
+ *
+ * Example response:
+ *
+ *	{
+ *		"common": {
+ *			"type": "GetEventsResp"
+ *		},
  *		"status": {
  *			"type": "ResponseStatus",
  *			"success": true,
@@ -380,66 +2929,1070 @@ func (srv *HTTPRestServer) insertEvent(w http.ResponseWriter, r *http.Request) {
 func (srv *HTTPRestServer) getEventsWithinTimeRange(w http.ResponseWriter, r *http.Request) {
 	/* Get all events within selected time range*/
 	var (
-		err  error
-		resp GetEventsResp
+		err  error
+		resp GetEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetEventsResp{Common: Common{Type: GetEventsRespName},
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+			Events: nil,
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	var msgData GetEventsReq
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	startUnix, err := dateTimeToUnix(&msgData.Start, srv.cfg.TimeZone)
+	if err != nil {
+		responseWithError(w, http.StatusInternalServerError, "Start data error.")
+
+		return
+	}
+
+	endUnix, err := dateTimeToUnix(&msgData.End, srv.cfg.TimeZone)
+	if err != nil {
+		responseWithError(w, http.StatusInternalServerError, "End data error.")
+
+		return
+	}
+
+	if startUnix > endUnix {
+		responseWithError(w, http.StatusBadRequest, InvertedTimeRangeMsg)
+
+		return
+	}
+
+	/* Bound the range before querying so a single request cannot load the whole table */
+	if rangeExceedsDays(startUnix, endUnix, srv.cfg.MaxRangeDays) {
+		responseWithError(w, http.StatusBadRequest, fmt.Sprintf(RangeTooLargeMsg, srv.cfg.MaxRangeDays))
+
+		return
+	}
+
+	result, err := srv.repo(r).GetEventsByTimeRange(startUnix, endUnix)
+	if err != nil {
+		srv.logger(r).Warning(err)
+	}
+
+	resp = GetEventsResp{
+		Common: Common{Type: GetEventsRespName},
+		Status: ResponseStatus{
+			Common:  Common{Type: ResponseStatusName},
+			Success: false, Message: "",
+		},
+		Events: result,
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+getEventsByShortcut handles a request to the /api/v1/getEventsByShortcut endpoint.
+Retrieves events within a range named by a shortcut: "today", "tomorrow",
+"this_week" (Monday to Sunday) or "this_month". Bounds are computed in configured
+time zone, so clients do not have to repeat the calendar math. Unknown shortcuts
+are rejected with 400.
+
+Example request:
+
+	POST /api/v1/getEventsByShortcut
+	{
+		"shortcut": "this_week"
+	}
+
+Example response:
+
+	{
+		"__type__": "GetEventsResp",
+		"events": [],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) getEventsByShortcut(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData GetEventsByShortcutReq
+		resp    GetEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetEventsResp{
+			Common: Common{Type: GetEventsRespName},
+			Events: nil,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	loc, err := srv.cfg.Location()
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, "Time zone error.")
+
+		return
+	}
+
+	start, end, err := shortcutRange(msgData.Shortcut, srv.clock.Now(), loc)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := srv.repo(r).GetEventsByTimeRange(start, end)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = GetEventsResp{
+		Common: Common{Type: GetEventsRespName},
+		Events: result,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+getEventsByCategory handles a request to the /api/v1/getEventsByCategory endpoint.
+Returns events with provided category, ordered by start. Empty category
+returns events without category.
+
+Example request:
+
+	POST /api/v1/getEventsByCategory
+	{
+		"category": "work"
+	}
+
+Example response:
+
+	{
+		"__type__": "GetEventsResp",
+		"events": [...],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) getEventsByCategory(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData GetEventsByCategoryReq
+		resp    GetEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetEventsResp{
+			Common: Common{Type: GetEventsRespName},
+			Events: nil,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := srv.repo(r).GetEventsByCategory(msgData.Category)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = GetEventsResp{
+		Common: Common{Type: GetEventsRespName},
+		Events: result,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+getEventsCreatedBetween handles a request to the /api/v1/getEventsCreatedBetween endpoint.
+Returns events added to the system within provided time range, ordered by creation,
+for activity reporting. Event start and end are not taken into account.
+Range is bounded by cfg.MaxRangeDays like getEventsWithinTimeRange.
+
+Example request:
+
+	POST /api/v1/getEventsCreatedBetween
+	{
+		"start": {"year": 2024, "month": 2, "day": 1, "hour": 0, "minute": 0},
+		"end": {"year": 2024, "month": 2, "day": 29, "hour": 23, "minute": 59}
+	}
+
+Example response:
+
+	{
+		"__type__": "GetEventsResp",
+		"events": [...],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) getEventsCreatedBetween(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData GetEventsReq
+		resp    GetEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetEventsResp{
+			Common: Common{Type: GetEventsRespName},
+			Events: nil,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	startUnix, err := dateTimeToUnix(&msgData.Start, srv.cfg.TimeZone)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, "Start data error.")
+		return
+	}
+
+	endUnix, err := dateTimeToUnix(&msgData.End, srv.cfg.TimeZone)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, "End data error.")
+		return
+	}
+
+	if startUnix > endUnix {
+		responseWithError(w, http.StatusBadRequest, InvertedTimeRangeMsg)
+		return
+	}
+
+	if rangeExceedsDays(startUnix, endUnix, srv.cfg.MaxRangeDays) {
+		responseWithError(w, http.StatusBadRequest, fmt.Sprintf(RangeTooLargeMsg, srv.cfg.MaxRangeDays))
+		return
+	}
+
+	result, err := srv.repo(r).GetEventsCreatedBetween(startUnix, endUnix)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = GetEventsResp{
+		Common: Common{Type: GetEventsRespName},
+		Events: result,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+getEventsByTitle handles a request to the /api/v1/getEventsByTitle endpoint.
+Returns events which title equals provided one exactly, ordered by start.
+Surrounding whitespace is ignored, as it is never stored. Empty title is rejected with 400.
+
+Example request:
+
+	POST /api/v1/getEventsByTitle
+	{
+		"title": "Ur. Mr X"
+	}
+
+Example response:
+
+	{
+		"__type__": "GetEventsResp",
+		"events": [...],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) getEventsByTitle(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData GetEventsByTitleReq
+		resp    GetEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetEventsResp{
+			Common: Common{Type: GetEventsRespName},
+			Events: nil,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if strings.TrimSpace(msgData.Title) == "" {
+		responseWithError(w, http.StatusBadRequest, "Title is required.")
+		return
+	}
+
+	result, err := srv.repo(r).GetEventsByTitle(msgData.Title)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = GetEventsResp{
+		Common: Common{Type: GetEventsRespName},
+		Events: result,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+countEvents handles a request to the /api/v1/countEvents endpoint.
+Returns number of events matching provided filters, built by the same WHERE clause
+as filtered listing, so clients can show totals or plan pagination without fetching.
+Events overlapping `start`..`end` are counted, bounds included, `text` matches part
+of the title. Omitted flags, bounds and empty source or text do not filter.
+
+Example request:
+
+	POST /api/v1/countEvents
+	{
+		"done": false,
+		"source": "WEB",
+		"start": {"__type__": "DateTime", "year": 2021, "month": 11, "day": 1, "hour": 0, "minute": 0},
+		"text": "meeting"
+	}
+
+Example response:
+
+	{
+		"__type__": "CountEventsResp",
+		"count": 42,
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) countEvents(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData CountEventsReq
+		resp    CountEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = CountEventsResp{
+			Common: Common{Type: CountEventsRespName},
+			Count:  0,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opts := EventQueryOptions{
+		Done:      msgData.Done,
+		Important: msgData.Important,
+		Urgent:    msgData.Urgent,
+		Source:    msgData.Source,
+		Text:      msgData.Text,
+	}
+
+	for _, bound := range []struct {
+		value *DateTime
+		unix  **int64
+	}{
+		{msgData.Start, &opts.Start},
+		{msgData.End, &opts.End},
+	} {
+		if bound.value == nil {
+			continue
+		}
+
+		unix, err := dateTimeToUnix(bound.value, srv.cfg.TimeZone)
+		if err != nil {
+			responseWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		*bound.unix = &unix
+	}
+
+	if opts.Start != nil && opts.End != nil && *opts.Start > *opts.End {
+		responseWithError(w, http.StatusBadRequest, InvertedTimeRangeMsg)
+		return
+	}
+
+	count, err := srv.repo(r).CountEventsFiltered(opts)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = CountEventsResp{
+		Common: Common{Type: CountEventsRespName},
+		Count:  count,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+getLocations handles a request to the /api/v1/getLocations endpoint.
+Returns distinct addresses already used by events, for autocompletion of new ones.
+Takes optional GetLocationsReq: `prefix` filters addresses case-insensitively and
+`limit` defaults to LocationsDefaultLimit and may not exceed LocationsMaxLimit.
+
+Example request:
+
+	POST /api/v1/getLocations
+	{
+		"prefix": "War",
+		"limit": 10
+	}
+
+Example response:
+
+	{
+		"__type__": "GetLocationsResp",
+		"locations": ["Warszawa, ul. Okrężna 26", "Warszawa, ul. Prosta 1"],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) getLocations(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData GetLocationsReq
+		resp    GetLocationsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetLocationsResp{
+			Common: Common{Type: GetLocationsRespName},
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil && !errors.Is(err, ErrEmptyBody) {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if msgData.Limit == 0 {
+		msgData.Limit = LocationsDefaultLimit
+	} else if msgData.Limit < 1 || msgData.Limit > LocationsMaxLimit {
+		responseWithError(w, http.StatusBadRequest,
+			fmt.Sprintf("Limit must be a number between 1 and %d.", LocationsMaxLimit))
+
+		return
+	}
+
+	locations, err := srv.repo(r).GetLocations(strings.TrimSpace(msgData.Prefix), msgData.Limit)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	/* Clients get empty list rather than null when nothing matches */
+	if locations == nil {
+		locations = []string{}
+	}
+
+	resp = GetLocationsResp{
+		Common:    Common{Type: GetLocationsRespName},
+		Locations: locations,
+		Status:    ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+getEventsByPriority handles a request to the /api/v1/getEventsByPriority endpoint.
+Returns events grouped into the four quadrants of the Eisenhower matrix, based on
+`important` and `urgent` flags. Takes optional GetEventsByPriorityReq limiting
+events to a time range, without body all events are grouped.
+
+Example request:
+
+	POST /api/v1/getEventsByPriority
+	{
+		"start": {"year": 2024, "month": 3, "day": 1, "hour": 0, "minute": 0},
+		"end": {"year": 2024, "month": 3, "day": 31, "hour": 23, "minute": 59}
+	}
+
+Example response:
+
+	{
+		"__type__": "GetEventsByPriorityResp",
+		"important_urgent": [...],
+		"important_not_urgent": [...],
+		"not_important_urgent": [...],
+		"not_important_not_urgent": [...],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) getEventsByPriority(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData GetEventsByPriorityReq
+		resp    GetEventsByPriorityResp
+		start   int64 = math.MinInt64
+		end     int64 = math.MaxInt64
 	)
 
-	responseWithError := func(w http.ResponseWriter, msg string) {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Header().Set("Content-Type", "application/json")
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetEventsByPriorityResp{
+			Common: Common{Type: PriorityEventsRespName},
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
 
-		resp = GetEventsResp{Common: Common{Type: GetEventsRespName},
-			Status: ResponseStatus{Common: Common{ResponseStatusName}, Success: false, Message: msg},
-			Events: nil,
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil && !errors.Is(err, ErrEmptyBody) {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if msgData.Start != nil {
+		start, err = dateTimeToUnix(msgData.Start, srv.cfg.TimeZone)
+		if err != nil {
+			responseWithError(w, http.StatusBadRequest, "Start data error.")
+			return
 		}
+	}
 
-		srv.send(resp, w, r)
+	if msgData.End != nil {
+		end, err = dateTimeToUnix(msgData.End, srv.cfg.TimeZone)
+		if err != nil {
+			responseWithError(w, http.StatusBadRequest, "End data error.")
+			return
+		}
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
+	if start > end {
+		responseWithError(w, http.StatusBadRequest, InvertedTimeRangeMsg)
+		return
+	}
+
+	resp = GetEventsByPriorityResp{
+		Common: Common{Type: PriorityEventsRespName},
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	for _, quadrant := range []struct {
+		important, urgent bool
+		events            *[]EventData
+	}{
+		{true, true, &resp.ImportantUrgent},
+		{true, false, &resp.ImportantNotUrgent},
+		{false, true, &resp.NotImportantUrgent},
+		{false, false, &resp.NotImportantNotUrgent},
+	} {
+		*quadrant.events, err = srv.repo(r).GetEventsByPriority(quadrant.important, quadrant.urgent, start, end)
+		if err != nil {
+			srv.logger(r).Error(err)
+			responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+			return
+		}
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+findEventsByUUIDPrefix handles a request to the /api/v1/findEventsByUuidPrefix endpoint.
+Debugging helper returning all events which UUID starts with provided prefix.
+Prefixes shorter than configured minimum length are rejected with 400.
+
+Example request:
+
+	POST /api/v1/findEventsByUuidPrefix
+	{
+		"prefix": "e0b2"
+	}
+
+Example response:
+
+	{
+		"__type__": "GetEventsResp",
+		"events": [...],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) findEventsByUUIDPrefix(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData FindEventsByUUIDPrefixReq
+		resp    GetEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetEventsResp{
+			Common: Common{Type: GetEventsRespName},
+			Events: nil,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
 
-	err = validateJWT(w, r)
+	err = validateJWT(srv.cfg, srv.clock, w, r)
 	if err != nil {
 		srv.invalidTokenResponse(w, r, err)
 		return
 	}
 
-	var msgData GetEventsReq
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(msgData.Prefix) < srv.cfg.UUIDPrefixMinLength {
+		responseWithError(w, http.StatusBadRequest,
+			fmt.Sprintf("Prefix must be at least %d characters long.", srv.cfg.UUIDPrefixMinLength))
+
+		return
+	}
+
+	result, err := srv.repo(r).FindEventsByUUIDPrefix(msgData.Prefix)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = GetEventsResp{
+		Common: Common{Type: GetEventsRespName},
+		Events: result,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
 
-	err = json.NewDecoder(r.Body).Decode(&msgData)
-	if err == io.EOF || err != nil {
-		responseWithError(w, "Missing body.")
+/*
+getOverlappingEvents handles a request to the /api/v1/getOverlappingEvents endpoint.
+Returns events overlapping the event with provided UUID, ordered by start.
+The event itself is not included. Returns 404 if event does not exist.
+
+Example request:
+
+	POST /api/v1/getOverlappingEvents
+	{
+		"uuid": "e0b2dd0f43614138995beafa87b6356b"
+	}
+
+Example response:
+
+	{
+		"__type__": "GetEventsResp",
+		"events": [...],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) getOverlappingEvents(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData GetOverlappingEventsReq
+		resp    GetEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = GetEventsResp{
+			Common: Common{Type: GetEventsRespName},
+			Events: nil,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
 
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
 		return
 	}
 
-	startUnix, err := dateTimeToUnix(&msgData.Start)
+	err = decodeBody(r, &msgData)
 	if err != nil {
-		responseWithError(w, "Start data error.")
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	event, err := srv.repo(r).GetEventByUUID(msgData.UUID)
+	if errors.Is(err, ErrEventNotFound) {
+		responseWithError(w, http.StatusNotFound, fmt.Sprintf("Event %s does not exist.", msgData.UUID))
+		return
+	} else if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
 
 		return
 	}
 
-	endUnix, err := dateTimeToUnix(&msgData.End)
+	start, err := dateTimeToUnix(&event.Start, srv.cfg.TimeZone)
 	if err != nil {
-		responseWithError(w, "End data error.")
+		responseWithError(w, http.StatusInternalServerError, "Start data error.")
+		return
+	}
 
+	end, err := dateTimeToUnix(&event.End, srv.cfg.TimeZone)
+	if err != nil {
+		responseWithError(w, http.StatusInternalServerError, "End data error.")
 		return
 	}
 
-	result, err := srv.db.GetEventsByTimeRange(startUnix, endUnix)
+	result, err := srv.repo(r).FindOverlapping(start, end, event.UUID)
 	if err != nil {
-		srv.log.Warning(err)
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
 	}
 
 	resp = GetEventsResp{
 		Common: Common{Type: GetEventsRespName},
-		Status: ResponseStatus{
-			Common:  Common{ResponseStatusName},
-			Success: false, Message: "",
-		},
 		Events: result,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+diffEvents handles a request to the /api/v1/diffEvents endpoint.
+Takes two time ranges and returns events present in only one of them,
+matched by UUID, together with their counts.
+
+Example request:
+
+	POST /api/v1/diffEvents
+	{
+		"first": {
+			"start": {"year": 2024, "month": 3, "day": 1, "hour": 0, "minute": 0},
+			"end": {"year": 2024, "month": 3, "day": 7, "hour": 23, "minute": 59}
+		},
+		"second": {
+			"start": {"year": 2024, "month": 3, "day": 5, "hour": 0, "minute": 0},
+			"end": {"year": 2024, "month": 3, "day": 14, "hour": 23, "minute": 59}
+		}
+	}
+
+Example response:
+
+	{
+		"__type__": "DiffEventsResp",
+		"only_in_first": [...],
+		"only_in_first_count": 2,
+		"only_in_second": [...],
+		"only_in_second_count": 1,
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) diffEvents(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		events  [2][]EventData
+		msgData DiffEventsReq
+		resp    DiffEventsResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = DiffEventsResp{
+			Common: Common{Type: DiffEventsRespName},
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for i, timeRange := range []GetEventsReq{msgData.First, msgData.Second} {
+		timeRange := timeRange
+
+		start, err := dateTimeToUnix(&timeRange.Start, srv.cfg.TimeZone)
+		if err != nil {
+			responseWithError(w, http.StatusInternalServerError, "Start data error.")
+			return
+		}
+
+		end, err := dateTimeToUnix(&timeRange.End, srv.cfg.TimeZone)
+		if err != nil {
+			responseWithError(w, http.StatusInternalServerError, "End data error.")
+			return
+		}
+
+		if start > end {
+			responseWithError(w, http.StatusBadRequest, InvertedTimeRangeMsg)
+			return
+		}
+
+		if rangeExceedsDays(start, end, srv.cfg.MaxRangeDays) {
+			responseWithError(w, http.StatusBadRequest, fmt.Sprintf(RangeTooLargeMsg, srv.cfg.MaxRangeDays))
+			return
+		}
+
+		events[i], err = srv.repo(r).GetEventsByTimeRange(start, end)
+		if err != nil {
+			srv.logger(r).Error(err)
+			responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+			return
+		}
+	}
+
+	onlyInFirst := eventsMissingFrom(events[0], events[1])
+	onlyInSecond := eventsMissingFrom(events[1], events[0])
+
+	resp = DiffEventsResp{
+		Common:            Common{Type: DiffEventsRespName},
+		OnlyInFirst:       onlyInFirst,
+		OnlyInFirstCount:  len(onlyInFirst),
+		OnlyInSecond:      onlyInSecond,
+		OnlyInSecondCount: len(onlyInSecond),
+		Status:            ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	srv.send(resp, w, r)
+}
+
+/*
+eventCountsByDay handles a request to the /api/v1/eventCountsByDay endpoint.
+Takes GetEventsReq and returns number of events overlapping every day of
+the range. Days are computed in configured time zone.
+
+Example request:
+
+	POST /api/v1/eventCountsByDay
+	{
+		"start": {"year": 2024, "month": 3, "day": 1, "hour": 0, "minute": 0},
+		"end": {"year": 2024, "month": 3, "day": 2, "hour": 23, "minute": 59}
+	}
+
+Example response:
+
+	{
+		"__type__": "EventCountsByDayResp",
+		"days": [
+			{"day": "2024-03-01", "count": 2},
+			{"day": "2024-03-02", "count": 0}
+		],
+		"status": {
+			"__type__": "ResponseStatus",
+			"success": true,
+			"message": ""
+		}
+	}
+*/
+func (srv *HTTPRestServer) eventCountsByDay(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		msgData GetEventsReq
+		resp    EventCountsByDayResp
+	)
+
+	responseWithError := func(w http.ResponseWriter, code int, msg string) {
+		resp = EventCountsByDayResp{
+			Common: Common{Type: EventCountsByDayRespName},
+			Days:   nil,
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: msg},
+		}
+
+		srv.sendWithStatus(resp, code, w, r)
+	}
+
+	err = validateJWT(srv.cfg, srv.clock, w, r)
+	if err != nil {
+		srv.invalidTokenResponse(w, r, err)
+		return
+	}
+
+	err = decodeBody(r, &msgData)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, err := dateTimeToUnix(&msgData.Start, srv.cfg.TimeZone)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, "Start data error.")
+		return
+	}
+
+	end, err := dateTimeToUnix(&msgData.End, srv.cfg.TimeZone)
+	if err != nil {
+		responseWithError(w, http.StatusBadRequest, "End data error.")
+		return
+	}
+
+	if start > end {
+		responseWithError(w, http.StatusBadRequest, InvertedTimeRangeMsg)
+		return
+	}
+
+	result, err := srv.repo(r).CountEventsByDay(start, end)
+	if err != nil {
+		srv.logger(r).Error(err)
+		responseWithError(w, http.StatusInternalServerError, fmt.Sprintf("%s", err))
+
+		return
+	}
+
+	resp = EventCountsByDayResp{
+		Common: Common{Type: EventCountsByDayRespName},
+		Days:   result,
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
 	}
 
 	srv.send(resp, w, r)
@@ -452,21 +4005,27 @@ func (srv *HTTPRestServer) killserver(w http.ResponseWriter, r *http.Request) {
 		response KillResp
 	)
 
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := decodeBody(r, &request)
 	if err != nil {
-		srv.log.Error(err)
+		srv.logger(r).Error(err)
+
+		response = KillResp{
+			Common: Common{Type: KillRespName},
+			Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: err.Error()},
+		}
+
+		srv.sendWithStatus(response, http.StatusBadRequest, w, r)
+
+		return
 	}
 
 	if request.Payload == srv.deadlyPackage {
-		srv.log.Critical("Received external kill signal.")
+		srv.logger(r).Critical("Received external kill signal.")
 
 		response = KillResp{
 			Common: Common{Type: KillRespName},
 			Status: ResponseStatus{
-				Common:  Common{ResponseStatusName},
+				Common:  Common{Type: ResponseStatusName},
 				Success: true,
 				Message: "Server will shutdown in 2 seconds!",
 			},
@@ -474,16 +4033,16 @@ func (srv *HTTPRestServer) killserver(w http.ResponseWriter, r *http.Request) {
 
 		srv.send(response, w, r)
 
-		srv.log.Critical("Received external kill signal.")
+		srv.logger(r).Critical("Received external kill signal.")
 		time.Sleep(GracefulShutdownTimeout)
 		srv.sigs <- syscall.SIGINT
 	} else {
-		srv.log.Error("Deadly package error.")
+		srv.logger(r).Error("Deadly package error.")
 
 		response = KillResp{
 			Common: Common{Type: KillRespName},
 			Status: ResponseStatus{
-				Common:  Common{ResponseStatusName},
+				Common:  Common{Type: ResponseStatusName},
 				Success: false,
 				Message: "Package error!",
 			},
@@ -0,0 +1,2380 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"eventshub/config"
+	logger "eventshub/logging"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestServer returns HTTPRestServer backed by fresh in-memory database
+// together with a valid token. Database is closed when the test finishes.
+func newTestServer(t *testing.T) (*HTTPRestServer, string) {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+
+	/* Every test gets own database, so events of other tests do not leak in */
+	db, err := sql.Open("sqlite3", inMemoryDatabaseFile(t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewSQLiteRepository(db, cfg)
+	if err = repo.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(repo.Shutdown)
+
+	token, err := createJWT(cfg, SystemClock{}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &HTTPRestServer{
+		cfg:   cfg,
+		clock: SystemClock{},
+		db:    repo,
+		log:   logger.NewConsoleLogger("TEST", logger.ERROR),
+	}
+
+	return srv, token
+}
+
+// stubRepo is embedded by DatabaseRepo stubs overriding a few methods. Methods a stub
+// does not override are served by the in-memory repository set by newStubServer.
+type stubRepo struct {
+	DatabaseRepo
+}
+
+func (s *stubRepo) setFallback(repo DatabaseRepo) {
+	s.DatabaseRepo = repo
+}
+
+// newStubServer returns server of newTestServer backed by stub repo, passed as pointer,
+// with its remaining methods served by the in-memory repository. Token is of user admin.
+func newStubServer(t *testing.T, repo DatabaseRepo) (*HTTPRestServer, string) {
+	t.Helper()
+
+	srv, token := newTestServer(t)
+
+	if stub, ok := repo.(interface{ setFallback(DatabaseRepo) }); ok {
+		stub.setFallback(srv.db)
+	}
+
+	srv.db = repo
+
+	return srv, token
+}
+
+// authorizedRequest returns request to target with body, carrying token in the Token header.
+func authorizedRequest(method, target, body, token string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Token", token)
+
+	return req
+}
+
+func Test_ImportStreamReportsMalformedLineAndContinues(t *testing.T) {
+	/* GIVEN an NDJSON stream with three events
+	 * AND one malformed line between them
+	 * WHEN it is posted to importStream
+	 * THEN one result per non-empty line should be streamed back
+	 * AND the malformed line should be reported as failed
+	 * AND all valid events should be stored in database
+	 */
+	srv, token := newTestServer(t)
+
+	body := strings.Join([]string{
+		`{"uuid": "a1000000000000000000000000000001", "title": "First", "start": {"year": 2024, "month": 1, "day": 1}, "end": {"year": 2024, "month": 1, "day": 1}}`,
+		`{"uuid": "a1000000000000000000000000000002", "title": "Second", "start": {"year": 2024, "month": 1, "day": 2}, "end": {"year": 2024, "month": 1, "day": 2}}`,
+		`{"uuid": "a1000000000000000000000000000003", "title": `,
+		``,
+		`{"uuid": "a1000000000000000000000000000004", "title": "Fourth", "start": {"year": 2024, "month": 1, "day": 4}, "end": {"year": 2024, "month": 1, "day": 4}}`,
+	}, "\n")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/importStream", strings.NewReader(body))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.importStream(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var results []ImportStreamLineResp
+
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var line ImportStreamLineResp
+
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		results = append(results, line)
+	}
+
+	assert.Len(t, results, 4)
+	assert.True(t, results[0].Status.Success)
+	assert.True(t, results[1].Status.Success)
+	assert.False(t, results[2].Status.Success)
+	assert.Equal(t, 3, results[2].Line)
+	assert.True(t, results[3].Status.Success)
+	assert.Equal(t, 5, results[3].Line)
+
+	events, err := srv.db.GetAllEvents()
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+}
+
+func Test_ResponsesCarryUTF8Charset(t *testing.T) {
+	/* GIVEN a server
+	 * WHEN version is requested with a valid token
+	 * AND without any token
+	 * THEN both responses should declare JSON content type with utf-8 charset
+	 * AND carry proper status codes
+	 */
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+
+	token, err := createJWT(cfg, SystemClock{}, "admin")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody)
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.serverVersionHandler(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody)
+
+	rec = httptest.NewRecorder()
+	srv.serverVersionHandler(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+}
+
+func Test_ResponsesCarryAPIVersion(t *testing.T) {
+	/* GIVEN a server
+	 * WHEN a token is validated and version is requested without token
+	 * THEN both responses should carry API version next to their type
+	 * AND messages without Common should be sent unchanged
+	 */
+	var body map[string]any
+
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+
+	token, err := createJWT(cfg, SystemClock{}, "admin")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/validateToken", http.NoBody)
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.validateToken(rec, req)
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, ValidateTokenRespName, body["__type__"])
+	assert.Equal(t, Version, body["__version__"])
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody)
+
+	rec = httptest.NewRecorder()
+	srv.serverVersionHandler(rec, req)
+
+	body = nil
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, InvalidTokenRespName, body["__type__"])
+	assert.Equal(t, Version, body["__version__"])
+
+	assert.Equal(t, TokenMsg{Token: "abc"}, withVersion(TokenMsg{Token: "abc"}))
+}
+
+func Test_InstanceNameInServerHeaderAndStatus(t *testing.T) {
+	/* GIVEN a server with configured instance name
+	 * WHEN status is requested through the middleware
+	 * THEN `Server` header should carry the instance name
+	 * AND status response should carry the instance name
+	 */
+	var resp GetStatusResp
+
+	srv, _ := newTestServer(t)
+	srv.cfg.InstanceName = "eventshub-test"
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status", http.NoBody)
+	rec := httptest.NewRecorder()
+
+	srv.serverHeaderMiddleware(http.HandlerFunc(srv.getStatus)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "eventshub-test", rec.Header().Get("Server"))
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "eventshub-test", resp.Instance)
+}
+
+func Test_StatusReportsChangesSince(t *testing.T) {
+	/* GIVEN three events modified at known times
+	 * WHEN status is requested with `since` parameter
+	 * THEN `changes` should count events modified at or after `since`
+	 * AND without `since` parameter `changes` should be omitted
+	 */
+	srv, _ := newTestServer(t)
+
+	for i, modified := range []int64{100, 200, 300} {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("b%031d", i)
+
+		_, err := srv.db.InsertEvent(&event)
+		assert.NoError(t, err)
+
+		_, err = srv.db.(*SQLiteRepository).handle().Exec("UPDATE events SET updated_at = ? WHERE uuid = ?;", modified, event.UUID)
+		assert.NoError(t, err)
+	}
+
+	for _, tc := range []struct {
+		query    string
+		expected *int64
+	}{
+		{"", nil},
+		{"?since=200", func() *int64 { v := int64(2); return &v }()},
+		{"?since=301", func() *int64 { v := int64(0); return &v }()},
+	} {
+		var resp GetStatusResp
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/status"+tc.query, http.NoBody)
+		rec := httptest.NewRecorder()
+
+		srv.getStatus(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, tc.expected, resp.Changes, tc.query)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/status?since=yesterday", http.NoBody)
+	rec := httptest.NewRecorder()
+
+	srv.getStatus(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func Test_GetOverlappingEvents(t *testing.T) {
+	/* GIVEN an event with one overlapping and one non-overlapping neighbour
+	 * WHEN overlapping events are requested for it
+	 * THEN only the overlapping neighbour should be returned
+	 * AND unknown UUID should result in 404
+	 */
+	var resp GetEventsResp
+
+	srv, token := newTestServer(t)
+
+	for _, e := range []EventData{
+		{UUID: "c1000000000000000000000000000001", Start: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 10}, End: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 12}},
+		{UUID: "c1000000000000000000000000000002", Start: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 11}, End: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 13}},
+		{UUID: "c1000000000000000000000000000003", Start: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 14}, End: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 15}},
+	} {
+		e := e
+
+		_, err := srv.db.InsertEvent(&e)
+		assert.NoError(t, err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/getOverlappingEvents",
+		strings.NewReader(`{"uuid": "c1000000000000000000000000000001"}`))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.getOverlappingEvents(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Events, 1)
+	assert.Equal(t, "c1000000000000000000000000000002", resp.Events[0].UUID)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/getOverlappingEvents",
+		strings.NewReader(`{"uuid": "c1000000000000000000000000000009"}`))
+	req.Header.Set("Token", token)
+
+	rec = httptest.NewRecorder()
+	srv.getOverlappingEvents(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func Test_EventCountsByDay(t *testing.T) {
+	/* GIVEN events spread over three days
+	 * AND one event spanning two days
+	 * WHEN counts are requested for a four days range
+	 * THEN every day of the range should be reported in order
+	 * AND every event should be counted on each day it overlaps
+	 */
+	var resp EventCountsByDayResp
+
+	srv, token := newTestServer(t)
+
+	for _, e := range []EventData{
+		{UUID: "d1000000000000000000000000000001", Start: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 9}, End: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 10}},
+		{UUID: "d1000000000000000000000000000002", Start: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 23, Minute: 30}, End: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 23, Minute: 45}},
+		{UUID: "d1000000000000000000000000000003", Start: DateTime{Year: 2024, Month: 3, Day: 2, Hour: 22}, End: DateTime{Year: 2024, Month: 3, Day: 3, Hour: 2}},
+		{UUID: "d1000000000000000000000000000004", Start: DateTime{Year: 2024, Month: 3, Day: 3, Hour: 12}, End: DateTime{Year: 2024, Month: 3, Day: 3, Hour: 13}},
+		{UUID: "d1000000000000000000000000000005", Start: DateTime{Year: 2024, Month: 3, Day: 5, Hour: 12}, End: DateTime{Year: 2024, Month: 3, Day: 5, Hour: 13}},
+	} {
+		e := e
+
+		_, err := srv.db.InsertEvent(&e)
+		assert.NoError(t, err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/eventCountsByDay", strings.NewReader(
+		`{"start": {"year": 2024, "month": 3, "day": 1}, "end": {"year": 2024, "month": 3, "day": 4, "hour": 23, "minute": 59}}`))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.eventCountsByDay(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, []DayCount{
+		{Day: "2024-03-01", Count: 2},
+		{Day: "2024-03-02", Count: 1},
+		{Day: "2024-03-03", Count: 2},
+		{Day: "2024-03-04", Count: 0},
+	}, resp.Days)
+}
+
+func Test_CountEventsFiltersByRangeAndText(t *testing.T) {
+	/* GIVEN stored events on different days
+	 * WHEN countEvents is requested with time range and text
+	 * THEN only events overlapping the range with matching title should be counted
+	 * AND inverted range should result in 400
+	 */
+	srv, token := newTestServer(t)
+
+	for _, e := range []EventData{
+		{UUID: "d2000000000000000000000000000001", Title: "Standup", Start: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 9}, End: DateTime{Year: 2024, Month: 3, Day: 1, Hour: 10}},
+		{UUID: "d2000000000000000000000000000002", Title: "Standup", Start: DateTime{Year: 2024, Month: 3, Day: 2, Hour: 9}, End: DateTime{Year: 2024, Month: 3, Day: 2, Hour: 10}},
+		{UUID: "d2000000000000000000000000000003", Title: "Lunch", Start: DateTime{Year: 2024, Month: 3, Day: 2, Hour: 12}, End: DateTime{Year: 2024, Month: 3, Day: 2, Hour: 13}},
+	} {
+		e := e
+
+		_, err := srv.db.InsertEvent(&e)
+		assert.NoError(t, err)
+	}
+
+	for body, expected := range map[string]int64{
+		`{}`: 3,
+		`{"start": {"year": 2024, "month": 3, "day": 1, "hour": 10}}`:                                                3,
+		`{"start": {"year": 2024, "month": 3, "day": 1, "hour": 10, "minute": 1}}`:                                   2,
+		`{"start": {"year": 2024, "month": 3, "day": 2}, "end": {"year": 2024, "month": 3, "day": 2, "hour": 9}}`:    1,
+		`{"start": {"year": 2024, "month": 3, "day": 1}, "end": {"year": 2024, "month": 3, "day": 3}, "text": "up"}`: 2,
+	} {
+		var resp CountEventsResp
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/countEvents", strings.NewReader(body))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.countEvents(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code, body)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, expected, resp.Count, body)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/countEvents", strings.NewReader(
+		`{"start": {"year": 2024, "month": 3, "day": 2}, "end": {"year": 2024, "month": 3, "day": 1}}`))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.countEvents(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), InvertedTimeRangeMsg)
+}
+
+func Test_PatchEventChangesOnlyPresentFields(t *testing.T) {
+	/* GIVEN a stored event
+	 * WHEN it is patched with only `done`
+	 * AND then with only `title`
+	 * THEN only the patched field should change each time
+	 * AND all other fields should be untouched
+	 * AND the checksum should change after every patch
+	 * AND patching unknown UUID should result in 404
+	 */
+	srv, token := newTestServer(t)
+
+	original := TestEvent1
+	original.UUID = "e1000000000000000000000000000001"
+
+	_, err := srv.db.InsertEvent(&original)
+	assert.NoError(t, err)
+
+	stored, err := srv.db.GetEventByUUID(original.UUID)
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		body   string
+		modify func(e *EventData)
+	}{
+		{`{"uuid": "e1000000000000000000000000000001", "done": true}`, func(e *EventData) { e.Done = true }},
+		{`{"uuid": "e1000000000000000000000000000001", "title": "Patched title"}`, func(e *EventData) { e.Title = "Patched title" }},
+	} {
+		var resp PatchEventResp
+
+		before := stored.Sha256()
+		expected := stored
+		tc.modify(&expected)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/patchEvent", strings.NewReader(tc.body))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.patchEvent(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code, tc.body)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.True(t, resp.Status.Success)
+
+		stored, err = srv.db.GetEventByUUID(original.UUID)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, stored, tc.body)
+		assert.NotEqual(t, before, stored.Sha256(), tc.body)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/patchEvent",
+		strings.NewReader(`{"uuid": "e1000000000000000000000000000009", "done": true}`))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.patchEvent(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func Test_DeleteEventsReportsRemovedCount(t *testing.T) {
+	/* GIVEN three stored events
+	 * WHEN two of them and two unknown UUIDs are deleted in bulk
+	 * THEN only the two existing events should be counted as deleted
+	 * AND only the two existing events should be audited as deleted
+	 * AND the remaining event should still be stored
+	 * AND a list exceeding the limit should be rejected with 400
+	 */
+	var resp DeleteEventsResp
+
+	srv, token := newTestServer(t)
+
+	for i := 1; i <= 3; i++ {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("f100000000000000000000000000000%d", i)
+
+		_, err := srv.db.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/deleteEvents", strings.NewReader(`{"uuids": [
+		"f1000000000000000000000000000001",
+		"f1000000000000000000000000000003",
+		"f1000000000000000000000000000008",
+		"f1000000000000000000000000000009"]}`))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.deleteEvents(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, int64(2), resp.Deleted)
+
+	entries, err := srv.db.GetAudit(10)
+	assert.NoError(t, err)
+
+	var audited []string
+
+	for _, entry := range entries {
+		if entry.Action == AuditActionDelete {
+			audited = append(audited, entry.UUID)
+		}
+	}
+
+	assert.ElementsMatch(t, []string{"f1000000000000000000000000000001", "f1000000000000000000000000000003"}, audited)
+
+	events, err := srv.db.GetAllEvents()
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "f1000000000000000000000000000002", events[0].UUID)
+
+	uuids, err := json.Marshal(DeleteEventsReq{UUIDs: make([]string, DeleteEventsMaxUUIDs+1)})
+	assert.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/deleteEvents", strings.NewReader(string(uuids)))
+	req.Header.Set("Token", token)
+
+	rec = httptest.NewRecorder()
+	srv.deleteEvents(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func Test_InsertEventIsAudited(t *testing.T) {
+	/* GIVEN a token issued for `auditor`
+	 * WHEN an event is inserted
+	 * THEN audit log should contain an insert entry of the event made by `auditor`
+	 * AND the entry should be returned by the audit endpoint
+	 * AND audit entries should not be modifiable
+	 */
+	var resp GetAuditResp
+
+	srv, _ := newTestServer(t)
+
+	token, err := createJWT(srv.cfg, SystemClock{}, "auditor")
+	assert.NoError(t, err)
+
+	body, err := json.Marshal(AddEventReq{Event: EventData{UUID: "a2000000000000000000000000000001", Title: "Audited"}})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/insertEvent", strings.NewReader(string(body)))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.insertEvent(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/audit?limit=10", http.NoBody)
+	req.Header.Set("Token", token)
+
+	rec = httptest.NewRecorder()
+	srv.getAudit(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Entries, 1)
+	assert.Equal(t, "auditor", resp.Entries[0].User)
+	assert.Equal(t, AuditActionInsert, resp.Entries[0].Action)
+	assert.Equal(t, "a2000000000000000000000000000001", resp.Entries[0].UUID)
+
+	_, err = srv.db.(*SQLiteRepository).handle().Exec("UPDATE audit SET username = 'someone';")
+	assert.Error(t, err)
+
+	_, err = srv.db.(*SQLiteRepository).handle().Exec("DELETE FROM audit;")
+	assert.Error(t, err)
+}
+
+func Test_InvertedTimeRangeIsRejected(t *testing.T) {
+	/* GIVEN a time range with start after end
+	 * WHEN events within the range are requested
+	 * THEN 400 should be returned with a message explaining the problem
+	 */
+	var resp GetEventsResp
+
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+
+	token, err := createJWT(cfg, SystemClock{}, "admin")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/getEventsWithinTimeRange", strings.NewReader(
+		`{"start": {"year": 2024, "month": 3, "day": 2}, "end": {"year": 2024, "month": 3, "day": 1}}`))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.getEventsWithinTimeRange(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.False(t, resp.Status.Success)
+	assert.Equal(t, InvertedTimeRangeMsg, resp.Status.Message)
+}
+
+func Test_TooWideTimeRangeIsRejected(t *testing.T) {
+	/* GIVEN a time range spanning more days than configured maximum
+	 * WHEN events within the range are requested
+	 * THEN 400 should be returned before the database is queried
+	 */
+	var resp GetEventsResp
+
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+	cfg.MaxRangeDays = 30
+
+	/* No database is set, reaching it would panic */
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+
+	token, err := createJWT(cfg, SystemClock{}, "admin")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/getEventsWithinTimeRange", strings.NewReader(
+		`{"start": {"year": 2024, "month": 1, "day": 1}, "end": {"year": 2024, "month": 2, "day": 1}}`))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.getEventsWithinTimeRange(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.False(t, resp.Status.Success)
+	assert.Equal(t, fmt.Sprintf(RangeTooLargeMsg, 30), resp.Status.Message)
+}
+
+func Test_GetEventsByPriorityGroupsQuadrants(t *testing.T) {
+	/* GIVEN one event in every Eisenhower matrix quadrant
+	 * AND one important and urgent event outside of requested range
+	 * WHEN events by priority are requested for the range
+	 * THEN every quadrant should contain its single event
+	 * AND without body all events should be grouped
+	 */
+	var resp GetEventsByPriorityResp
+
+	srv, token := newTestServer(t)
+
+	for i, e := range []EventData{
+		{UUID: "a3000000000000000000000000000001", Important: true, Urgent: true},
+		{UUID: "a3000000000000000000000000000002", Important: true, Urgent: false},
+		{UUID: "a3000000000000000000000000000003", Important: false, Urgent: true},
+		{UUID: "a3000000000000000000000000000004", Important: false, Urgent: false},
+		{UUID: "a3000000000000000000000000000005", Important: true, Urgent: true},
+	} {
+		e := e
+		day := int32(i + 1)
+
+		if i == 4 {
+			day = 20
+		}
+
+		e.Start = DateTime{Year: 2024, Month: 3, Day: day, Hour: 10}
+		e.End = DateTime{Year: 2024, Month: 3, Day: day, Hour: 11}
+
+		_, err := srv.db.InsertEvent(&e)
+		assert.NoError(t, err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/getEventsByPriority", strings.NewReader(
+		`{"start": {"year": 2024, "month": 3, "day": 1}, "end": {"year": 2024, "month": 3, "day": 10}}`))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.getEventsByPriority(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	for expected, quadrant := range map[string][]EventData{
+		"a3000000000000000000000000000001": resp.ImportantUrgent,
+		"a3000000000000000000000000000002": resp.ImportantNotUrgent,
+		"a3000000000000000000000000000003": resp.NotImportantUrgent,
+		"a3000000000000000000000000000004": resp.NotImportantNotUrgent,
+	} {
+		assert.Len(t, quadrant, 1, expected)
+
+		if len(quadrant) == 1 {
+			assert.Equal(t, expected, quadrant[0].UUID)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/getEventsByPriority", http.NoBody)
+	req.Header.Set("Token", token)
+
+	rec = httptest.NewRecorder()
+	srv.getEventsByPriority(rec, req)
+
+	resp = GetEventsByPriorityResp{}
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.ImportantUrgent, 2)
+}
+
+func Test_EmptyAndMalformedBodiesAreRejected(t *testing.T) {
+	/* GIVEN every endpoint requiring JSON body
+	 * WHEN it is called with empty body
+	 * AND with malformed body
+	 * THEN it should respond with 400 and JSON status describing the problem
+	 */
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+
+	token, err := createJWT(cfg, SystemClock{}, "admin")
+	assert.NoError(t, err)
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"login":                    srv.loginHandler,
+		"insertEvent":              srv.insertEvent,
+		"getEventCheckSum":         srv.getEventCheckSum,
+		"getEventDetail":           srv.getEventDetail,
+		"getEventsWithinTimeRange": srv.getEventsWithinTimeRange,
+		"getEventsByCategory":      srv.getEventsByCategory,
+		"findEventsByUuidPrefix":   srv.findEventsByUUIDPrefix,
+		"getOverlappingEvents":     srv.getOverlappingEvents,
+		"eventCountsByDay":         srv.eventCountsByDay,
+		"patchEvent":               srv.patchEvent,
+		"deleteEvents":             srv.deleteEvents,
+		"importFromURL":            srv.importFromURL,
+		"updateFlags":              srv.updateFlags,
+		"ki11s3rv3rn0w":            srv.killserver,
+	} {
+		for _, body := range []string{"", "{\"uuid\": "} {
+			var status ResponseStatus
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/"+name, strings.NewReader(body))
+			req.Header.Set("Token", token)
+
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, name)
+			assert.Equal(t, JSONContentType, rec.Header().Get("Content-Type"), name)
+
+			if name == "login" {
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status), name)
+			} else {
+				var resp struct {
+					Status ResponseStatus `json:"status"`
+				}
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), name)
+				status = resp.Status
+			}
+
+			assert.False(t, status.Success, name)
+			assert.NotEmpty(t, status.Message, name)
+		}
+	}
+}
+
+func Test_BackupSnapshotContainsEvents(t *testing.T) {
+	/* GIVEN a file-backed database with two events
+	 * WHEN admin requests a backup
+	 * THEN a snapshot should be streamed as attachment
+	 * AND reopened snapshot should contain both events
+	 * AND other users should be refused with 403
+	 */
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+	cfg.AdminUsername = "admin"
+	cfg.DatabaseFile = filepath.Join(t.TempDir(), "events.db")
+
+	db, err := sql.Open("sqlite3", cfg.DatabaseFile)
+	assert.NoError(t, err)
+
+	repo := NewSQLiteRepository(db, cfg)
+	assert.NoError(t, repo.Migrate())
+
+	t.Cleanup(repo.Shutdown)
+
+	for _, e := range []EventData{TestEvent1, TestEvent2} {
+		e := e
+
+		_, err = repo.InsertEvent(&e)
+		assert.NoError(t, err)
+	}
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, db: repo, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+
+	token, err := createJWT(cfg, SystemClock{}, "admin")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/backup", http.NoBody)
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.requireAdmin(srv.backup)(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, BackupContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "attachment")
+
+	snapshotFile := filepath.Join(t.TempDir(), "snapshot.db")
+	assert.NoError(t, os.WriteFile(snapshotFile, rec.Body.Bytes(), 0o600))
+
+	snapshotCfg := config.Default()
+	snapshotCfg.DatabaseFile = snapshotFile
+
+	snapshotDB, err := sql.Open("sqlite3", snapshotFile)
+	assert.NoError(t, err)
+
+	snapshot := NewSQLiteRepository(snapshotDB, snapshotCfg)
+	defer snapshot.Close()
+
+	events, err := snapshot.GetAllEvents()
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	token, err = createJWT(cfg, SystemClock{}, "someone")
+	assert.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/backup", http.NoBody)
+	req.Header.Set("Token", token)
+
+	rec = httptest.NewRecorder()
+	srv.requireAdmin(srv.backup)(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func Test_BackupOfInMemoryDatabaseIsNotSupported(t *testing.T) {
+	/* GIVEN an in-memory database
+	 * WHEN admin requests a backup
+	 * THEN 501 with a clear status should be returned
+	 */
+	var resp BackupResp
+
+	srv, token := newTestServer(t)
+	srv.cfg.AdminUsername = "admin"
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/backup", http.NoBody)
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.requireAdmin(srv.backup)(rec, req)
+
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.False(t, resp.Status.Success)
+	assert.Equal(t, ErrBackupNotSupported.Error(), resp.Status.Message)
+}
+
+func Test_ImportFromURLStoresXMLFeed(t *testing.T) {
+	/* GIVEN an XML feed served from an allowed local network
+	 * WHEN it is imported from URL
+	 * THEN valid events should be stored and the invalid one counted as failed
+	 * AND the same feed should be refused when local network is not allowed
+	 */
+	var resp ImportFromURLResp
+
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `<root>
+			<event ver="1" uuid="11111111111111111111111111111111" start="2031-03-01 10:00" end="2031-03-01 11:00"
+				remind="0" done="No" urgent="Yes" important="No" title="Dentist" address="Main St" info=""/>
+			<event ver="1" uuid="22222222222222222222222222222222" start="2031-03-02 10:00" end="2031-03-02 11:00"
+				remind="15" done="No" urgent="No" important="Yes" title="Review" address="" info=""/>
+			<event ver="1" uuid="33333333333333333333333333333333" start="2031-03-03 10:00" end="2031-03-03 11:00"
+				remind="0" done="No" urgent="No" important="No" title="`+strings.Repeat("x", 300)+`" address="" info=""/>
+		</root>`)
+	}))
+	defer feed.Close()
+
+	srv, token := newTestServer(t)
+
+	importFeed := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/importFromURL", strings.NewReader(
+			fmt.Sprintf(`{"url": %q, "format": "xml"}`, feed.URL)))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.importFromURL(rec, req)
+
+		return rec
+	}
+
+	rec := importFeed()
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Contains(t, resp.Status.Message, ErrFeedURLNotAllowed.Error())
+
+	srv.cfg.ImportAllowedNets = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+
+	rec = importFeed()
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Status.Success)
+	assert.Equal(t, 3, resp.Received)
+	assert.Equal(t, 2, resp.Imported)
+	assert.Equal(t, 1, resp.Failed)
+
+	stored, err := srv.db.GetEventByUUID("22222222222222222222222222222222")
+	assert.NoError(t, err)
+	assert.Equal(t, "Review", stored.Title)
+	assert.Equal(t, "XML", stored.Source)
+	assert.Equal(t, int32(15), stored.Reminder)
+}
+
+func Test_InsertEventBeyondQuotaIs403(t *testing.T) {
+	/* GIVEN a server limiting users to one event
+	 * WHEN a user inserts two new events
+	 * THEN the second one should be rejected with 403 and quota status
+	 */
+	var resp AddEventResp
+
+	srv, token := newTestServer(t)
+	srv.cfg.MaxEventsPerUser = 1
+
+	for i, expected := range []int{http.StatusOK, http.StatusForbidden} {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("f100000000000000000000000000000%d", i)
+
+		body, err := json.Marshal(AddEventReq{Event: event})
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/insertEvent", strings.NewReader(string(body)))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.insertEvent(rec, req)
+
+		assert.Equal(t, expected, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	}
+
+	assert.False(t, resp.Status.Success)
+	assert.Contains(t, resp.Status.Message, ErrQuotaExceeded.Error())
+}
+
+// largeBackupRepo is a DatabaseRepo producing backup snapshot of given size.
+type largeBackupRepo struct {
+	DatabaseRepo
+	size int
+}
+
+func (repo largeBackupRepo) Backup(path string) error {
+	return os.WriteFile(path, make([]byte, repo.size), 0o600)
+}
+
+func Test_SlowClientDownloadsWholeBackup(t *testing.T) {
+	/* GIVEN a server with short socket write timeout and a large backup snapshot
+	 * WHEN a client reads the download slower than the write timeout allows for the whole response
+	 * THEN it should still receive the whole snapshot, as every write renews the deadline
+	 */
+	const size = 32 << 20
+
+	cfg := config.Default()
+	cfg.WriteTimeout = 200 * time.Millisecond
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, db: largeBackupRepo{size: size},
+		log: logger.NewConsoleLogger("TEST", logger.CRITICAL)}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(srv.backup))
+	server.Config.WriteTimeout = cfg.WriteTimeout
+	server.Config.ConnContext = connContext
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	var (
+		received int
+		started  = time.Now()
+		buf      = make([]byte, 64<<10)
+	)
+
+	for {
+		n, err := resp.Body.Read(buf)
+		received += n
+
+		if err != nil {
+			assert.True(t, errors.Is(err, io.EOF), err)
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, size, received)
+	assert.Greater(t, time.Since(started), cfg.WriteTimeout)
+}
+
+func Test_UserSettingsDefaultToEmptyAndRoundTrip(t *testing.T) {
+	/* GIVEN a user who never stored settings
+	 * WHEN settings are requested
+	 * THEN an empty object should be returned
+	 * AND settings stored with PUT should be returned by subsequent GET
+	 * AND malformed settings should be rejected without overwriting stored ones
+	 */
+	var resp UserSettingsResp
+
+	srv, _ := newTestServer(t)
+
+	token, err := createJWT(srv.cfg, SystemClock{}, "settings-user")
+	assert.NoError(t, err)
+
+	request := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/settings", strings.NewReader(body))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.settings(rec, req)
+
+		return rec
+	}
+
+	rec := request(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.JSONEq(t, `{}`, string(resp.Settings))
+
+	rec = request(http.MethodPut, `{"theme": "dark", "weekStartsOn": "monday"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = request(http.MethodPut, `{"theme": `)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = request(http.MethodPut, `["not", "an", "object"]`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = request(http.MethodPut, `{"blob": "`+strings.Repeat("x", UserSettingsMaxSize)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = request(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.JSONEq(t, `{"theme": "dark", "weekStartsOn": "monday"}`, string(resp.Settings))
+}
+
+func Test_DiffEventsReturnsEventsOfOnlyOneRange(t *testing.T) {
+	/* GIVEN events in two overlapping ranges
+	 * AND an event in a range disjoint with both
+	 * WHEN ranges are diffed
+	 * THEN events present in both ranges should not be returned
+	 * AND remaining events should be returned with their counts
+	 * AND diffing disjoint ranges should return all events of each range
+	 */
+	var resp DiffEventsResp
+
+	srv, token := newTestServer(t)
+
+	for _, e := range []EventData{
+		{UUID: "d3000000000000000000000000000001", Start: DateTime{Year: 2031, Month: 3, Day: 2}, End: DateTime{Year: 2031, Month: 3, Day: 2, Hour: 1}},
+		{UUID: "d3000000000000000000000000000002", Start: DateTime{Year: 2031, Month: 3, Day: 6}, End: DateTime{Year: 2031, Month: 3, Day: 6, Hour: 1}},
+		{UUID: "d3000000000000000000000000000003", Start: DateTime{Year: 2031, Month: 3, Day: 10}, End: DateTime{Year: 2031, Month: 3, Day: 10, Hour: 1}},
+		{UUID: "d3000000000000000000000000000004", Start: DateTime{Year: 2031, Month: 5, Day: 1}, End: DateTime{Year: 2031, Month: 5, Day: 1, Hour: 1}},
+	} {
+		e := e
+
+		_, err := srv.db.InsertEvent(&e)
+		assert.NoError(t, err)
+	}
+
+	diff := func(first, second string) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/diffEvents",
+			strings.NewReader(`{"first": `+first+`, "second": `+second+`}`))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.diffEvents(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		resp = DiffEventsResp{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	}
+
+	march1To7 := `{"start": {"year": 2031, "month": 3, "day": 1}, "end": {"year": 2031, "month": 3, "day": 7}}`
+	march5To14 := `{"start": {"year": 2031, "month": 3, "day": 5}, "end": {"year": 2031, "month": 3, "day": 14}}`
+	may := `{"start": {"year": 2031, "month": 5, "day": 1}, "end": {"year": 2031, "month": 5, "day": 31}}`
+
+	diff(march1To7, march5To14)
+	assert.Equal(t, 1, resp.OnlyInFirstCount)
+	assert.Equal(t, "d3000000000000000000000000000001", resp.OnlyInFirst[0].UUID)
+	assert.Equal(t, 1, resp.OnlyInSecondCount)
+	assert.Equal(t, "d3000000000000000000000000000003", resp.OnlyInSecond[0].UUID)
+
+	diff(march5To14, may)
+	assert.Equal(t, 2, resp.OnlyInFirstCount)
+	assert.Equal(t, 1, resp.OnlyInSecondCount)
+	assert.Equal(t, "d3000000000000000000000000000004", resp.OnlyInSecond[0].UUID)
+}
+
+func Test_StreamEventsPushesInsertedEvent(t *testing.T) {
+	/* GIVEN a client connected to the event stream
+	 * WHEN an event is inserted after subscription
+	 * THEN a change message of the event should be streamed to the client
+	 * AND connections without token should be rejected
+	 */
+	srv, token := newTestServer(t)
+	srv.bus = NewEventBus()
+	srv.db.(*SQLiteRepository).bus = srv.bus
+
+	server := httptest.NewServer(http.HandlerFunc(srv.streamEvents))
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	res.Body.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, http.NoBody)
+	assert.NoError(t, err)
+	req.Header.Set("Token", token)
+
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, EventStreamContentType, res.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(res.Body)
+
+	/* Subscription is in place once the connected comment arrives */
+	for line := ""; line != ": connected\n"; {
+		if line, err = reader.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	event := EventData{UUID: "a5000000000000000000000000000001", Title: "Streamed"}
+	_, err = srv.db.InsertEvent(&event)
+	assert.NoError(t, err)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if data := strings.TrimPrefix(line, "data: "); data != line {
+			var change EventChange
+
+			assert.NoError(t, json.Unmarshal([]byte(data), &change))
+			assert.Equal(t, EventChange{UUID: event.UUID, Action: AuditActionInsert}, change)
+
+			break
+		}
+	}
+}
+
+func Test_StreamConnectionsAreCapped(t *testing.T) {
+	/* GIVEN a server allowing two stream connections
+	 * WHEN clients connect up to and beyond the cap
+	 * THEN connections up to the cap should be streamed
+	 * AND the one beyond it should be refused with 503
+	 * AND a slot should be available again once a client disconnects
+	 */
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+	cfg.MaxStreamConns = 2
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, bus: NewEventBus(),
+		log: logger.NewConsoleLogger("TEST", logger.CRITICAL)}
+
+	token, err := createJWT(cfg, SystemClock{}, "admin")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(srv.streamEvents))
+	defer server.Close()
+
+	connect := func() *http.Response {
+		req, err := http.NewRequest(http.MethodGet, server.URL, http.NoBody)
+		assert.NoError(t, err)
+		req.Header.Set("Token", token)
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	first, second := connect(), connect()
+	defer second.Body.Close()
+
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+	assert.Equal(t, http.StatusOK, second.StatusCode)
+
+	refused := connect()
+	assert.Equal(t, http.StatusServiceUnavailable, refused.StatusCode)
+	assert.NotEmpty(t, refused.Header.Get("Retry-After"))
+	refused.Body.Close()
+
+	/* Handler notices disconnect asynchronously */
+	first.Body.Close()
+	assert.Eventually(t, func() bool { return srv.streams.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	third := connect()
+	defer third.Body.Close()
+
+	assert.Equal(t, http.StatusOK, third.StatusCode)
+}
+
+func Test_RequireAdminAllowsOnlyAdmin(t *testing.T) {
+	/* GIVEN a handler wrapped with requireAdmin
+	 * WHEN it is requested by admin, other user and without token
+	 * THEN only admin should reach the handler
+	 * AND other user should be refused with 403 and missing token with 401
+	 */
+	t.Parallel()
+
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+	cfg.AdminUsername = "admin"
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+	handler := srv.requireAdmin(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tc := range []struct {
+		user string
+		code int
+	}{
+		{"admin", http.StatusOK},
+		{"someone", http.StatusForbidden},
+		{"", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/audit", http.NoBody)
+
+		if tc.user != "" {
+			token, err := createJWT(cfg, SystemClock{}, tc.user)
+			assert.NoError(t, err)
+			req.Header.Set("Token", token)
+		}
+
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		assert.Equal(t, tc.code, rec.Code, tc.user)
+	}
+}
+
+func Test_GetEventCheckSumOfMissingEventIs404(t *testing.T) {
+	/* GIVEN a server with one event
+	 * WHEN checksum of the event and of an unknown UUID is requested
+	 * THEN checksum of the event should be returned
+	 * AND 404 should be returned for the unknown UUID
+	 */
+	srv, token := newTestServer(t)
+
+	event := TestEvent1
+	event.UUID = "d1000000000000000000000000000001"
+
+	_, err := srv.db.InsertEvent(&event)
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		uuid string
+		code int
+	}{
+		{event.UUID, http.StatusOK},
+		{"d1000000000000000000000000000009", http.StatusNotFound},
+	} {
+		var resp GetEventCheckSumResp
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/getEventCheckSum", strings.NewReader(`{"uuid": "`+tc.uuid+`"}`))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.getEventCheckSum(rec, req)
+
+		assert.Equal(t, tc.code, rec.Code, tc.uuid)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, tc.code == http.StatusOK, resp.Status.Success, tc.uuid)
+	}
+}
+
+func Test_UpdateFlagsChangesOnlySelectedEvents(t *testing.T) {
+	/* GIVEN four stored events which are not done
+	 * WHEN `done` is set in bulk for two of them and an unknown UUID
+	 * THEN only the two existing events should be counted and marked done
+	 * AND only the two existing events should be audited as updated
+	 * AND other events and flags absent from the request should be untouched
+	 * AND request without any flag should be rejected with 400
+	 */
+	var resp UpdateFlagsResp
+
+	srv, token := newTestServer(t)
+
+	for i := 1; i <= 4; i++ {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("f200000000000000000000000000000%d", i)
+
+		_, err := srv.db.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/updateFlags", strings.NewReader(`{"uuids": [
+		"f2000000000000000000000000000001",
+		"f2000000000000000000000000000003",
+		"f2000000000000000000000000000009"], "done": true}`))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.updateFlags(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, int64(2), resp.Updated)
+
+	entries, err := srv.db.GetAudit(10)
+	assert.NoError(t, err)
+
+	var audited []string
+
+	for _, entry := range entries {
+		if entry.Action == AuditActionUpdate {
+			audited = append(audited, entry.UUID)
+		}
+	}
+
+	assert.ElementsMatch(t, []string{"f2000000000000000000000000000001", "f2000000000000000000000000000003"}, audited)
+
+	events, err := srv.db.GetAllEvents()
+	assert.NoError(t, err)
+	assert.Len(t, events, 4)
+
+	for _, event := range events {
+		updated := event.UUID == "f2000000000000000000000000000001" || event.UUID == "f2000000000000000000000000000003"
+
+		assert.Equal(t, updated, event.Done, event.UUID)
+		assert.Equal(t, TestEvent1.Important, event.Important, event.UUID)
+		assert.Equal(t, TestEvent1.Urgent, event.Urgent, event.UUID)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/updateFlags",
+		strings.NewReader(`{"uuids": ["f2000000000000000000000000000002"]}`))
+	req.Header.Set("Token", token)
+
+	rec = httptest.NewRecorder()
+	srv.updateFlags(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func Test_GetEventDetailCarriesMetadata(t *testing.T) {
+	/* GIVEN an event inserted by `admin` and then patched by `editor`
+	 * WHEN its detail is requested
+	 * THEN creation and update timestamps should be populated
+	 * AND `editor` should be reported as the last one who modified it
+	 * AND unknown UUID should result in 404
+	 */
+	var resp GetEventDetailResp
+
+	srv, token := newTestServer(t)
+
+	editorToken, err := createJWT(srv.cfg, srv.clock, "editor")
+	assert.NoError(t, err)
+
+	for _, step := range []struct {
+		handler http.HandlerFunc
+		token   string
+		body    string
+	}{
+		{srv.insertEvent, token, `{"event": {"uuid": "e2000000000000000000000000000001", "title": "Detailed"}}`},
+		{srv.patchEvent, editorToken, `{"uuid": "e2000000000000000000000000000001", "done": true}`},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(step.body))
+		req.Header.Set("Token", step.token)
+
+		rec := httptest.NewRecorder()
+		step.handler(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code, step.body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/getEventDetail", strings.NewReader(`{"uuid": "e2000000000000000000000000000001"}`))
+	req.Header.Set("Token", token)
+
+	rec := httptest.NewRecorder()
+	srv.getEventDetail(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	if assert.NotNil(t, resp.Event) {
+		assert.Equal(t, "Detailed", resp.Event.Title)
+		assert.True(t, resp.Event.Done)
+		assert.NotEqual(t, int64(0), resp.Event.CreatedAt)
+		assert.GreaterOrEqual(t, resp.Event.UpdatedAt, resp.Event.CreatedAt)
+		assert.Equal(t, "editor", resp.Event.LastModifiedBy)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/getEventDetail", strings.NewReader(`{"uuid": "e2000000000000000000000000000009"}`))
+	req.Header.Set("Token", token)
+
+	rec = httptest.NewRecorder()
+	srv.getEventDetail(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// failingStatusRepo is a DatabaseRepo which status can not be read.
+type failingStatusRepo struct {
+	DatabaseRepo
+}
+
+func (failingStatusRepo) GetStatus() (GetStatusResp, error) {
+	return GetStatusResp{}, errors.New("no such table: status")
+}
+
+func Test_StatusReadFailureSendsSingle500(t *testing.T) {
+	/* GIVEN a server which repository fails to read status
+	 * WHEN status is requested
+	 * THEN exactly one JSON response with 500 should be sent
+	 */
+	var resp GetStatusResp
+
+	srv := &HTTPRestServer{cfg: config.Default(), clock: SystemClock{}, db: failingStatusRepo{},
+		log: logger.NewConsoleLogger("TEST", logger.CRITICAL)}
+
+	rec := httptest.NewRecorder()
+	srv.getStatus(rec, httptest.NewRequest(http.MethodGet, "/api/v1/status", http.NoBody))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	/* Two concatenated JSON documents would fail to unmarshal */
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.False(t, resp.Status.Success)
+	assert.Contains(t, resp.Status.Message, "no such table")
+}
+
+// schemaVersionRepo is a DatabaseRepo reporting fixed schema version.
+type schemaVersionRepo struct {
+	DatabaseRepo
+}
+
+func (schemaVersionRepo) GetSchemaVersion() (int, error) {
+	return SchemaVersion, nil
+}
+
+func Test_BuildInfoReportsDiagnostics(t *testing.T) {
+	/* GIVEN a server
+	 * WHEN build information is requested by admin and by other user
+	 * THEN admin should receive every diagnostic key with non-empty value
+	 * AND other user should be denied
+	 */
+	var body map[string]any
+
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+	cfg.AdminUsername = "admin"
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, db: schemaVersionRepo{},
+		log: logger.NewConsoleLogger("TEST", logger.CRITICAL)}
+
+	request := func(user string) *httptest.ResponseRecorder {
+		token, err := createJWT(cfg, SystemClock{}, user)
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/buildInfo", http.NoBody)
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.requireAdmin(srv.buildInfo)(rec, req)
+
+		return rec
+	}
+
+	rec := request("admin")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+	for _, key := range []string{"version", "go_version", "module", "db_driver", "schema_version"} {
+		assert.NotEmpty(t, body[key], key)
+	}
+
+	vcs, ok := body["vcs"].(map[string]any)
+	assert.True(t, ok)
+
+	for _, key := range []string{"system", "revision", "time"} {
+		assert.NotEmpty(t, vcs[key], key)
+	}
+
+	assert.Contains(t, vcs, "modified")
+	assert.Equal(t, float64(SchemaVersion), body["schema_version"])
+	assert.Equal(t, BackendSQLite, body["db_driver"])
+
+	assert.Equal(t, http.StatusForbidden, request("alice").Code)
+}
+
+func Test_GetLocationsReturnsDistinctAddresses(t *testing.T) {
+	/* GIVEN events sharing some addresses and an event without address
+	 * WHEN locations are requested without and with prefix
+	 * THEN every non-empty address should be listed once in alphabetical order
+	 * AND prefix should filter them case-insensitively
+	 * AND limit out of range should be rejected
+	 */
+	var resp GetLocationsResp
+
+	srv, token := newTestServer(t)
+
+	for i, address := range []string{"Łódź, ul. Rzgowska 65", "Warszawa, ul. Okrężna 26", "", "Warszawa, ul. Okrężna 26", "Kraków, Rynek 1"} {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("l100000000000000000000000000000%d", i)
+		event.Address = address
+
+		_, err := srv.db.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	request := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/getLocations", strings.NewReader(body))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.getLocations(rec, req)
+
+		return rec
+	}
+
+	rec := request("")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"Kraków, Rynek 1", "Warszawa, ul. Okrężna 26", "Łódź, ul. Rzgowska 65"}, resp.Locations)
+
+	resp = GetLocationsResp{}
+	rec = request(`{"prefix": "war"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"Warszawa, ul. Okrężna 26"}, resp.Locations)
+
+	assert.Equal(t, http.StatusBadRequest, request(fmt.Sprintf(`{"limit": %d}`, LocationsMaxLimit+1)).Code)
+}
+
+func Test_InsertOnlyModeDoesNotOverwrite(t *testing.T) {
+	/* GIVEN a stored event
+	 * WHEN it is sent again with a changed title in insert-only mode and with an unknown mode
+	 * THEN insert-only should respond with 409 and keep the stored title
+	 * AND unknown mode should be rejected with 400
+	 */
+	srv, token := newTestServer(t)
+
+	insert := func(mode, title string) int {
+		event := TestEvent1
+		event.UUID = "i2000000000000000000000000000001"
+		event.Title = title
+
+		body, err := json.Marshal(AddEventReq{Event: event})
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/insertEvent?mode="+mode, strings.NewReader(string(body)))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.insertEvent(rec, req)
+
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, insert("insert-only", "Original"))
+	assert.Equal(t, http.StatusConflict, insert("insert-only", "Changed"))
+	assert.Equal(t, http.StatusBadRequest, insert("overwrite", "Changed"))
+
+	stored, err := srv.db.GetEventByUUID("i2000000000000000000000000000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "Original", stored.Title)
+
+	assert.Equal(t, http.StatusOK, insert("upsert", "Changed"))
+}
+
+func Test_ServerTimeReportsConfiguredZone(t *testing.T) {
+	/* GIVEN a server configured for Europe/Warsaw with a fixed summer clock
+	 * WHEN time is requested without token
+	 * THEN configured zone, its summer offset and the clock time should be returned
+	 */
+	var resp ServerTimeResp
+
+	cfg := config.Default()
+	cfg.TimeZone = "Europe/Warsaw"
+
+	now := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	srv := &HTTPRestServer{cfg: cfg, clock: &fixedClock{now: now}, log: logger.NewConsoleLogger("TEST", logger.CRITICAL)}
+
+	rec := httptest.NewRecorder()
+	srv.serverTime(rec, httptest.NewRequest(http.MethodGet, "/api/v1/time", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, cfg.TimeZone, resp.TimeZone)
+	assert.Equal(t, 2*60*60, resp.UTCOffset)
+	assert.Equal(t, now.Unix(), resp.Timestamp)
+	assert.True(t, resp.Status.Success)
+}
+
+func Test_DateTimeFormatControlsOutput(t *testing.T) {
+	/* GIVEN servers configured for object and ISO 8601 date times, current and legacy
+	 * WHEN a response with nested events is sent
+	 * THEN object mode should send date time objects
+	 * AND ISO 8601 mode should send strings, with discriminators dropped in legacy mode
+	 */
+	resp := GetEventsResp{
+		Common: Common{Type: GetEventsRespName},
+		Events: []EventData{TestEvent2},
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	for _, tc := range []struct {
+		format, compat string
+		start          string
+	}{
+		{config.DateTimeFormatObject, config.CompatCurrent,
+			`"start":{"__type__":"DateTime","year":2024,"month":2,"day":13,"hour":12,"minute":0}`},
+		{config.DateTimeFormatObject, config.CompatLegacy, `"start":{"day":13,"hour":12,"minute":0,"month":2,"year":2024}`},
+		{config.DateTimeFormatISO8601, config.CompatCurrent, `"start":"2024-02-13T12:00:00"`},
+		{config.DateTimeFormatISO8601, config.CompatLegacy, `"start":"2024-02-13T12:00:00"`},
+	} {
+		cfg := config.Default()
+		cfg.DateTimeFormat = tc.format
+		cfg.Compat = tc.compat
+
+		srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.CRITICAL)}
+
+		rec := httptest.NewRecorder()
+		srv.send(resp, rec, httptest.NewRequest(http.MethodGet, "/api/v1/getEventsByCategory", http.NoBody))
+
+		body := rec.Body.String()
+		assert.Contains(t, body, tc.start, tc.format+"/"+tc.compat)
+		assert.Contains(t, body, `"end":`, tc.format+"/"+tc.compat)
+		assert.Equal(t, tc.compat == config.CompatCurrent, strings.Contains(body, `"__type__":"EventData"`), tc.format+"/"+tc.compat)
+	}
+}
+
+func Test_LegacyCompatOmitsDiscriminators(t *testing.T) {
+	/* GIVEN servers in current and legacy compatibility mode
+	 * WHEN the same response with nested messages is sent
+	 * THEN current mode should carry `__type__` and `__version__` at every level
+	 * AND legacy mode should carry none of them while keeping other fields
+	 */
+	resp := GetEventsResp{
+		Common: Common{Type: GetEventsRespName},
+		Events: []EventData{TestEvent1},
+		Status: ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: true, Message: ""},
+	}
+
+	for compat, present := range map[string]bool{config.CompatCurrent: true, config.CompatLegacy: false} {
+		cfg := config.Default()
+		cfg.Compat = compat
+
+		srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.CRITICAL)}
+
+		rec := httptest.NewRecorder()
+		srv.send(resp, rec, httptest.NewRequest(http.MethodGet, "/api/v1/getEventsByCategory", http.NoBody))
+
+		body := rec.Body.String()
+		assert.Equal(t, present, strings.Contains(body, `"__type__"`), compat)
+		assert.Equal(t, present, strings.Contains(body, `"__version__"`), compat)
+		assert.Contains(t, body, `"uuid":"`+TestEvent1.UUID+`"`, compat)
+		assert.Contains(t, body, `"reminder":7`, compat)
+		assert.Contains(t, body, `"success":true`, compat)
+	}
+}
+
+// healthyRepo is a DatabaseRepo which always answers health checks.
+type healthyRepo struct {
+	DatabaseRepo
+}
+
+func (healthyRepo) HealthCheck() error {
+	return nil
+}
+
+func Test_ReadyzReportsLowDiskSpace(t *testing.T) {
+	/* GIVEN a ready server with file database and a minimum of 1 MiB free space
+	 * WHEN readyz is requested while less and then more than that is free
+	 * THEN 503 with the reason should be returned first and 200 afterwards
+	 * AND in-memory database should skip the disk check
+	 */
+	t.Parallel()
+
+	var freePath string
+
+	free := uint64(10)
+
+	cfg := config.Default()
+	cfg.DatabaseFile = "file:/var/lib/eventshub/events.db?_fk=1"
+	cfg.MinFreeBytes = 1 << 20
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, db: healthyRepo{},
+		log: logger.NewConsoleLogger("TEST", logger.CRITICAL)}
+	srv.ready.Store(true)
+	srv.diskFree = func(dir string) (uint64, error) {
+		freePath = dir
+		return free * 4096, nil
+	}
+
+	rec := httptest.NewRecorder()
+	srv.readyz(rec, httptest.NewRequest(http.MethodGet, "/api/v1/readyz", http.NoBody))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Low disk space: 40960 bytes free")
+	assert.Equal(t, "/var/lib/eventshub", freePath)
+
+	free = 1024
+
+	rec = httptest.NewRecorder()
+	srv.readyz(rec, httptest.NewRequest(http.MethodGet, "/api/v1/readyz", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"free_bytes":4194304`)
+
+	free = 0
+	srv.cfg.DatabaseFile = ":memory:"
+
+	rec = httptest.NewRecorder()
+	srv.readyz(rec, httptest.NewRequest(http.MethodGet, "/api/v1/readyz", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "free_bytes")
+}
+
+func Test_ReassignEventsTransfersOwnership(t *testing.T) {
+	/* GIVEN two events of alice and one of carol
+	 * WHEN the admin reassigns events of alice to bob
+	 * THEN two events should be reported as moved and stored with bob as owner
+	 * AND each moved event should be recorded in the audit log as updated by the admin
+	 * AND the event of carol should be untouched
+	 * AND reassigning to an unknown user should be rejected with 404
+	 * AND reassigning beyond the quota of the receiving user should be rejected with 403
+	 */
+	var resp ReassignEventsResp
+
+	srv, _ := newTestServer(t)
+	srv.cfg.AdminUsername = "admin"
+
+	repo := srv.db.(*SQLiteRepository)
+
+	token, err := createJWT(srv.cfg, srv.clock, "admin")
+	assert.NoError(t, err)
+
+	for _, user := range []string{"alice", "bob", "carol"} {
+		assert.NoError(t, srv.db.AddUser(user, "S3cret!pass", false))
+	}
+
+	for i, owner := range []string{"alice", "alice", "carol"} {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("f300000000000000000000000000000%d", i)
+		event.Owner = owner
+
+		_, err := srv.db.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	request := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/reassignEvents", strings.NewReader(body))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.requireAdmin(srv.reassignEvents)(rec, req)
+
+		return rec
+	}
+
+	ownerOf := func(uuid string) string {
+		var owner string
+
+		assert.NoError(t, repo.handle().QueryRow("SELECT owner FROM events WHERE uuid = ?;", uuid).Scan(&owner))
+
+		return owner
+	}
+
+	rec := request(`{"fromUser": "alice", "toUser": "bob"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, int64(2), resp.Reassigned)
+
+	assert.Equal(t, "bob", ownerOf("f3000000000000000000000000000000"))
+	assert.Equal(t, "bob", ownerOf("f3000000000000000000000000000001"))
+	assert.Equal(t, "carol", ownerOf("f3000000000000000000000000000002"))
+
+	entries, err := srv.db.GetAudit(10)
+	assert.NoError(t, err)
+
+	var audited []string
+
+	for _, entry := range entries {
+		assert.Equal(t, "admin", entry.User)
+		assert.Equal(t, AuditActionUpdate, entry.Action)
+		audited = append(audited, entry.UUID)
+	}
+
+	assert.ElementsMatch(t, []string{"f3000000000000000000000000000000", "f3000000000000000000000000000001"}, audited)
+
+	rec = request(`{"fromUser": "carol", "toUser": "dave"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "carol", ownerOf("f3000000000000000000000000000002"))
+
+	/* Bob already owns two events, third one would exceed the quota */
+	srv.cfg.MaxEventsPerUser = 2
+
+	rec = request(`{"fromUser": "carol", "toUser": "bob"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "carol", ownerOf("f3000000000000000000000000000002"))
+
+	srv.cfg.MaxEventsPerUser = 3
+
+	rec = request(`{"fromUser": "carol", "toUser": "bob"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "bob", ownerOf("f3000000000000000000000000000002"))
+}
+
+func Test_MergeEventsCombinesPartialEvents(t *testing.T) {
+	/* GIVEN two records of the same event, each with some fields missing
+	 * WHEN the admin merges the second one into the first one
+	 * THEN the merged event should keep fields of the primary and gain the missing ones
+	 * AND the secondary event should be deleted
+	 * AND merging a missing event should be rejected with 404
+	 */
+	var resp MergeEventsResp
+
+	srv, token := newTestServer(t)
+	srv.cfg.AdminUsername = "admin"
+
+	primary := TestEvent1
+	primary.UUID = "f4000000000000000000000000000000"
+	primary.Address = ""
+	primary.Category = ""
+	primary.Reminder = 0
+
+	secondary := TestEvent1
+	secondary.UUID = "f4000000000000000000000000000001"
+	secondary.Title = "Other title"
+	secondary.Address = "Main Street 1"
+	secondary.Info = ""
+	secondary.Category = "work"
+	secondary.Reminder = 30
+
+	for _, event := range []EventData{primary, secondary} {
+		event := event
+
+		_, err := srv.db.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	request := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/mergeEvents", strings.NewReader(body))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.requireAdmin(srv.mergeEvents)(rec, req)
+
+		return rec
+	}
+
+	rec := request(`{"primary": "f4000000000000000000000000000000", "secondary": "f4000000000000000000000000000001"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Status.Success)
+	assert.Equal(t, primary.UUID, resp.Event.UUID)
+	assert.Equal(t, primary.Title, resp.Event.Title)
+	assert.Equal(t, primary.Info, resp.Event.Info)
+	assert.Equal(t, "Main Street 1", resp.Event.Address)
+	assert.Equal(t, "work", resp.Event.Category)
+	assert.Equal(t, int32(30), resp.Event.Reminder)
+
+	stored, err := srv.db.GetEventByUUID(primary.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Main Street 1", stored.Address)
+	assert.Equal(t, "work", stored.Category)
+
+	_, err = srv.db.GetEventByUUID(secondary.UUID)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+
+	rec = request(`{"primary": "f4000000000000000000000000000000", "secondary": "f4000000000000000000000000000001"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func Test_GetEventsByTitleMatchesExactly(t *testing.T) {
+	/* GIVEN two events sharing a title and one with a longer title starting the same
+	 * WHEN events are requested by the shared title
+	 * THEN only the two events should be returned, ordered by start
+	 * AND request without title should be rejected with 400
+	 */
+	var resp GetEventsResp
+
+	srv, token := newTestServer(t)
+
+	for i, title := range []string{"Dentist", "Dentist", "Dentist appointment"} {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("f400000000000000000000000000000%d", i)
+		event.Title = title
+		event.Start.Day = int32(20 - i)
+		event.End.Day = int32(20 - i)
+
+		_, err := srv.db.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	request := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/getEventsByTitle", strings.NewReader(body))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.getEventsByTitle(rec, req)
+
+		return rec
+	}
+
+	rec := request(`{"title": "Dentist"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	if assert.Len(t, resp.Events, 2) {
+		assert.Equal(t, "f4000000000000000000000000000001", resp.Events[0].UUID)
+		assert.Equal(t, "f4000000000000000000000000000000", resp.Events[1].UUID)
+	}
+
+	rec = request(`{"title": " "}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func Test_GetEventsCreatedBetweenUsesCreationTime(t *testing.T) {
+	/* GIVEN three events inserted two hours apart, all taking place on the same day
+	 * WHEN events created within a window around the second insert are requested
+	 * THEN only the second event should be returned
+	 * AND inverted window should be rejected with 400
+	 */
+	var resp GetEventsResp
+
+	srv, token := newTestServer(t)
+
+	clock := &fixedClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	srv.db.(*SQLiteRepository).clock = clock
+
+	for i := 0; i < 3; i++ {
+		event := TestEvent1
+		event.UUID = fmt.Sprintf("f800000000000000000000000000000%d", i)
+
+		_, err := srv.db.InsertEvent(&event)
+		assert.NoError(t, err)
+
+		clock.now = clock.now.Add(2 * time.Hour)
+	}
+
+	window := func(from, to time.Time) string {
+		fromUnix, toUnix := from.Unix(), to.Unix()
+
+		start, err := unixToDateTime(&fromUnix, srv.cfg.TimeZone)
+		assert.NoError(t, err)
+
+		end, err := unixToDateTime(&toUnix, srv.cfg.TimeZone)
+		assert.NoError(t, err)
+
+		body, err := json.Marshal(GetEventsReq{Start: start, End: end})
+		assert.NoError(t, err)
+
+		return string(body)
+	}
+
+	request := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/getEventsCreatedBetween", strings.NewReader(body))
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.getEventsCreatedBetween(rec, req)
+
+		return rec
+	}
+
+	from := time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC)
+
+	rec := request(window(from, to))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	if assert.Len(t, resp.Events, 1) {
+		assert.Equal(t, "f8000000000000000000000000000001", resp.Events[0].UUID)
+	}
+
+	rec = request(window(to, from))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// insertRecordingRepo is a DatabaseRepo remembering events passed to InsertEvent.
+type insertRecordingRepo struct {
+	stubRepo
+	inserted []EventData
+}
+
+func (repo *insertRecordingRepo) InsertEvent(e *EventData) (*EventData, error) {
+	repo.inserted = append(repo.inserted, *e)
+	return e, nil
+}
+
+func Test_DefaultReminderAppliedOnlyOnRequest(t *testing.T) {
+	/* GIVEN default reminder of 3 days
+	 * WHEN events are inserted with and without use_default_reminder
+	 * THEN zero reminder should be replaced only when the default was requested
+	 * AND explicitly provided reminder should never be replaced
+	 */
+	repo := &insertRecordingRepo{}
+	srv, token := newStubServer(t, repo)
+	srv.cfg.ReminderDays = 3
+
+	for _, body := range []string{
+		`{"event": {"uuid": "f5000000000000000000000000000001", "reminder": 0}, "use_default_reminder": true}`,
+		`{"event": {"uuid": "f5000000000000000000000000000002", "reminder": 0}}`,
+		`{"event": {"uuid": "f5000000000000000000000000000003", "reminder": 7}, "use_default_reminder": true}`,
+	} {
+		rec := httptest.NewRecorder()
+		srv.insertEvent(rec, authorizedRequest(http.MethodPost, "/api/v1/insertEvent", body, token))
+
+		assert.Equal(t, http.StatusOK, rec.Code, body)
+	}
+
+	if assert.Len(t, repo.inserted, 3) {
+		assert.Equal(t, int32(3), repo.inserted[0].Reminder)
+		assert.Equal(t, int32(0), repo.inserted[1].Reminder)
+		assert.Equal(t, int32(7), repo.inserted[2].Reminder)
+	}
+}
+
+// uuidsRepo is a DatabaseRepo storing fixed events looked up by GetEventsByUUIDs.
+type uuidsRepo struct {
+	stubRepo
+	events []EventData
+}
+
+func (repo uuidsRepo) GetEventsByUUIDs(uuids []string) ([]EventData, error) {
+	var result []EventData
+
+	for _, e := range repo.events {
+		for _, uuid := range uuids {
+			if e.UUID == uuid {
+				result = append(result, e)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func Test_PlanSyncClassifiesEvents(t *testing.T) {
+	/* GIVEN two stored events
+	 * WHEN sync is planned with a matching checksum of one, other checksum of another and an unknown UUID
+	 * THEN they should be classified as unchanged, changed and new respectively
+	 */
+	var resp PlanSyncResp
+
+	srv, token := newStubServer(t, &uuidsRepo{events: []EventData{TestEvent1, TestEvent2}})
+
+	body, err := json.Marshal(PlanSyncReq{Checksums: map[string]string{
+		TestEvent1.UUID:                    strings.ToUpper(fmt.Sprintf("%x", TestEvent1.Sha256())),
+		TestEvent2.UUID:                    fmt.Sprintf("%x", TestEvent1.Sha256()),
+		"f6000000000000000000000000000001": fmt.Sprintf("%x", TestEvent1.Sha256()),
+	}})
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	srv.planSync(rec, authorizedRequest(http.MethodPost, "/api/v1/planSync", string(body), token))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"f6000000000000000000000000000001"}, resp.New)
+	assert.Equal(t, []string{TestEvent2.UUID}, resp.Changed)
+	assert.Equal(t, []string{TestEvent1.UUID}, resp.Unchanged)
+}
+
+func Test_InsertEventReportsAllValidationErrors(t *testing.T) {
+	/* GIVEN an event with too long title, malformed color and source outside the allowlist
+	 * WHEN it is inserted in insert-only mode
+	 * THEN 400 with ValidationErrorResp naming all three fields should be returned
+	 * AND nothing should be stored
+	 */
+	var resp ValidationErrorResp
+
+	repo := &insertRecordingRepo{}
+	srv, token := newStubServer(t, repo)
+	srv.cfg.MaxTitleLength = 5
+	srv.cfg.AllowedSources = config.ParseSources("XML")
+
+	body := `{"event": {"uuid": "f7000000000000000000000000000001", "title": "Too long", "color": "red", "source": "XLM"}}`
+
+	rec := httptest.NewRecorder()
+	srv.insertEvent(rec, authorizedRequest(http.MethodPost, "/api/v1/insertEvent?mode=insert-only", body, token))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, ValidationErrorRespName, resp.Type)
+	assert.False(t, resp.Status.Success)
+
+	if assert.Len(t, resp.Errors, 3) {
+		assert.Contains(t, resp.Errors["title"], "at most 5")
+		assert.Contains(t, resp.Errors["color"], "#rrggbb")
+		assert.Contains(t, resp.Errors["source"], "not allowed")
+	}
+
+	assert.Empty(t, repo.inserted)
+}
+
+// eventsRepo is a DatabaseRepo iterating over fixed events.
+type eventsRepo struct {
+	stubRepo
+	events []EventData
+}
+
+func (repo eventsRepo) ForEachEvent(batchSize int, fn func(EventData) error) error {
+	for _, e := range repo.events {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func Test_ExportCSVRoundTrips(t *testing.T) {
+	/* GIVEN events with commas, quotes and new lines in their text
+	 * WHEN they are exported as CSV
+	 * THEN encoding/csv should read back the header and one row per event with original values
+	 */
+	tricky := TestEvent2
+	tricky.Title = `Say "hello", then leave`
+	tricky.Info = "First line\nsecond line"
+
+	srv, token := newStubServer(t, &eventsRepo{events: []EventData{TestEvent1, tricky}})
+
+	rec := httptest.NewRecorder()
+	srv.exportCSV(rec, authorizedRequest(http.MethodGet, "/api/v1/export.csv", "", token))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ExportCSVContentType, rec.Header().Get("Content-Type"))
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	assert.NoError(t, err)
+
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, exportCSVHeader, records[0])
+		assert.Equal(t, []string{
+			TestEvent1.UUID, "Ur. Mr X", "2021-01-12 00:00", "2021-01-12 00:00", "Warszawa, ul. Okrężna 26",
+			"Likes beer", "7", "false", "true", "false", "APP",
+		}, records[1])
+		assert.Equal(t, tricky.Title, records[2][1])
+		assert.Equal(t, tricky.Info, records[2][5])
+	}
+}
+
+func Test_CSVRecordEscapesFormulas(t *testing.T) {
+	/* GIVEN event text starting with characters spreadsheets evaluate as formulas
+	 * WHEN the event is converted to CSV record
+	 * THEN such text should be prefixed with `'`
+	 * AND other text, dates and numbers should be left untouched
+	 */
+	t.Parallel()
+
+	for _, value := range []string{"=1+2", "+1", "-1", "@SUM(A1)", "\tTab", "\rReturn"} {
+		e := TestEvent1
+		e.Title = value
+		e.Address = value
+		e.Info = value
+
+		record := csvRecord(e)
+		assert.Equal(t, "'"+value, record[1])
+		assert.Equal(t, "'"+value, record[4])
+		assert.Equal(t, "'"+value, record[5])
+	}
+
+	e := TestEvent1
+	e.Title = "Meeting = 1h"
+	e.Reminder = -1
+
+	record := csvRecord(e)
+	assert.Equal(t, "Meeting = 1h", record[1])
+	assert.Equal(t, "2021-01-12 00:00", record[2])
+	assert.Equal(t, "-1", record[6])
+}
+
+func Test_ExportCSVIsGzippedWhenAccepted(t *testing.T) {
+	/* GIVEN stored events
+	 * WHEN CSV export is requested with and without gzip in Accept-Encoding
+	 * THEN gzip accepting client should get compressed output decompressing to the same CSV
+	 * AND other clients, including ones refusing gzip with q=0, should get it uncompressed
+	 * AND both responses should vary by Accept-Encoding
+	 */
+	srv, token := newStubServer(t, &eventsRepo{events: []EventData{TestEvent1, TestEvent2}})
+
+	export := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := authorizedRequest(http.MethodGet, "/api/v1/export.csv", "", token)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+
+		rec := httptest.NewRecorder()
+		srv.exportCSV(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+
+		return rec
+	}
+
+	plain := export("")
+	assert.Empty(t, plain.Header().Get("Content-Encoding"))
+
+	refused := export("gzip;q=0, identity")
+	assert.Empty(t, refused.Header().Get("Content-Encoding"))
+	assert.Equal(t, plain.Body.String(), refused.Body.String())
+
+	compressed := export("deflate, GZIP;q=0.8")
+	assert.Equal(t, "gzip", compressed.Header().Get("Content-Encoding"))
+
+	zr, err := gzip.NewReader(compressed.Body)
+	assert.NoError(t, err)
+
+	decompressed, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Equal(t, plain.Body.String(), string(decompressed))
+
+	records, err := csv.NewReader(bytes.NewReader(decompressed)).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	/* Disabled compression leaves output plain even for gzip accepting clients */
+	srv.cfg.Gzip = false
+
+	req := authorizedRequest(http.MethodGet, "/api/v1/export.csv", "", token)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rec := httptest.NewRecorder()
+	srv.exportCSV(rec, req)
+
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, plain.Body.String(), rec.Body.String())
+}
+
+// rangeRepo is a DatabaseRepo remembering bounds of the last time range query.
+type rangeRepo struct {
+	stubRepo
+	start, end int64
+}
+
+func (repo *rangeRepo) GetEventsByTimeRange(start, end int64) ([]EventData, error) {
+	repo.start, repo.end = start, end
+	return []EventData{TestEvent1}, nil
+}
+
+func Test_GetEventsByShortcutQueriesConfiguredZone(t *testing.T) {
+	/* GIVEN a server configured for Europe/Warsaw with a clock on the last day of a month in UTC
+	 *   but already on the first day of the next one in Warsaw
+	 * WHEN events of this month and of an unknown shortcut are requested
+	 * THEN the Warsaw month should be queried in Warsaw time
+	 * AND the unknown shortcut should be rejected with 400 without querying the database
+	 */
+	var resp GetEventsResp
+
+	repo := &rangeRepo{}
+	srv, _ := newStubServer(t, repo)
+	srv.cfg.TimeZone = "Europe/Warsaw"
+
+	/* 23:30 UTC on January 31 is already 00:30 on February 1 in Warsaw */
+	srv.clock = &fixedClock{now: time.Date(2024, 1, 31, 23, 30, 0, 0, time.UTC)}
+
+	token, err := createJWT(srv.cfg, srv.clock, "alice")
+	assert.NoError(t, err)
+
+	request := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.getEventsByShortcut(rec, authorizedRequest(http.MethodPost, "/api/v1/getEventsByShortcut", body, token))
+
+		return rec
+	}
+
+	rec := request(`{"shortcut": "this_month"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Status.Success)
+	assert.Len(t, resp.Events, 1)
+
+	loc, err := srv.cfg.Location()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, loc).Unix(), repo.start)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, loc).Unix()-1, repo.end)
+
+	repo.start, repo.end = 0, 0
+
+	rec = request(`{"shortcut": "next_year"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Zero(t, repo.start)
+}
+
+func Test_MyRemindersListsOnlyOwnUpcomingReminders(t *testing.T) {
+	/* GIVEN events of alice and bob, some done, some reminding outside the window
+	 * WHEN alice and bob request their reminders for next 7 days
+	 * THEN each of them should receive only own not done events reminding within the window
+	 * AND reminders should be ordered by the moment they fire, not by event start
+	 */
+	srv, _ := newTestServer(t)
+	srv.clock = &fixedClock{now: time.Date(2021, 1, 4, 12, 0, 0, 0, time.UTC)}
+
+	at := func(day, hour int) DateTime {
+		return DateTime{Common{Type: DateTimeStructName}, 2021, 1, int32(day), int32(hour), 0}
+	}
+
+	for _, tc := range []struct {
+		uuid, owner string
+		start       DateTime
+		reminder    int32
+		done        bool
+	}{
+		{"f5000000000000000000000000000000", "alice", at(12, 0), 7, false},
+		{"f5000000000000000000000000000001", "alice", at(7, 12), 2, false},
+		{"f5000000000000000000000000000002", "alice", at(8, 0), 2, true},
+		{"f5000000000000000000000000000003", "alice", at(30, 0), 7, false},
+		{"f5000000000000000000000000000004", "bob", at(12, 0), 7, false},
+	} {
+		event := TestEvent1
+		event.UUID, event.Owner = tc.uuid, tc.owner
+		event.Start, event.End = tc.start, tc.start
+		event.Reminder, event.Done = tc.reminder, tc.done
+
+		_, err := srv.db.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	reminders := func(user string) []string {
+		var (
+			resp  MyRemindersResp
+			uuids []string
+		)
+
+		token, err := createJWT(srv.cfg, srv.clock, user)
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/myReminders?days=7", http.NoBody)
+		req.Header.Set("Token", token)
+
+		rec := httptest.NewRecorder()
+		srv.myReminders(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+		for _, reminder := range resp.Reminders {
+			assert.LessOrEqual(t, reminder.RemindAt, srv.clock.Now().AddDate(0, 0, 7).Unix())
+			uuids = append(uuids, reminder.Event.UUID)
+		}
+
+		return uuids
+	}
+
+	assert.Equal(t, []string{"f5000000000000000000000000000000", "f5000000000000000000000000000001"}, reminders("alice"))
+	assert.Equal(t, []string{"f5000000000000000000000000000004"}, reminders("bob"))
+	assert.Empty(t, reminders("carol"))
+}
@@ -0,0 +1,105 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrPasswordAlgoUnavailable = errors.New("password hashing algorithm unavailable")
+
+// PasswordHasher produces and checks stored password hashes of a single
+// algorithm. Hashes are self-describing, Owns recognises the ones the
+// hasher is able to Verify regardless of the currently configured algorithm.
+type PasswordHasher interface {
+	Name() string
+	Hash(plainPassword string) (string, error)
+	Verify(plainPassword, hash string) bool
+	Owns(hash string) bool
+}
+
+var (
+	passwordHashersMu sync.RWMutex
+	passwordHashers   = map[string]PasswordHasher{}
+)
+
+func init() {
+	registerPasswordHasher(bcryptHasher{cost: bcrypt.DefaultCost})
+}
+
+func registerPasswordHasher(h PasswordHasher) {
+	/* Make algorithm available for GOCALENDAR_PASSWORD_ALGO and verification of stored hashes */
+	passwordHashersMu.Lock()
+	defer passwordHashersMu.Unlock()
+
+	passwordHashers[h.Name()] = h
+}
+
+func passwordHasher(name string) (PasswordHasher, error) {
+	/* Return hasher of configured algorithm, some are available only with build tags */
+	passwordHashersMu.RLock()
+	defer passwordHashersMu.RUnlock()
+
+	h, ok := passwordHashers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPasswordAlgoUnavailable, name)
+	}
+
+	return h, nil
+}
+
+func hasherOf(hash string) PasswordHasher {
+	/* Find hasher which produced stored hash, nil if none recognises it */
+	passwordHashersMu.RLock()
+	defer passwordHashersMu.RUnlock()
+
+	for _, h := range passwordHashers {
+		if h.Owns(hash) {
+			return h
+		}
+	}
+
+	return nil
+}
+
+func verifyPassword(plainPassword, hash string, preferred PasswordHasher) (valid, rehash bool) {
+	/* Check password against hash of any known algorithm, report if it should be
+	 * stored again using the preferred one.
+	 */
+	h := hasherOf(hash)
+	if h == nil || !h.Verify(plainPassword, hash) {
+		return false, false
+	}
+
+	return true, h.Name() != preferred.Name()
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+func (bcryptHasher) Name() string {
+	return "bcrypt"
+}
+
+func (h bcryptHasher) Hash(plainPassword string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plainPassword), h.cost)
+
+	return string(hash), err
+}
+
+func (bcryptHasher) Verify(plainPassword, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(plainPassword)) == nil
+}
+
+func (bcryptHasher) Owns(hash string) bool {
+	/* $2a$, $2b$ and $2y$ variants share the format */
+	return strings.HasPrefix(hash, "$2")
+}
@@ -0,0 +1,99 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Parameters recommended by RFC 9106 for memory constrained environments.
+const (
+	Argon2Time    uint32 = 3
+	Argon2Memory  uint32 = 64 * 1024
+	Argon2Threads uint8  = 4
+	Argon2KeyLen  uint32 = 32
+	Argon2SaltLen int    = 16
+)
+
+func init() {
+	registerPasswordHasher(argon2idHasher{
+		time:    Argon2Time,
+		memory:  Argon2Memory,
+		threads: Argon2Threads,
+		keyLen:  Argon2KeyLen,
+	})
+}
+
+type argon2idHasher struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+	keyLen  uint32
+}
+
+func (argon2idHasher) Name() string {
+	return "argon2id"
+}
+
+func (h argon2idHasher) Hash(plainPassword string) (string, error) {
+	/* Encode in PHC string format, parameters travel with the hash */
+	salt := make([]byte, Argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(plainPassword), salt, h.time, h.memory, h.threads, h.keyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.memory, h.time, h.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (argon2idHasher) Verify(plainPassword, hash string) bool {
+	/* Recompute key using parameters of the stored hash, not the current ones */
+	var (
+		version              int
+		time, memory         uint32
+		threads              uint8
+		salt, key, candidate []byte
+		err                  error
+	)
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false
+	}
+
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return false
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return false
+	}
+
+	candidate = argon2.IDKey([]byte(plainPassword), salt, time, memory, threads, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, candidate) == 1
+}
+
+func (argon2idHasher) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
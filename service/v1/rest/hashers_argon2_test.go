@@ -0,0 +1,46 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func Test_Argon2idAndBcryptVerifyEachOther(t *testing.T) {
+	/* GIVEN argon2id configured as the preferred algorithm
+	 * WHEN bcrypt and argon2id hashes are verified
+	 * THEN both should be accepted for the correct password
+	 * AND only the bcrypt one should be reported for rehashing
+	 */
+	preferred, err := passwordHasher("argon2id")
+	assert.NoError(t, err)
+
+	argonHash, err := preferred.Hash("S3cret!")
+	assert.NoError(t, err)
+	assert.True(t, preferred.Owns(argonHash))
+	assert.False(t, bcryptHasher{}.Owns(argonHash))
+
+	bcryptHash, err := bcryptHasher{cost: bcrypt.MinCost}.Hash("S3cret!")
+	assert.NoError(t, err)
+
+	valid, rehash := verifyPassword("S3cret!", argonHash, preferred)
+	assert.True(t, valid)
+	assert.False(t, rehash)
+
+	valid, rehash = verifyPassword("S3cret!", bcryptHash, preferred)
+	assert.True(t, valid)
+	assert.True(t, rehash)
+
+	valid, _ = verifyPassword("wrong", argonHash, preferred)
+	assert.False(t, valid)
+
+	/* The other way round bcrypt preferred migrates argon2id hashes */
+	valid, rehash = verifyPassword("S3cret!", argonHash, bcryptHasher{})
+	assert.True(t, valid)
+	assert.True(t, rehash)
+}
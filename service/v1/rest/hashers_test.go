@@ -0,0 +1,77 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// legacyTestHasher stands for an algorithm users are migrated away from.
+type legacyTestHasher struct{}
+
+func (legacyTestHasher) Name() string {
+	return "legacy"
+}
+
+func (legacyTestHasher) Hash(plainPassword string) (string, error) {
+	return "$legacy$" + plainPassword, nil
+}
+
+func (legacyTestHasher) Verify(plainPassword, hash string) bool {
+	return hash == "$legacy$"+plainPassword
+}
+
+func (legacyTestHasher) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$legacy$")
+}
+
+func registerLegacyTestHasher(t *testing.T) {
+	registerPasswordHasher(legacyTestHasher{})
+
+	t.Cleanup(func() {
+		passwordHashersMu.Lock()
+		defer passwordHashersMu.Unlock()
+
+		delete(passwordHashers, legacyTestHasher{}.Name())
+	})
+}
+
+func Test_VerifyPasswordAcrossAlgorithms(t *testing.T) {
+	/* GIVEN hashes produced by bcrypt and by another registered algorithm
+	 * WHEN verifyPassword is called with bcrypt as the preferred algorithm
+	 * THEN both hashes should be verified by the algorithm which produced them
+	 * AND only the non-bcrypt one should be reported for rehashing
+	 * AND unknown hashes and wrong passwords should be rejected
+	 */
+	registerLegacyTestHasher(t)
+
+	preferred, err := passwordHasher("BCRYPT")
+	assert.NoError(t, err)
+
+	bcryptHash, err := bcryptHasher{cost: bcrypt.MinCost}.Hash("S3cret!")
+	assert.NoError(t, err)
+
+	valid, rehash := verifyPassword("S3cret!", bcryptHash, preferred)
+	assert.True(t, valid)
+	assert.False(t, rehash)
+
+	valid, rehash = verifyPassword("S3cret!", "$legacy$S3cret!", preferred)
+	assert.True(t, valid)
+	assert.True(t, rehash)
+
+	valid, rehash = verifyPassword("wrong", "$legacy$S3cret!", preferred)
+	assert.False(t, valid)
+	assert.False(t, rehash)
+
+	valid, _ = verifyPassword("S3cret!", "S3cret!", preferred)
+	assert.False(t, valid)
+
+	_, err = passwordHasher("md5")
+	assert.ErrorIs(t, err, ErrPasswordAlgoUnavailable)
+}
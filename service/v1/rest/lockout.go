@@ -0,0 +1,149 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LoginLockoutMaxEntries bounds number of keys tracked for login failures. Least recently
+// failed keys are evicted once it is reached, so spraying random usernames can not exhaust
+// memory. Locked keys are never evicted.
+const LoginLockoutMaxEntries = 10000
+
+// loginFailures counts consecutive failed logins of one key.
+type loginFailures struct {
+	key         string
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// failureCounter locks a key, an account or a client address, for a cooldown
+// after too many consecutive failures. Zero value is ready to use.
+type failureCounter struct {
+	mu       sync.Mutex
+	failures map[string]*list.Element
+	idle     list.List // entries not locked, least recently failed at the back
+	locks    list.List // locked entries, in order of locking
+}
+
+// loginLockout disables login of an account from a client address for a cooldown
+// after too many consecutive failures. Failures of the address are limited too, so
+// it can not guess passwords across many accounts either. Keying accounts by address
+// keeps others from locking the owner out by failing on purpose. Zero value is ready to use.
+type loginLockout struct {
+	accounts  failureCounter
+	addresses failureCounter
+}
+
+func accountKey(username, ip string) string {
+	/* Key account lockout by username and client address, address never contains `|` */
+	return username + "|" + ip
+}
+
+func (c *failureCounter) locked(key string, now time.Time) time.Duration {
+	/* Return remaining lockout of the key, zero if it may log in */
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.failures[key]; ok {
+		if f := e.Value.(*loginFailures); now.Before(f.lockedUntil) {
+			return f.lockedUntil.Sub(now)
+		}
+	}
+
+	return 0
+}
+
+func (c *failureCounter) fail(key string, now time.Time, maxFailures int, cooldown time.Duration) bool {
+	/* Count failed login, lock the key for cooldown once maxFailures is reached.
+	 * Returns true when this failure locked the key. maxFailures of 0 disables lockout.
+	 * Failure of a new key is not counted while every tracked key is locked.
+	 */
+	if maxFailures <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failures == nil {
+		c.failures = map[string]*list.Element{}
+	}
+
+	var f *loginFailures
+
+	if e, ok := c.failures[key]; ok {
+		f = c.remove(e)
+	} else if len(c.failures) < LoginLockoutMaxEntries || c.evict(now) {
+		f = &loginFailures{key: key}
+	} else {
+		return false
+	}
+
+	/* Failures older than cooldown are forgotten, so occasional typos never add up */
+	if now.Sub(f.last) > cooldown {
+		f.count = 0
+	}
+
+	f.count++
+	f.last = now
+
+	locking := f.count >= maxFailures
+	if locking {
+		f.count = 0
+		f.lockedUntil = now.Add(cooldown)
+	}
+
+	if now.Before(f.lockedUntil) {
+		c.failures[key] = c.locks.PushBack(f)
+	} else {
+		c.failures[key] = c.idle.PushFront(f)
+	}
+
+	return locking
+}
+
+func (c *failureCounter) reset(key string) {
+	/* Forget failures after successful login */
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.failures[key]; ok {
+		c.remove(e)
+		delete(c.failures, key)
+	}
+}
+
+func (c *failureCounter) remove(e *list.Element) *loginFailures {
+	/* Unlink entry from whichever list holds it, the other one ignores it. Caller holds the lock. */
+	c.idle.Remove(e)
+	c.locks.Remove(e)
+
+	return e.Value.(*loginFailures)
+}
+
+func (c *failureCounter) evict(now time.Time) bool {
+	/* Drop entries whose lock expired, then the least recently failed entry which is not
+	 * locked. Returns false when no room was made, as every entry is locked. Caller holds the lock.
+	 */
+	for e := c.locks.Front(); e != nil && !now.Before(e.Value.(*loginFailures).lockedUntil); e = c.locks.Front() {
+		delete(c.failures, c.remove(e).key)
+	}
+
+	if len(c.failures) < LoginLockoutMaxEntries {
+		return true
+	}
+
+	if e := c.idle.Back(); e != nil {
+		delete(c.failures, c.remove(e).key)
+		return true
+	}
+
+	return false
+}
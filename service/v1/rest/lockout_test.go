@@ -0,0 +1,176 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// passwordRepo is a DatabaseRepo accepting a single password of any user.
+type passwordRepo struct {
+	stubRepo
+	password string
+}
+
+func (repo passwordRepo) AuthenticateUser(user string, password string) (bool, error) {
+	return password == repo.password, nil
+}
+
+func newLockoutServer(t *testing.T) (*HTTPRestServer, *fixedClock) {
+	srv, _ := newStubServer(t, &passwordRepo{password: "S3cret!"})
+	srv.cfg.LoginMaxFailures = 3
+	srv.cfg.LoginMaxIPFailures = 5
+	srv.cfg.LoginLockout = time.Minute
+
+	clock := &fixedClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	srv.clock = clock
+
+	return srv, clock
+}
+
+func login(srv *HTTPRestServer, user, password, remoteAddr string) *httptest.ResponseRecorder {
+	body := `{"username": "` + user + `", "password": "` + password + `"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login", strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+
+	rec := httptest.NewRecorder()
+	srv.loginHandler(rec, req)
+
+	return rec
+}
+
+func Test_LoginLocksAccountAfterFailures(t *testing.T) {
+	/* GIVEN lockout after 3 failures of an account for a minute
+	 * WHEN alice fails to log in 3 times from one address
+	 * THEN even the correct password should be refused with 423 from that address
+	 * AND alice should still log in from other addresses, so nobody can lock her out on purpose
+	 * AND after the cooldown the correct password should be accepted again
+	 */
+	srv, clock := newLockoutServer(t)
+
+	for i := 0; i < 3; i++ {
+		rec := login(srv, "alice", "wrong", "192.0.2.1:1000")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Not enough mana!", rec.Body.String())
+	}
+
+	rec := login(srv, "alice", "S3cret!", "192.0.2.1:1000")
+	assert.Equal(t, http.StatusLocked, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	rec = login(srv, "alice", "S3cret!", "198.51.100.7:1000")
+	assert.Contains(t, rec.Body.String(), "token")
+
+	clock.now = clock.now.Add(time.Minute)
+
+	rec = login(srv, "alice", "S3cret!", "192.0.2.1:1000")
+	assert.Contains(t, rec.Body.String(), "token")
+}
+
+func Test_LoginLocksAddressAfterFailures(t *testing.T) {
+	/* GIVEN lockout after 5 failures of an address for a minute
+	 * WHEN one address fails to log in 5 times, each time as another user
+	 * THEN any login from that address should be refused with 429
+	 * AND none of the accounts should be locked for other addresses
+	 */
+	srv, clock := newLockoutServer(t)
+
+	for _, user := range []string{"alice", "bob", "carol", "dave", "erin"} {
+		rec := login(srv, user, "wrong", "192.0.2.1:1000")
+		assert.Equal(t, "Not enough mana!", rec.Body.String())
+	}
+
+	rec := login(srv, "frank", "S3cret!", "192.0.2.1:1000")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	rec = login(srv, "alice", "S3cret!", "198.51.100.7:1000")
+	assert.Contains(t, rec.Body.String(), "token")
+
+	clock.now = clock.now.Add(time.Minute)
+
+	rec = login(srv, "frank", "S3cret!", "192.0.2.1:1000")
+	assert.Contains(t, rec.Body.String(), "token")
+}
+
+func Test_LockoutEvictsOldestEntriesWhenFull(t *testing.T) {
+	/* GIVEN a failure counter holding LoginLockoutMaxEntries recent failures
+	 * WHEN another key fails
+	 * THEN the key failed longest ago should be evicted to make room
+	 * AND the number of tracked keys should never exceed the limit
+	 */
+	var sut failureCounter
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < LoginLockoutMaxEntries; i++ {
+		sut.fail(strconv.Itoa(i), now.Add(time.Duration(i)*time.Millisecond), 3, time.Minute)
+	}
+
+	sut.fail("new", now.Add(time.Second), 3, time.Minute)
+
+	assert.Len(t, sut.failures, LoginLockoutMaxEntries)
+	assert.NotContains(t, sut.failures, "0")
+	assert.Contains(t, sut.failures, "1")
+	assert.Contains(t, sut.failures, "new")
+}
+
+func Test_LockoutNeverEvictsLockedEntries(t *testing.T) {
+	/* GIVEN a failure counter full of locked keys but one failed first
+	 * WHEN new keys fail
+	 * THEN the only key not locked should be evicted for the first one
+	 * AND failures of the second one should not be tracked, as all other keys are locked
+	 * AND once locks expire, new keys should be tracked again
+	 */
+	var sut failureCounter
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	sut.fail("idle", now, 3, time.Minute)
+
+	for i := 1; i < LoginLockoutMaxEntries; i++ {
+		sut.fail(strconv.Itoa(i), now.Add(time.Millisecond), 1, time.Minute)
+	}
+
+	assert.True(t, sut.fail("first", now.Add(time.Second), 1, time.Minute))
+	assert.NotContains(t, sut.failures, "idle")
+
+	assert.False(t, sut.fail("second", now.Add(time.Second), 1, time.Minute))
+	assert.NotContains(t, sut.failures, "second")
+	assert.Len(t, sut.failures, LoginLockoutMaxEntries)
+	assert.Positive(t, sut.locked("1", now.Add(time.Second)))
+
+	assert.True(t, sut.fail("second", now.Add(time.Minute+time.Millisecond), 1, time.Minute))
+	assert.Contains(t, sut.failures, "second")
+	assert.Contains(t, sut.failures, "first")
+}
+
+func Test_SuccessfulLoginResetsFailures(t *testing.T) {
+	/* GIVEN lockout after 3 failures
+	 * WHEN alice fails twice, logs in successfully and fails twice again
+	 * THEN the account should not be locked
+	 */
+	srv, _ := newLockoutServer(t)
+
+	login(srv, "alice", "wrong", "192.0.2.1:1000")
+	login(srv, "alice", "wrong", "192.0.2.1:1000")
+
+	rec := login(srv, "alice", "S3cret!", "192.0.2.1:1000")
+	assert.Contains(t, rec.Body.String(), "token")
+
+	login(srv, "alice", "wrong", "192.0.2.1:1000")
+	login(srv, "alice", "wrong", "192.0.2.1:1000")
+
+	rec = login(srv, "alice", "S3cret!", "192.0.2.1:1000")
+	assert.Contains(t, rec.Body.String(), "token")
+}
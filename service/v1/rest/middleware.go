@@ -0,0 +1,452 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	logger "eventshub/logging"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// contextKey keys values stored in the request context by middleware.
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	loggerKey
+	connKey
+	budgetKey
+	grpcUserKey
+)
+
+// acceptedMediaTypes lists request body media types accepted by endpoints
+// other than application/json, which is accepted everywhere. Endpoints are
+// keyed by path relative to the configured prefix.
+var acceptedMediaTypes = map[string][]string{
+	"/importStream": {"application/x-ndjson"},
+}
+
+// streamingEndpoints lists endpoints, relative to the configured prefix, which
+// stream their response or hold the connection, so they are not limited by
+// cfg.HandlerTimeout and manage write deadlines themselves.
+var streamingEndpoints = map[string]bool{
+	"/attachment":    true,
+	"/backup":        true,
+	"/events/stream": true,
+	"/export.csv":    true,
+	"/importStream":  true,
+	"/ws":            true,
+}
+
+// withMiddleware wraps handler with the middleware chain. First middleware
+// of the chain is the outermost one and sees the request first.
+func (srv *HTTPRestServer) withMiddleware(handler http.Handler) http.Handler {
+	chain := []func(http.Handler) http.Handler{
+		srv.requestIDMiddleware,
+		srv.serverHeaderMiddleware,
+		srv.recoveryMiddleware,
+		srv.readinessMiddleware,
+		srv.apiVersionMiddleware,
+		srv.contentTypeMiddleware,
+		srv.timeoutMiddleware,
+		srv.queryBudgetMiddleware,
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		handler = chain[i](handler)
+	}
+
+	return handler
+}
+
+// RequestIDMaxLength bounds length of request IDs accepted from clients.
+const RequestIDMaxLength = 64
+
+// requestIDPattern lists characters of request IDs accepted from clients, so an ID
+// can not forge or pad log lines it is included in.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// requestIDMiddleware assigns every request an ID, taken from `X-Request-ID` header
+// or generated when missing. IDs longer than RequestIDMaxLength or with characters
+// outside requestIDPattern are replaced with generated ones. ID is echoed in the
+// response header and stored in the request context together with a logger
+// including it in every line.
+func (srv *HTTPRestServer) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if len(id) > RequestIDMaxLength || !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+
+		ctx := context.WithValue(r.Context(), requestIDKey, id)
+		ctx = context.WithValue(ctx, loggerKey, srv.log.With("request_id="+id+" client="+srv.clientIP(r)))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// serverHeaderMiddleware sets the `Server` header with configured instance name
+// on every response, so responses can be correlated to instances behind a load balancer.
+func (srv *HTTPRestServer) serverHeaderMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", srv.cfg.InstanceName)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoveryMiddleware recovers from panics of the handlers, so a single failing request
+// does not tear down the connection without a response. Stack is logged and client
+// receives 500 with request ID. Recovery is disabled when cfg.RecoverPanics is false.
+func (srv *HTTPRestServer) recoveryMiddleware(next http.Handler) http.Handler {
+	if !srv.cfg.RecoverPanics {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			/* Aborted handlers are expected to panic, net/http handles them silently */
+			if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(recovered)
+			}
+
+			id := requestID(r)
+
+			srv.logger(r).Critical(fmt.Sprintf("Request %s to %s panicked: %v\n%s", id, r.URL.Path, recovered, debug.Stack()))
+
+			resp := ErrorResp{
+				Common:    Common{Type: ErrorRespName},
+				RequestID: id,
+				Status: ResponseStatus{
+					Common:  Common{Type: ResponseStatusName},
+					Success: false,
+					Message: "Internal server error.",
+				},
+			}
+
+			w.Header().Set("X-Request-ID", id)
+			srv.sendWithStatus(resp, http.StatusInternalServerError, w, r)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readinessMiddleware rejects requests with 503 Service Unavailable until Configure
+// has fully succeeded, so no request reaches a handler before database is migrated
+// and the admin user is stored.
+func (srv *HTTPRestServer) readinessMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !srv.ready.Load() {
+			resp := NotReadyResp{
+				Common: Common{Type: NotReadyRespName},
+				Status: ResponseStatus{
+					Common:  Common{Type: ResponseStatusName},
+					Success: false,
+					Message: "Server is starting, try again later.",
+				},
+			}
+
+			srv.sendWithStatus(resp, http.StatusServiceUnavailable, w, r)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// contentTypeMiddleware rejects requests carrying a body of other media type than
+// application/json with 415 Unsupported Media Type. Requests without body are let through,
+// so GET requests and endpoints with optional body do not need to set the header.
+func (srv *HTTPRestServer) contentTypeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, srv.cfg.PathPrefix)
+
+		/* Attachments are raw files, their allowlist is configurable */
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err == nil && (isAcceptedMediaType(path, mediaType) ||
+			(path == "/attachment" && srv.cfg.AttachmentTypeAllowed(mediaType))) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		resp := UnsupportedMediaResp{
+			Common: Common{Type: UnsupportedMediaRespName},
+			Status: ResponseStatus{
+				Common:  Common{Type: ResponseStatusName},
+				Success: false,
+				Message: fmt.Sprintf("Unsupported Content-Type %q, expected application/json.", r.Header.Get("Content-Type")),
+			},
+		}
+
+		srv.sendWithStatus(resp, http.StatusUnsupportedMediaType, w, r)
+	})
+}
+
+// timeoutMiddleware limits processing time of non-streaming requests to cfg.HandlerTimeout,
+// answering 503 when handler does not finish in time. Deadline is set on the request context
+// as well. It is independent from the socket write timeout, which also covers the time
+// a client takes to read the response.
+func (srv *HTTPRestServer) timeoutMiddleware(next http.Handler) http.Handler {
+	if srv.cfg.HandlerTimeout <= 0 {
+		return next
+	}
+
+	body, _ := srv.marshal(HandlerTimeoutResp{
+		Common: Common{Type: HandlerTimeoutRespName},
+		Status: ResponseStatus{
+			Common:  Common{Type: ResponseStatusName},
+			Success: false,
+			Message: fmt.Sprintf("Request not processed within %s.", srv.cfg.HandlerTimeout),
+		},
+	})
+
+	limited := http.TimeoutHandler(next, srv.cfg.HandlerTimeout, string(body))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if streamingEndpoints[strings.TrimPrefix(r.URL.Path, srv.cfg.PathPrefix)] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		/* Handler headers replace it, timeout response is sent with it */
+		w.Header().Set("Content-Type", JSONContentType)
+		limited.ServeHTTP(w, r)
+	})
+}
+
+// queryBudgetMiddleware gives every non-streaming request a budget of cfg.QueryBudget
+// database statements, charged by the repository returned by srv.repo. Once a statement
+// is refused, handler response is discarded and the request is answered with 500 here.
+// Requests which already changed the database are not refused, only logged.
+// Streaming endpoints may legitimately run any number of statements and are not limited.
+func (srv *HTTPRestServer) queryBudgetMiddleware(next http.Handler) http.Handler {
+	if srv.cfg.QueryBudget <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if streamingEndpoints[strings.TrimPrefix(r.URL.Path, srv.cfg.PathPrefix)] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		budget := &queryBudget{limit: int64(srv.cfg.QueryBudget)}
+		bw := &budgetWriter{ResponseWriter: w, budget: budget}
+
+		next.ServeHTTP(bw, r.WithContext(context.WithValue(r.Context(), budgetKey, budget)))
+
+		if !budget.exceeded() {
+			return
+		}
+
+		srv.logger(r).Error(fmt.Sprintf("Request %s ran %d database statements, query budget is %d.",
+			r.URL.Path, budget.used.Load(), srv.cfg.QueryBudget))
+
+		if budget.refused.Load() && !bw.wroteHeader {
+			srv.sendWithStatus(ErrorResp{
+				Common:    Common{Type: ErrorRespName},
+				RequestID: requestID(r),
+				Status: ResponseStatus{
+					Common:  Common{Type: ResponseStatusName},
+					Success: false,
+					Message: fmt.Sprintf("Request exceeded query budget of %d database statements.", srv.cfg.QueryBudget),
+				},
+			}, http.StatusInternalServerError, w, r)
+		}
+	})
+}
+
+func isAcceptedMediaType(path, mediaType string) bool {
+	/* Check whether endpoint under `path`, relative to the prefix, accepts body of `mediaType` */
+	if mediaType == "application/json" {
+		return true
+	}
+
+	for _, accepted := range acceptedMediaTypes[path] {
+		if mediaType == accepted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// apiVersionMiddleware sets the `X-API-Version` header with the API version on every response.
+// If request carries `X-API-Version` header with a different major version, e.g. `v2`,
+// it is rejected with 400 Bad Request before reaching the handler.
+func (srv *HTTPRestServer) apiVersionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-API-Version", Version)
+
+		if requested := r.Header.Get("X-API-Version"); requested != "" && majorVersion(requested) != majorVersion(Version) {
+			resp := InvalidAPIVersionResp{
+				Common: Common{Type: InvalidAPIVersionRespName},
+				Status: ResponseStatus{
+					Common:  Common{Type: ResponseStatusName},
+					Success: false,
+					Message: fmt.Sprintf("Requested API version %s is not compatible with %s.", requested, Version),
+				},
+				Version: Version,
+			}
+
+			srv.sendWithStatus(resp, http.StatusBadRequest, w, r)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func requestID(r *http.Request) string {
+	/* Return request ID assigned by requestIDMiddleware, sent by the client or a new one */
+	if id, ok := r.Context().Value(requestIDKey).(string); ok {
+		return id
+	}
+
+	if id := r.Header.Get("X-Request-ID"); id != "" {
+		return id
+	}
+
+	return newRequestID()
+}
+
+// clientIP returns address of the client which sent the request. `X-Forwarded-For` and
+// `X-Real-IP` headers are honoured only when the immediate peer is a trusted proxy,
+// otherwise anyone could spoof them and the peer address is returned.
+func (srv *HTTPRestServer) clientIP(r *http.Request) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+
+	addr, err := netip.ParseAddr(peer)
+	if err != nil || !srv.isTrustedProxy(addr) {
+		return peer
+	}
+
+	/* Walk the chain from the nearest hop, the first untrusted address is the client */
+	var forwarded []string
+
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(value, ",")...)
+	}
+
+	client := ""
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+
+		client = hop.String()
+
+		if !srv.isTrustedProxy(hop) {
+			return client
+		}
+	}
+
+	if client != "" {
+		return client
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.String()
+	}
+
+	return peer
+}
+
+func (srv *HTTPRestServer) isTrustedProxy(addr netip.Addr) bool {
+	/* Check whether address belongs to any of cfg.TrustedProxies */
+	addr = addr.Unmap()
+
+	for _, prefix := range srv.cfg.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func connContext(ctx context.Context, conn net.Conn) context.Context {
+	/* Expose connection to handlers, so streaming ones can extend write deadline */
+	return context.WithValue(ctx, connKey, conn)
+}
+
+// deadlineWriter extends the connection write deadline before every write, so a streamed
+// download is limited by how long a single write stalls rather than by its total time.
+type deadlineWriter struct {
+	w io.Writer
+	r *http.Request
+	d time.Duration
+}
+
+func (dw deadlineWriter) Write(p []byte) (int, error) {
+	extendWriteDeadline(dw.r, dw.d)
+	return dw.w.Write(p)
+}
+
+func extendWriteDeadline(r *http.Request, d time.Duration) {
+	/* Move write deadline of the request connection `d` from now. Server WriteTimeout
+	 * limits whole response, long-lived streams extend it before every write instead.
+	 */
+	if conn, ok := r.Context().Value(connKey).(net.Conn); ok {
+		_ = conn.SetWriteDeadline(time.Now().Add(d))
+	}
+}
+
+func (srv *HTTPRestServer) logger(r *http.Request) *logger.ConsoleLogger {
+	/* Return logger of the request, server logger when request did not pass requestIDMiddleware */
+	if log, ok := r.Context().Value(loggerKey).(*logger.ConsoleLogger); ok {
+		return log
+	}
+
+	return srv.log
+}
+
+func newRequestID() string {
+	/* Generate random 128-bit request ID */
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(id)
+}
+
+func majorVersion(version string) string {
+	/* Return major part of a version like `v1.1.0`, `1.2` or `v1` */
+	major, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+
+	return major
+}
@@ -0,0 +1,314 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"encoding/json"
+	"eventshub/config"
+	logger "eventshub/logging"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_APIVersionNegotiation(t *testing.T) {
+	/* GIVEN a handler wrapped with apiVersionMiddleware
+	 * WHEN requests with different X-API-Version headers are served
+	 * THEN every response should carry X-API-Version header
+	 * AND requests with incompatible major version should be rejected with 400
+	 */
+	t.Parallel()
+
+	srv := &HTTPRestServer{cfg: config.Default(), clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+	handler := srv.apiVersionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range []struct {
+		requested string
+		code      int
+	}{
+		{"", http.StatusOK},
+		{Version, http.StatusOK},
+		{"v1", http.StatusOK},
+		{"1.0.3", http.StatusOK},
+		{"v2", http.StatusBadRequest},
+		{"v2.0.0", http.StatusBadRequest},
+		{"v0.9", http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody)
+		if tc.requested != "" {
+			req.Header.Set("X-API-Version", tc.requested)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, tc.code, rec.Code, tc.requested)
+		assert.Equal(t, Version, rec.Header().Get("X-API-Version"), tc.requested)
+	}
+}
+
+func Test_RequestsBeforeReadinessAreRejected(t *testing.T) {
+	/* GIVEN a handler wrapped with readinessMiddleware
+	 * WHEN it is requested before the server is ready
+	 * THEN 503 should be returned without reaching the handler
+	 * AND once ready, the request should reach the handler
+	 */
+	t.Parallel()
+
+	var reached bool
+
+	srv := &HTTPRestServer{cfg: config.Default(), clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+	handler := srv.readinessMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		reached = true
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.False(t, reached)
+
+	srv.ready.Store(true)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, reached)
+}
+
+func Test_UnsupportedContentTypeIsRejected(t *testing.T) {
+	/* GIVEN a handler wrapped with contentTypeMiddleware
+	 * WHEN requests with different Content-Type headers are served
+	 * THEN bodies other than JSON should be rejected with 415
+	 * AND requests without body should be let through regardless of the header
+	 */
+	t.Parallel()
+
+	srv := &HTTPRestServer{cfg: config.Default(), clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+	handler := srv.contentTypeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range []struct {
+		path        string
+		contentType string
+		body        string
+		code        int
+	}{
+		{"/api/v1/insertEvent", "application/json", `{}`, http.StatusOK},
+		{"/api/v1/insertEvent", "application/json; charset=utf-8", `{}`, http.StatusOK},
+		{"/api/v1/insertEvent", "text/plain", `{}`, http.StatusUnsupportedMediaType},
+		{"/api/v1/insertEvent", "", `{}`, http.StatusUnsupportedMediaType},
+		{"/api/v1/insertEvent", "application/x-ndjson", `{}`, http.StatusUnsupportedMediaType},
+		{"/api/v1/importStream", "application/x-ndjson", `{}`, http.StatusOK},
+		{"/api/v1/getEventsByPriority", "text/plain", ``, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, tc.code, rec.Code, tc.path+" "+tc.contentType)
+	}
+}
+
+func Test_SlowHandlerTimesOutExceptStreams(t *testing.T) {
+	/* GIVEN a handler taking longer than configured handler timeout
+	 * WHEN it is requested on regular and streaming endpoints
+	 * THEN regular endpoint should answer 503 with a JSON status
+	 * AND streaming endpoint should let the handler finish
+	 */
+	t.Parallel()
+
+	cfg := config.Default()
+	cfg.HandlerTimeout = 10 * time.Millisecond
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+	handler := srv.timeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var resp HandlerTimeoutResp
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, JSONContentType, rec.Header().Get("Content-Type"))
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, HandlerTimeoutRespName, resp.Type)
+	assert.False(t, resp.Status.Success)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/backup", http.NoBody))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func Test_PanickingHandlerIsRecovered(t *testing.T) {
+	/* GIVEN a server with a handler which panics
+	 * WHEN the handler is requested
+	 * THEN 500 with JSON ErrorResp carrying a request ID should be returned
+	 * AND the server should keep serving subsequent requests
+	 */
+	t.Parallel()
+
+	var resp ErrorResp
+
+	srv := &HTTPRestServer{cfg: config.Default(), clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(_ http.ResponseWriter, _ *http.Request) {
+		var settings map[string]string
+
+		settings["boom"] = "nil map"
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := httptest.NewServer(srv.recoveryMiddleware(mux))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		res, err := http.Get(server.URL + "/panic")
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+		assert.Equal(t, ErrorRespName, resp.Type)
+		assert.NotEmpty(t, resp.RequestID)
+		assert.Equal(t, resp.RequestID, res.Header.Get("X-Request-ID"))
+		res.Body.Close()
+
+		res, err = http.Get(server.URL + "/ok")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		res.Body.Close()
+	}
+}
+
+func Test_RequestIDIsPropagated(t *testing.T) {
+	/* GIVEN a handler wrapped with requestIDMiddleware
+	 * WHEN a request carrying X-Request-ID is served
+	 * THEN the same ID should be echoed in the response header and seen by the handler
+	 * AND requests without the header should get a generated ID
+	 */
+	t.Parallel()
+
+	var seen string
+
+	srv := &HTTPRestServer{cfg: config.Default(), clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+	handler := srv.requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestID(r)
+
+		assert.True(t, srv.log != srv.logger(r), "request should have its own logger")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody)
+	req.Header.Set("X-Request-ID", "client-generated-id")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, "client-generated-id", rec.Header().Get("X-Request-ID"))
+	assert.Equal(t, "client-generated-id", seen)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody))
+
+	assert.Len(t, rec.Header().Get("X-Request-ID"), 32)
+	assert.Equal(t, rec.Header().Get("X-Request-ID"), seen)
+}
+
+func Test_UnsafeRequestIDIsReplaced(t *testing.T) {
+	/* GIVEN a handler wrapped with requestIDMiddleware
+	 * WHEN requests carry IDs with spaces, key=value pairs, line breaks or over 64 characters
+	 * THEN each of them should get a generated ID instead
+	 * AND an ID of exactly 64 allowed characters should be kept
+	 */
+	t.Parallel()
+
+	srv := &HTTPRestServer{cfg: config.Default(), clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+	handler := srv.requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	served := func(id string) string {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody)
+		req.Header.Set("X-Request-ID", id)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec.Header().Get("X-Request-ID")
+	}
+
+	for _, unsafe := range []string{"abc client=10.0.0.1", "id\tforged", "a/b", strings.Repeat("a", 65)} {
+		id := served(unsafe)
+		assert.NotEqual(t, unsafe, id)
+		assert.Len(t, id, 32, unsafe)
+	}
+
+	allowed := strings.Repeat("A-z.0_", 10) + "abcd"
+	assert.Equal(t, allowed, served(allowed))
+}
+
+func Test_ClientIPHonoursOnlyTrustedProxies(t *testing.T) {
+	/* GIVEN a server trusting proxies of 10.0.0.0/8
+	 * WHEN requests with forwarding headers come from different peers
+	 * THEN headers should be used only when the peer is a trusted proxy
+	 * AND the nearest untrusted address of X-Forwarded-For should be the client
+	 */
+	t.Parallel()
+
+	cfg := config.Default()
+	cfg.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+
+	for _, tc := range []struct {
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		expected   string
+	}{
+		{"203.0.113.9:4000", "198.51.100.1", "198.51.100.2", "203.0.113.9"},
+		{"10.1.2.3:4000", "", "", "10.1.2.3"},
+		{"10.1.2.3:4000", "198.51.100.1", "", "198.51.100.1"},
+		{"10.1.2.3:4000", "192.0.2.66, 198.51.100.1, 10.0.0.5", "", "198.51.100.1"},
+		{"10.1.2.3:4000", "10.0.0.7, 10.0.0.5", "", "10.0.0.7"},
+		{"10.1.2.3:4000", "", "198.51.100.2", "198.51.100.2"},
+		{"10.1.2.3:4000", "not-an-ip", "garbage", "10.1.2.3"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody)
+		req.RemoteAddr = tc.remoteAddr
+
+		if tc.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tc.forwarded)
+		}
+
+		if tc.realIP != "" {
+			req.Header.Set("X-Real-IP", tc.realIP)
+		}
+
+		assert.Equal(t, tc.expected, srv.clientIP(req), tc.remoteAddr+" "+tc.forwarded)
+	}
+}
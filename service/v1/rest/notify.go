@@ -0,0 +1,182 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"eventshub/config"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// DueReminder is a reminder of an event which should be delivered to its owner.
+type DueReminder struct {
+	Username string
+	Email    string
+	Event    EventData
+}
+
+// Notifier delivers reminders of events, e.g. by email.
+type Notifier interface {
+	Notify(reminder DueReminder) error
+}
+
+// SMTPNotifier sends every reminder as a plain text email through configured mail server.
+// Whole delivery of a reminder is limited by SMTPTimeout, so an unresponsive server can
+// neither stall other reminders nor the shutdown.
+type SMTPNotifier struct {
+	cfg     config.SMTP
+	clock   Clock
+	timeout time.Duration
+}
+
+func NewSMTPNotifier(cfg config.SMTP, clock Clock) *SMTPNotifier {
+	return &SMTPNotifier{cfg: cfg, clock: clock, timeout: SMTPTimeout}
+}
+
+func (n *SMTPNotifier) Notify(reminder DueReminder) error {
+	/* Same exchange as smtp.SendMail, which has no timeout, over connection with a deadline */
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(n.cfg.Host, n.cfg.Port), n.timeout)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if err = conn.SetDeadline(time.Now().Add(n.timeout)); err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		return err
+	}
+
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: n.cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+
+	/* Authenticate only when credentials are configured, net/smtp refuses to send
+	 * them over unencrypted connection to other host than localhost.
+	 */
+	if n.cfg.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(n.cfg.From); err != nil {
+		return err
+	}
+
+	if err = client.Rcpt(reminder.Email); err != nil {
+		return err
+	}
+
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = data.Write(reminderMessage(n.cfg.From, reminder, n.clock.Now())); err != nil {
+		return err
+	}
+
+	if err = data.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func reminderMessage(from string, reminder DueReminder, now time.Time) []byte {
+	/* Format RFC 5322 message, user provided text never reaches headers unencoded */
+	var msg bytes.Buffer
+
+	e := reminder.Event
+	header := func(name, value string) {
+		fmt.Fprintf(&msg, "%s: %s\r\n", name, value)
+	}
+
+	header("From", from)
+	header("To", reminder.Email)
+	header("Subject", mime.QEncoding.Encode("utf-8", "Reminder: "+singleLine(e.Title)))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "8bit")
+	msg.WriteString("\r\n")
+
+	fmt.Fprintf(&msg, "Hello %s,\r\n\r\n", reminder.Username)
+	fmt.Fprintf(&msg, "%s\r\n", singleLine(e.Title))
+	fmt.Fprintf(&msg, "Start: %04d-%02d-%02d %02d:%02d\r\n", e.Start.Year, e.Start.Month, e.Start.Day, e.Start.Hour, e.Start.Minute)
+	fmt.Fprintf(&msg, "End:   %04d-%02d-%02d %02d:%02d\r\n", e.End.Year, e.End.Month, e.End.Day, e.End.Hour, e.End.Minute)
+
+	if e.Address != "" {
+		fmt.Fprintf(&msg, "Where: %s\r\n", singleLine(e.Address))
+	}
+
+	if e.Info != "" {
+		fmt.Fprintf(&msg, "\r\n%s\r\n", strings.ReplaceAll(strings.ReplaceAll(e.Info, "\r\n", "\n"), "\n", "\r\n"))
+	}
+
+	return msg.Bytes()
+}
+
+func singleLine(text string) string {
+	/* Fold line breaks, they would end a header or break message layout */
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func (srv *HTTPRestServer) runReminders(ctx context.Context, interval time.Duration) {
+	/* Periodically deliver due reminders until ctx is cancelled */
+	defer srv.background.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		srv.deliverReminders()
+
+		select {
+		case <-ctx.Done():
+			srv.log.Info("Reminders stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (srv *HTTPRestServer) deliverReminders() {
+	/* Send due reminders, failures are only logged. Reminder not marked as delivered
+	 * stays due, so it is retried with the next run.
+	 */
+	reminders, err := srv.db.GetDueReminders(srv.clock.Now().Unix())
+	if err != nil {
+		srv.log.Error("Reading due reminders failed. ", err)
+		return
+	}
+
+	for _, reminder := range reminders {
+		if err = srv.notifier.Notify(reminder); err != nil {
+			srv.log.Warning("Sending reminder of ", reminder.Event.UUID, " to ", reminder.Username, " failed, will retry. ", err)
+			continue
+		}
+
+		if err = srv.db.MarkReminded(reminder.Event.UUID); err != nil {
+			srv.log.Error("Marking reminder of ", reminder.Event.UUID, " failed. ", err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"bufio"
+	"errors"
+	"eventshub/config"
+	logger "eventshub/logging"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpMessage is a message received by mockSMTPServer.
+type smtpMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+func mockSMTPServer(t *testing.T) (string, string, <-chan smtpMessage) {
+	/* Accept connections speaking just enough SMTP for net/smtp.SendMail without auth or TLS */
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan smtpMessage, 10)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			serveSMTP(conn, messages)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	return host, port, messages
+}
+
+func serveSMTP(conn net.Conn, messages chan<- smtpMessage) {
+	defer conn.Close()
+
+	var msg smtpMessage
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP mock")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			msg.From = strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			msg.To = append(msg.To, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				if dataLine == ".\r\n" {
+					break
+				}
+
+				data.WriteString(dataLine)
+			}
+
+			msg.Data = data.String()
+			messages <- msg
+			msg = smtpMessage{}
+
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// remindersRepo is a DatabaseRepo with one due reminder until it is marked as delivered.
+type remindersRepo struct {
+	DatabaseRepo
+	due    []DueReminder
+	marked []string
+}
+
+func (r *remindersRepo) GetDueReminders(now int64) ([]DueReminder, error) {
+	return r.due, nil
+}
+
+func (r *remindersRepo) MarkReminded(uuid string) error {
+	r.marked = append(r.marked, uuid)
+	r.due = nil
+
+	return nil
+}
+
+// failingNotifier fails to deliver first `failures` reminders and then forwards them.
+type failingNotifier struct {
+	Notifier
+	failures int
+}
+
+func (n *failingNotifier) Notify(reminder DueReminder) error {
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("421 service not available")
+	}
+
+	return n.Notifier.Notify(reminder)
+}
+
+func Test_UnresponsiveMailServerTimesOut(t *testing.T) {
+	/* GIVEN a mail server accepting connections but never answering
+	 * WHEN a reminder is sent through it
+	 * THEN sending should fail once the timeout passes instead of blocking
+	 */
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	/* Connections are kept open and silent until the listener is closed */
+	go func() {
+		var conns []net.Conn
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				break
+			}
+
+			conns = append(conns, conn)
+		}
+
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	sut := NewSMTPNotifier(config.SMTP{Host: host, Port: port, From: "eventshub@example.com"}, SystemClock{})
+	sut.timeout = 100 * time.Millisecond
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- sut.Notify(DueReminder{Username: "alice", Email: "alice@example.com", Event: TestEvent1})
+	}()
+
+	select {
+	case err := <-done:
+		var netErr net.Error
+
+		assert.ErrorAs(t, err, &netErr)
+		assert.True(t, netErr.Timeout())
+	case <-time.After(5 * time.Second):
+		t.Fatal("sending to unresponsive mail server did not time out")
+	}
+}
+
+func Test_DueReminderIsEmailedToOwner(t *testing.T) {
+	/* GIVEN a due reminder of an event and a mail server failing at first
+	 * WHEN reminders are delivered twice
+	 * THEN the first failure should leave the reminder due
+	 * AND the second run should email it to the owner and mark it as delivered
+	 */
+	host, port, messages := mockSMTPServer(t)
+
+	e := TestEvent1
+	e.Title = "Urodziny Łukasza\r\nBcc: everyone@example.com"
+
+	repo := &remindersRepo{due: []DueReminder{{Username: "alice", Email: "alice@example.com", Event: e}}}
+	clock := &fixedClock{now: time.Date(2021, 1, 5, 9, 0, 0, 0, time.UTC)}
+
+	srv := &HTTPRestServer{cfg: config.Default(), clock: clock, db: repo,
+		log: logger.NewConsoleLogger("TEST", logger.CRITICAL)}
+	srv.notifier = &failingNotifier{
+		Notifier: NewSMTPNotifier(config.SMTP{Host: host, Port: port, From: "eventshub@example.com"}, clock),
+		failures: 1,
+	}
+
+	srv.deliverReminders()
+	assert.Empty(t, repo.marked)
+	assert.Len(t, repo.due, 1)
+
+	srv.deliverReminders()
+	assert.Equal(t, []string{e.UUID}, repo.marked)
+
+	select {
+	case msg := <-messages:
+		assert.Equal(t, "eventshub@example.com", msg.From)
+		assert.Equal(t, []string{"alice@example.com"}, msg.To)
+		assert.Contains(t, msg.Data, "To: alice@example.com\r\n")
+		assert.Contains(t, msg.Data, "Subject: =?utf-8?q?Reminder:_Urodziny_=C5=81ukasza_Bcc:_everyone@example.com?=\r\n")
+		assert.Contains(t, msg.Data, "Start: 2021-01-12 00:00\r\n")
+		assert.Contains(t, msg.Data, "Where: "+e.Address+"\r\n")
+		assert.NotContains(t, msg.Data, "\r\nBcc:")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message reached the mail server")
+	}
+
+	srv.deliverReminders()
+	assert.Len(t, repo.marked, 1)
+}
@@ -8,10 +8,18 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"eventshub/config"
 	logger "eventshub/logging"
+	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const (
@@ -19,110 +27,366 @@ const (
 	ReadHeaderTimeout time.Duration = 2 * time.Second
 	ReadTimeout       time.Duration = 1 * time.Second
 	ShutdownTimeout   time.Duration = 10 * time.Second
-	WriteTimeout      time.Duration = 5 * time.Second
+	JanitorInterval   time.Duration = 1 * time.Hour
 	VERSION           string        = "1.1.0"
 )
 
 type HTTPRestServer struct {
-	db            DatabaseRepo
-	log           *logger.ConsoleLogger
-	server        *http.Server
-	sigs          chan os.Signal
-	deadlyPackage string
+	blobs          BlobStore
+	bus            *EventBus
+	cfg            *config.Config
+	clock          Clock
+	db             DatabaseRepo
+	lockout        loginLockout
+	log            *logger.ConsoleLogger
+	notifier       Notifier
+	diskFree       func(dir string) (uint64, error)
+	server         *http.Server
+	grpc           *grpc.Server
+	sigs           chan os.Signal
+	deadlyPackage  string
+	background     sync.WaitGroup
+	stopBackground context.CancelFunc
+	stopping       <-chan struct{}
+	ready          atomic.Bool
+	streams        atomic.Int64
 }
 
-func (srv *HTTPRestServer) Configure(sigs chan os.Signal) {
+func (srv *HTTPRestServer) Configure(sigs chan os.Signal, cfg *config.Config) {
 	var (
 		err error
 		db  *sql.DB
 	)
 
 	srv.sigs = sigs
+	srv.cfg = cfg
+
+	if srv.clock == nil {
+		srv.clock = SystemClock{}
+	}
+
+	srv.bus = NewEventBus()
 
 	srv.log = logger.NewConsoleLogger("SERVER", logger.DEBUG)
 	srv.log.Info("Configuring server.")
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/api/v1/version", srv.serverVersionHandler)
-	mux.HandleFunc("/api/v1/login", srv.loginHandler)
-	mux.HandleFunc("/api/v1/insertEvent", srv.insertEvent)
-	mux.HandleFunc("/api/v1/getEventCheckSum", srv.getEventCheckSum)
-	mux.HandleFunc("/api/v1/getEventsWithinTimeRange", srv.getEventsWithinTimeRange)
-	mux.HandleFunc("/api/v1/status", srv.getStatus)
-	mux.HandleFunc("/api/v1/ki11s3rv3rn0w", srv.killserver)
-
-	host := os.Getenv("GOCALENDAR_HOST")
-
-	if host == "" {
-		err = errors.New("failed to obtain host")
-		srv.log.Critical(err)
-		panic(err)
+	if len(cfg.Features.Unknown) > 0 {
+		srv.log.Warning("Ignoring unknown features: ", strings.Join(cfg.Features.Unknown, ", "))
 	}
 
-	port := os.Getenv("GOCALENDAR_PORT")
+	mux := srv.routes()
 
-	if port == "" {
-		err = errors.New("failed to obtain port")
-		srv.log.Critical(err)
-		panic(err)
-	}
-
-	if deadlyPackage := os.Getenv("GOCALENDAR_DEADLY_PACKAGE"); deadlyPackage == "" {
-		err = errors.New("failed to obtain deadly package")
-		srv.log.Critical(err)
+	if cfg.Features.Kill && cfg.DeadlyPackage == "" {
+		srv.log.Critical(errors.New("failed to obtain deadly package"))
 	} else {
-		srv.deadlyPackage = deadlyPackage
+		srv.deadlyPackage = cfg.DeadlyPackage
 	}
 
-	srv.log.Info("Server will listen on ", host, ":", port)
+	srv.log.Info("Server will listen on ", cfg.Host, ":", cfg.Port)
+
+	if cfg.GRPCPort != "" {
+		srv.log.Info("gRPC server will listen on ", cfg.Host, ":", cfg.GRPCPort)
+	}
+	srv.log.Info("Effective configuration: ", srv.configSummary())
 
 	srv.server = &http.Server{
 		ReadTimeout:       ReadTimeout,
-		WriteTimeout:      WriteTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
 		IdleTimeout:       IdleTimeout,
 		ReadHeaderTimeout: ReadHeaderTimeout,
-		Addr:              host + ":" + port,
-		Handler:           mux,
+		Addr:              cfg.Host + ":" + cfg.Port,
+		Handler:           srv.withMiddleware(mux),
+		ConnContext:       connContext,
 	}
 
-	db, err = sql.Open("sqlite3", SQLFile)
-	if err != nil {
+	/* Unknown algorithm would only surface at the first login */
+	if _, err = passwordHasher(cfg.PasswordAlgo); err != nil {
 		srv.log.Critical(err)
 		panic(err)
 	}
 
-	srv.db = NewSQLiteRepository(db)
-
-	err = srv.db.Migrate()
+	/* Database may not be ready yet when the server starts, wait for it instead of crashing */
+	err = srv.retryStartup("Opening database", func() (err error) {
+		db, err = openDatabase(cfg.DatabaseFile)
+		return err
+	})
 	if err != nil {
 		srv.log.Critical(err)
 		panic(err)
 	}
 
-	/* Store hashed password for the user */
-	adminUsername := os.Getenv("GOCALENDAR_ADMIN_USERNAME")
-	if adminUsername == "" {
-		err = errors.New("failed to obtain adminUsername")
+	repo := NewSQLiteRepository(db, cfg)
+	repo.clock = srv.clock
+	repo.bus = srv.bus
+	srv.db = repo
+
+	err = srv.retryStartup("Migrating database", srv.db.Migrate)
+	if err != nil {
 		srv.log.Critical(err)
 		panic(err)
 	}
 
-	adminHash := os.Getenv("GOCALENDAR_ADMIN_HASH")
-	if adminHash == "" {
-		err = errors.New("failed to obtain adminHash")
+	/* Store hashed password for the user, admin may already exist in persistent database */
+	err = srv.db.UpsertUser(cfg.AdminUsername, cfg.AdminHash, true)
+	if err != nil {
 		srv.log.Critical(err)
 		panic(err)
 	}
 
-	err = srv.db.AddUser(adminUsername, adminHash, true)
+	if cfg.AttachmentsDir != "" {
+		srv.blobs, err = NewLocalBlobStore(cfg.AttachmentsDir)
+		if err != nil {
+			srv.log.Critical(err)
+			panic(err)
+		}
+
+		/* Repository removes blobs of attachments whose events it deletes */
+		repo.blobs = srv.blobs
+
+		srv.log.Info("Attachments will be stored in ", cfg.AttachmentsDir, ".")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	srv.stopBackground = cancel
+	srv.stopping = ctx.Done()
+
+	/* Long-lived streams end as soon as shutdown starts instead of delaying it */
+	srv.server.RegisterOnShutdown(cancel)
+
+	if cfg.EventTTLDays > 0 {
+		srv.log.Info("Events older than ", cfg.EventTTLDays, " days will be deleted.")
+		srv.background.Add(1)
+
+		go srv.runJanitor(ctx, JanitorInterval)
+	}
+
+	if cfg.SMTP.Enabled() && !cfg.Features.Reminders {
+		srv.log.Warning("SMTP is configured, but reminders feature is disabled, no reminders will be sent.")
+	}
+
+	if cfg.SMTP.Enabled() && cfg.Features.Reminders {
+		srv.log.Info("Reminders will be sent through ", cfg.SMTP.Host, ".")
+		srv.notifier = NewSMTPNotifier(cfg.SMTP, srv.clock)
+		srv.background.Add(1)
+
+		go srv.runReminders(ctx, cfg.ReminderInterval)
+	}
+
+	srv.ready.Store(true)
+	srv.log.Info("Server is ready.")
+}
+
+func (srv *HTTPRestServer) configSummary() string {
+	/* Describe effective configuration as a single line of key=value pairs.
+	 * Secrets are never printed, only whether they are set.
+	 */
+	redacted := func(secret string) string {
+		if secret == "" {
+			return "unset"
+		}
+
+		return "<redacted>"
+	}
+
+	onOff := func(on bool) string {
+		if on {
+			return "on"
+		}
+
+		return "off"
+	}
+
+	features := strings.Join(srv.cfg.Features.Enabled(), ",")
+	if features == "" {
+		features = "none"
+	}
+
+	pairs := []struct {
+		key   string
+		value any
+	}{
+		{"host", srv.cfg.Host},
+		{"port", srv.cfg.Port},
+		{"grpc_port", srv.cfg.GRPCPort},
+		{"path_prefix", srv.cfg.PathPrefix},
+		{"health_path_prefix", srv.cfg.HealthPathPrefix},
+		{"tls", onOff(srv.cfg.CertificatePath != "" && srv.cfg.SigningKeyPath != "")},
+		{"db_driver", "sqlite3"},
+		{"db_path", srv.cfg.DatabaseFile},
+		{"timezone", srv.cfg.TimeZone},
+		{"token_ttl", tokenLifeTime},
+		{"jwt_leeway", srv.cfg.JWTLeeway},
+		{"handler_timeout", srv.cfg.HandlerTimeout},
+		{"write_timeout", srv.cfg.WriteTimeout},
+		{"slow_query", srv.cfg.SlowQuery},
+		{"log_level", logger.LevelName(srv.log.Level())},
+		{"features", features},
+		{"instance", srv.cfg.InstanceName},
+		{"admin_user", srv.cfg.AdminUsername},
+		{"smtp", onOff(srv.cfg.SMTP.Enabled())},
+		{"field_encryption", onOff(len(srv.cfg.FieldEncryptionKey) > 0)},
+		{"admin_hash", redacted(srv.cfg.AdminHash)},
+		{"password_algo", srv.cfg.PasswordAlgo},
+		{"token_secret", redacted(srv.cfg.TokenSecret)},
+	}
+
+	summary := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		summary = append(summary, fmt.Sprintf("%s=%v", pair.key, pair.value))
+	}
+
+	return strings.Join(summary, " ")
+}
+
+func (srv *HTTPRestServer) runJanitor(ctx context.Context, interval time.Duration) {
+	/* Periodically delete expired events until ctx is cancelled */
+	defer srv.background.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		srv.deleteExpiredEvents()
+
+		select {
+		case <-ctx.Done():
+			srv.log.Info("Janitor stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (srv *HTTPRestServer) deleteExpiredEvents() {
+	/* Delete events which ended more than cfg.EventTTLDays ago */
+	cutoff := srv.clock.Now().AddDate(0, 0, -srv.cfg.EventTTLDays).Unix()
+
+	deleted, err := srv.db.DeleteEventsEndedBefore(cutoff)
+	if err != nil {
+		srv.log.Error("Deleting expired events failed. ", err)
+		return
+	}
+
+	srv.log.Info("Deleted ", deleted, " expired events.")
+}
+
+func (srv *HTTPRestServer) routes() *http.ServeMux {
+	/* Register handlers under configured prefixes, routes of disabled features are left out and respond with 404.
+	 * Status endpoints have own prefix, so health checks of a gateway can reach them at a separate path.
+	 */
+	api, health := srv.cfg.PathPrefix, srv.cfg.HealthPathPrefix
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(api+"/version", srv.serverVersionHandler)
+	mux.HandleFunc(api+"/time", srv.serverTime)
+	mux.HandleFunc(api+"/login", srv.loginHandler)
+	mux.HandleFunc(api+"/validateToken", srv.validateToken)
+	mux.HandleFunc(api+"/insertEvent", srv.insertEvent)
+	mux.HandleFunc(api+"/getEventCheckSum", srv.getEventCheckSum)
+	mux.HandleFunc(api+"/planSync", srv.planSync)
+	mux.HandleFunc(api+"/getEventDetail", srv.getEventDetail)
+	mux.HandleFunc(api+"/getEventsWithinTimeRange", srv.getEventsWithinTimeRange)
+	mux.HandleFunc(api+"/getEventsCreatedBetween", srv.getEventsCreatedBetween)
+	mux.HandleFunc(api+"/getEventsByPriority", srv.getEventsByPriority)
+	mux.HandleFunc(api+"/getEventsByShortcut", srv.getEventsByShortcut)
+	mux.HandleFunc(api+"/getEventsByCategory", srv.getEventsByCategory)
+	mux.HandleFunc(api+"/getEventsByTitle", srv.getEventsByTitle)
+	mux.HandleFunc(api+"/countEvents", srv.countEvents)
+	mux.HandleFunc(api+"/getLocations", srv.getLocations)
+	mux.HandleFunc(api+"/findEventsByUuidPrefix", srv.findEventsByUUIDPrefix)
+	mux.HandleFunc(api+"/getOverlappingEvents", srv.getOverlappingEvents)
+	mux.HandleFunc(api+"/eventCountsByDay", srv.eventCountsByDay)
+	mux.HandleFunc(api+"/diffEvents", srv.diffEvents)
+	mux.HandleFunc(api+"/patchEvent", srv.patchEvent)
+	mux.HandleFunc(api+"/deleteEvents", srv.deleteEvents)
+	mux.HandleFunc(api+"/updateFlags", srv.updateFlags)
+	mux.HandleFunc(health+"/status", srv.getStatus)
+	mux.HandleFunc(health+"/statusHistory", srv.getStatusHistory)
+	mux.HandleFunc(health+"/readyz", srv.readyz)
+	mux.HandleFunc(api+"/backup", srv.requireAdmin(srv.backup))
+	mux.HandleFunc(api+"/export.csv", srv.exportCSV)
+	mux.HandleFunc(api+"/buildInfo", srv.requireAdmin(srv.buildInfo))
+	mux.HandleFunc(api+"/reassignEvents", srv.requireAdmin(srv.reassignEvents))
+	mux.HandleFunc(api+"/mergeEvents", srv.requireAdmin(srv.mergeEvents))
+	mux.HandleFunc(api+"/settings", srv.settings)
+	mux.HandleFunc(api+"/attachment", srv.attachment)
+	mux.HandleFunc(api+"/email", srv.userEmail)
+	mux.HandleFunc(api+"/myReminders", srv.myReminders)
+
+	if srv.cfg.Features.Import {
+		mux.HandleFunc(api+"/importStream", srv.importStream)
+		mux.HandleFunc(api+"/importFromURL", srv.importFromURL)
+	}
+
+	if srv.cfg.Features.Audit {
+		mux.HandleFunc(api+"/audit", srv.requireAdmin(srv.getAudit))
+	}
+
+	if srv.cfg.Features.Kill {
+		mux.HandleFunc(api+"/ki11s3rv3rn0w", srv.killserver)
+	}
+
+	if srv.cfg.Features.Streams {
+		mux.HandleFunc(api+"/events/stream", srv.streamEvents)
+		mux.HandleFunc(api+"/ws", srv.websocket)
+	}
+
+	return mux
+}
+
+func openDatabase(file string) (*sql.DB, error) {
+	/* Open database handle and make sure database is reachable */
+	db, err := sql.Open("sqlite3", file)
 	if err != nil {
-		srv.log.Critical(err)
-		panic(err)
+		return nil, err
+	}
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
 	}
+
+	return db, nil
 }
 
-func (srv *HTTPRestServer) Start() {
-	/* Starts HTTPRestServer as a goroutine. */
+func (srv *HTTPRestServer) retryStartup(operation string, fn func() error) error {
+	/* Run startup operation, retry it up to cfg.DBStartupRetries times with exponential backoff.
+	 * Error of the last attempt is returned once retries are exhausted.
+	 */
+	backoff := DatabaseStartupBackoff
+
+	for attempt := 1; ; attempt++ {
+		srv.log.Info(fmt.Sprintf("%s, attempt %d of %d.", operation, attempt, srv.cfg.DBStartupRetries+1))
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if attempt > srv.cfg.DBStartupRetries {
+			return err
+		}
+
+		srv.log.Warning(fmt.Sprintf("%s failed, retrying in %s. ", operation, backoff), err)
+
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > DatabaseStartupMaxBackoff {
+			backoff = DatabaseStartupMaxBackoff
+		}
+	}
+}
+
+func (srv *HTTPRestServer) Start() error {
+	/* Starts HTTPRestServer as a goroutine without TLS, so tokens and passwords travel
+	 * in clear text. Refused with ErrInsecureNotAllowed unless cfg.AllowInsecure is set.
+	 */
+	if !srv.cfg.AllowInsecure {
+		srv.log.Critical(ErrInsecureNotAllowed)
+		return ErrInsecureNotAllowed
+	}
+
 	srv.log.Warning("USING NOT SECURE PROTOCOL.")
 
 	go func() {
@@ -133,6 +397,16 @@ func (srv *HTTPRestServer) Start() {
 			srv.log.Error("HTTP REST Server error while listening. ", err)
 		}
 	}()
+
+	if srv.cfg.GRPCPort != "" {
+		srv.grpc = srv.newGRPCServer()
+
+		if err := srv.serveGRPC(); err != nil {
+			srv.log.Error("gRPC server error while listening. ", err)
+		}
+	}
+
+	return nil
 }
 
 func (srv *HTTPRestServer) StartTLS() {
@@ -140,10 +414,7 @@ func (srv *HTTPRestServer) StartTLS() {
 	srv.log.Info("Starting TLS server.")
 
 	go func() {
-		certificatePath := os.Getenv("GOCALENDAR_OPENSSL_CALENDAR_CERTIFICATE")
-		privatekeyPath := os.Getenv("GOCALENDAR_OPENSSL_CALENDAR_SIGNING_KEY")
-
-		err := srv.server.ListenAndServeTLS(certificatePath, privatekeyPath)
+		err := srv.server.ListenAndServeTLS(srv.cfg.CertificatePath, srv.cfg.SigningKeyPath)
 		if errors.Is(err, http.ErrServerClosed) {
 			srv.log.Error("HTTP REST Server is closed. ", err)
 		} else if err != nil {
@@ -152,6 +423,23 @@ func (srv *HTTPRestServer) StartTLS() {
 
 		srv.log.Warning("Stopped serving new connections")
 	}()
+
+	if srv.cfg.GRPCPort == "" {
+		return
+	}
+
+	/* gRPC clients use the same certificate as HTTPS ones */
+	creds, err := credentials.NewServerTLSFromFile(srv.cfg.CertificatePath, srv.cfg.SigningKeyPath)
+	if err != nil {
+		srv.log.Error("gRPC server error while loading certificate. ", err)
+		return
+	}
+
+	srv.grpc = srv.newGRPCServer(grpc.Creds(creds))
+
+	if err = srv.serveGRPC(); err != nil {
+		srv.log.Error("gRPC server error while listening. ", err)
+	}
 }
 
 func (srv *HTTPRestServer) Stop() error {
@@ -165,6 +453,20 @@ func (srv *HTTPRestServer) Stop() error {
 		srv.log.Error("HTTP shutdown error: ", err)
 	}
 
+	/* In-flight gRPC calls finish before the database they use is closed */
+	if srv.grpc != nil {
+		srv.grpc.GracefulStop()
+	}
+
+	if srv.stopBackground != nil {
+		srv.stopBackground()
+		srv.background.Wait()
+	}
+
+	if srv.db != nil {
+		srv.db.Shutdown()
+	}
+
 	srv.log.Info("Graceful shutdown complete.")
 
 	return nil
@@ -0,0 +1,258 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"context"
+	"errors"
+	"eventshub/config"
+	logger "eventshub/logging"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConfigureTwiceKeepsSingleAdmin(t *testing.T) {
+	/* GIVEN a persistent database shared between restarts
+	 * WHEN Configure() is called twice with different admin hash
+	 * THEN users table should contain a single admin row
+	 * AND its password should be the latest hash
+	 */
+	var (
+		count int
+		hash  string
+	)
+
+	cfg := config.Default()
+	cfg.Host = "localhost"
+	cfg.Port = "4789"
+	cfg.AdminUsername = "admin"
+	cfg.AdminHash = "first-hash"
+
+	first := HTTPRestServer{}
+	first.Configure(make(chan os.Signal, 1), cfg)
+
+	defer first.db.Shutdown()
+
+	cfg.AdminHash = "second-hash"
+
+	second := HTTPRestServer{}
+	second.Configure(make(chan os.Signal, 1), cfg)
+
+	defer second.db.Shutdown()
+
+	db := second.db.(*SQLiteRepository).handle()
+
+	err := db.QueryRow("SELECT COUNT(*) FROM users WHERE username = ?;", cfg.AdminUsername).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	err = db.QueryRow("SELECT password FROM users WHERE username = ?;", cfg.AdminUsername).Scan(&hash)
+	assert.NoError(t, err)
+	assert.Equal(t, "second-hash", hash)
+}
+
+// flakyRepo is a DatabaseRepo which Migrate fails given number of times.
+type flakyRepo struct {
+	DatabaseRepo
+	failures int
+	calls    int
+}
+
+func (r *flakyRepo) Migrate() error {
+	r.calls++
+	if r.calls <= r.failures {
+		return errors.New("database is not ready")
+	}
+
+	return nil
+}
+
+func Test_StartupRetriesMigrateUntilDatabaseIsReady(t *testing.T) {
+	/* GIVEN a repository which Migrate fails twice before it succeeds
+	 * WHEN migration is run with startup retries
+	 * THEN it should succeed after the third attempt
+	 * AND with too few retries the last error should be returned
+	 */
+	cfg := config.Default()
+	cfg.DBStartupRetries = 3
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+
+	repo := &flakyRepo{failures: 2}
+
+	assert.NoError(t, srv.retryStartup("Migrating database", repo.Migrate))
+	assert.Equal(t, 3, repo.calls)
+
+	cfg.DBStartupRetries = 1
+	repo = &flakyRepo{failures: 2}
+
+	assert.Error(t, srv.retryStartup("Migrating database", repo.Migrate))
+	assert.Equal(t, 2, repo.calls)
+}
+
+func Test_DisabledFeatureRouteIsNotFound(t *testing.T) {
+	/* GIVEN a server with only `import` feature enabled
+	 * WHEN routes of disabled and enabled features are requested without token
+	 * THEN disabled routes should respond with 404
+	 * AND enabled route should be reached and respond with 401
+	 */
+	cfg := config.Default()
+	cfg.Features = config.ParseFeatures(config.FeatureImport)
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+	mux := srv.routes()
+
+	for path, code := range map[string]int{
+		"/api/v1/audit":         http.StatusNotFound,
+		"/api/v1/ki11s3rv3rn0w": http.StatusNotFound,
+		"/api/v1/events/stream": http.StatusNotFound,
+		"/api/v1/ws":            http.StatusNotFound,
+		"/api/v1/importStream":  http.StatusUnauthorized,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, http.NoBody))
+
+		assert.Equal(t, code, rec.Code, path)
+	}
+}
+
+func Test_JanitorDeletesOnlyExpiredEvents(t *testing.T) {
+	/* GIVEN events which ended 40 and 10 days ago and one in the future
+	 * AND TTL of 30 days
+	 * WHEN janitor runs
+	 * THEN only the event which ended 40 days ago should be deleted
+	 * AND janitor should stop once its context is cancelled
+	 */
+	srv, _ := newTestServer(t)
+	srv.cfg.EventTTLDays = 30
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	srv.clock = &fixedClock{now: now}
+	srv.db.(*SQLiteRepository).clock = srv.clock
+
+	for uuid, end := range map[string]time.Time{
+		"a4000000000000000000000000000001": now.AddDate(0, 0, -40),
+		"a4000000000000000000000000000002": now.AddDate(0, 0, -10),
+		"a4000000000000000000000000000003": now.AddDate(0, 0, 5),
+	} {
+		event := TestEvent1
+		event.UUID = uuid
+		event.End, _ = unixToDateTime(func() *int64 { u := end.Unix(); return &u }(), srv.cfg.TimeZone)
+		event.Start = event.End
+
+		_, err := srv.db.InsertEvent(&event)
+		assert.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	srv.background.Add(1)
+
+	go srv.runJanitor(ctx, time.Hour)
+
+	cancel()
+	srv.background.Wait()
+
+	events, err := srv.db.GetAllEvents()
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	for _, e := range events {
+		assert.NotEqual(t, "a4000000000000000000000000000001", e.UUID)
+	}
+}
+
+func Test_ConfigSummaryRedactsSecrets(t *testing.T) {
+	/* GIVEN a server configured with secrets
+	 * WHEN configuration summary is built
+	 * THEN it should contain every expected key
+	 * AND neither the token secret nor the admin hash should be printed
+	 */
+	t.Parallel()
+
+	cfg := config.Default()
+	cfg.Host = "localhost"
+	cfg.Port = "4789"
+	cfg.AdminUsername = "admin"
+	cfg.AdminHash = "$2a$10$secret-hash"
+	cfg.TokenSecret = "very-secret-token"
+	cfg.Features = config.ParseFeatures("audit,kill")
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.INFO)}
+
+	summary := srv.configSummary()
+
+	for _, key := range []string{"host=localhost", "port=4789", "tls=off", "db_driver=sqlite3", "db_path=", "timezone=",
+		"token_ttl=2m0s", "log_level=INFO", "features=audit,kill", "token_secret=<redacted>"} {
+		assert.Contains(t, summary, key)
+	}
+
+	assert.NotContains(t, summary, cfg.TokenSecret)
+	assert.NotContains(t, summary, cfg.AdminHash)
+}
+
+func Test_RoutesFollowConfiguredPrefixes(t *testing.T) {
+	/* GIVEN a server with custom API and health path prefixes
+	 * WHEN endpoints are requested under custom and default prefixes
+	 * THEN they should be reached only under the configured ones
+	 */
+	cfg := config.Default()
+	cfg.PathPrefix = "/calendar/api"
+	cfg.HealthPathPrefix = "/health"
+
+	srv := &HTTPRestServer{cfg: cfg, clock: SystemClock{}, db: failingStatusRepo{},
+		log: logger.NewConsoleLogger("TEST", logger.CRITICAL)}
+	mux := srv.routes()
+
+	for path, code := range map[string]int{
+		"/calendar/api/version":     http.StatusUnauthorized,
+		"/calendar/api/insertEvent": http.StatusUnauthorized,
+		"/health/status":            http.StatusInternalServerError,
+		"/api/v1/version":           http.StatusNotFound,
+		"/api/v1/insertEvent":       http.StatusNotFound,
+		"/api/v1/status":            http.StatusNotFound,
+		"/calendar/api/status":      http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+
+		assert.Equal(t, code, rec.Code, path)
+	}
+}
+
+func Test_StartRefusesPlaintextWithoutOptIn(t *testing.T) {
+	/* GIVEN a server configured for a free local port without GOCALENDAR_ALLOW_INSECURE
+	 * WHEN Start is called
+	 * THEN ErrInsecureNotAllowed should be returned
+	 * AND nothing should listen on the port
+	 */
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := listener.Addr().String()
+	listener.Close()
+
+	srv := &HTTPRestServer{cfg: config.Default(), clock: SystemClock{},
+		log: logger.NewConsoleLogger("TEST", logger.CRITICAL), server: &http.Server{Addr: addr}}
+
+	assert.ErrorIs(t, srv.Start(), ErrInsecureNotAllowed)
+
+	time.Sleep(50 * time.Millisecond)
+
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err == nil {
+		conn.Close()
+	}
+
+	assert.Error(t, err)
+}
@@ -6,8 +6,9 @@ package v1rest
 
 import (
 	"errors"
+	"eventshub/config"
+	"fmt"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -17,26 +18,34 @@ const (
 	tokenLifeTime time.Duration = 2 * time.Minute
 )
 
+// Errors returned by validateJWT. Clients should refresh the token on
+// ErrTokenExpired and log in again on ErrTokenInvalid or ErrTokenMissing.
+var (
+	ErrTokenExpired = errors.New("token has expired")
+	ErrTokenInvalid = errors.New("token is invalid")
+	ErrTokenMissing = errors.New("failed to obtain token from HEADER")
+)
+
 // Create a JSON Web Token (JWT) based on an open standard (RFC 7519) based on the provided username.
-// The username parameter is the user's identifier.
+// The username parameter is the user's identifier, the token is signed with cfg.TokenSecret
+// and expires tokenLifeTime after the time provided by clock.
 // Returns a string representing the JWT token and an error if the token creation process fails.
-func createJWT(username string) (string, error) {
+func createJWT(cfg *config.Config, clock Clock, username string) (string, error) {
 	token := jwt.New(jwt.SigningMethodHS512)
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		claims["exp"] = time.Now().Add(tokenLifeTime).Unix()
+		claims["exp"] = clock.Now().Add(tokenLifeTime).Unix()
 		claims["authorized"] = true
 		claims["user"] = username
 	} else {
 		return "", errors.New("failed to obtain token claims")
 	}
 
-	secret := os.Getenv("GOCALENDAR_TOKEN_SECRET")
-	if secret == "" {
-		panic(errors.New("failed to obtain token secret"))
+	if cfg.TokenSecret == "" {
+		return "", errors.New("failed to obtain token secret")
 	}
 
-	tokenStr, err := token.SignedString([]byte(secret))
+	tokenStr, err := token.SignedString([]byte(cfg.TokenSecret))
 	if err != nil {
 		return "", err
 	}
@@ -44,9 +53,48 @@ func createJWT(username string) (string, error) {
 	return tokenStr, nil
 }
 
-func validateJWT(_ http.ResponseWriter, r *http.Request) (err error) {
+func validateJWT(cfg *config.Config, clock Clock, _ http.ResponseWriter, r *http.Request) (err error) {
+	_, err = parseJWT(cfg, clock, r)
+
+	return err
+}
+
+// tokenUsername returns the username the request token was issued for.
+func tokenUsername(cfg *config.Config, clock Clock, r *http.Request) (string, error) {
+	claims, err := parseJWT(cfg, clock, r)
+	if err != nil {
+		return "", err
+	}
+
+	return claimsUsername(claims)
+}
+
+func parseJWT(cfg *config.Config, clock Clock, r *http.Request) (jwt.MapClaims, error) {
+	/* Verify request token against time provided by clock and return its claims */
 	if r.Header["Token"] == nil {
-		return errors.New("failed to obtain token from HEADER")
+		return nil, ErrTokenMissing
+	}
+
+	return parseToken(cfg, clock, r.Header["Token"][0])
+}
+
+func claimsUsername(claims jwt.MapClaims) (string, error) {
+	/* Return user the token was issued for */
+	user, ok := claims["user"].(string)
+	if !ok || user == "" {
+		return "", fmt.Errorf("%w: failed to obtain user", ErrTokenInvalid)
+	}
+
+	return user, nil
+}
+
+func parseToken(cfg *config.Config, clock Clock, raw string) (jwt.MapClaims, error) {
+	/* Verify token against time provided by clock and return its claims.
+	 * Shared by HTTP handlers, which read the token from header, and gRPC ones reading metadata.
+	 * Oversized token is refused before spending time on decoding and verifying it.
+	 */
+	if size := len(raw); size > cfg.MaxTokenBytes {
+		return nil, fmt.Errorf("%w: token has %d bytes, at most %d allowed", ErrTokenInvalid, size, cfg.MaxTokenBytes)
 	}
 
 	// Receive the parsed token.
@@ -56,32 +104,36 @@ func validateJWT(_ http.ResponseWriter, r *http.Request) (err error) {
 			return nil, errors.New("unsupported signing method")
 		}
 
-		secret := os.Getenv("GOCALENDAR_TOKEN_SECRET")
-		if secret == "" {
-			panic(errors.New("failed to obtain token secret"))
+		if cfg.TokenSecret == "" {
+			return nil, errors.New("failed to obtain token secret")
 		}
 
-		return []byte(secret), nil
+		return []byte(cfg.TokenSecret), nil
+	}
+
+	/* Leeway tolerates clock drift between the token issuer and this server */
+	token, err := jwt.Parse(raw, keyFunc, jwt.WithTimeFunc(clock.Now), jwt.WithLeeway(cfg.JWTLeeway))
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
 	}
 
-	token, err := jwt.Parse(r.Header["Token"][0], keyFunc)
 	if token == nil || err != nil {
-		return errors.New("there was an error during token parsing")
+		return nil, fmt.Errorf("%w: %v", ErrTokenInvalid, err)
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return errors.New("there was an error during claims parsing")
+		return nil, fmt.Errorf("%w: failed to parse claims", ErrTokenInvalid)
 	}
 
 	exp, ok := claims["exp"].(float64)
 	if !ok {
-		return errors.New("failed to obtain token expiration time")
+		return nil, fmt.Errorf("%w: failed to obtain expiration time", ErrTokenInvalid)
 	}
 
-	if int64(exp) < time.Now().Local().Unix() {
-		return errors.New("token has expired")
+	if int64(exp) < clock.Now().Add(-cfg.JWTLeeway).Unix() {
+		return nil, ErrTokenExpired
 	}
 
-	return nil
+	return claims, nil
 }
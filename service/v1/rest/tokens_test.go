@@ -0,0 +1,254 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"encoding/json"
+	"errors"
+	"eventshub/config"
+	logger "eventshub/logging"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedClock is a Clock returning preset time, which tests move manually.
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+func Test_ValidateJWTReturnsDistinctErrors(t *testing.T) {
+	/* GIVEN tokens that are valid, missing, expired, signed with other secret or malformed
+	 * WHEN they are validated
+	 * THEN valid token should pass
+	 * AND every failure should be reported with its own sentinel error
+	 */
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+
+	valid, err := createJWT(cfg, SystemClock{}, "admin")
+	assert.NoError(t, err)
+
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{
+		"exp":        time.Now().Add(-time.Minute).Unix(),
+		"authorized": true,
+		"user":       "admin",
+	}).SignedString([]byte(cfg.TokenSecret))
+	assert.NoError(t, err)
+
+	otherCfg := config.Default()
+	otherCfg.TokenSecret = "other-secret"
+
+	foreign, err := createJWT(otherCfg, SystemClock{}, "admin")
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		token    *string
+		expected error
+	}{
+		{"valid", &valid, nil},
+		{"missing", nil, ErrTokenMissing},
+		{"expired", &expired, ErrTokenExpired},
+		{"bad signature", &foreign, ErrTokenInvalid},
+		{"malformed", func() *string { s := "not-a-token"; return &s }(), ErrTokenInvalid},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody)
+		if tc.token != nil {
+			req.Header.Set("Token", *tc.token)
+		}
+
+		err := validateJWT(cfg, SystemClock{}, nil, req)
+
+		if tc.expected == nil {
+			assert.NoError(t, err, tc.name)
+		} else {
+			assert.True(t, errors.Is(err, tc.expected), tc.name)
+		}
+	}
+}
+
+func Test_InvalidTokenResponseCarriesCode(t *testing.T) {
+	/* GIVEN every token validation error
+	 * WHEN invalidTokenResponse is sent
+	 * THEN response should be 401 with machine-readable code matching the error
+	 */
+	srv := &HTTPRestServer{cfg: config.Default(), clock: SystemClock{}, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+
+	for _, tc := range []struct {
+		reason error
+		code   string
+	}{
+		{ErrTokenExpired, TokenExpiredCode},
+		{ErrTokenMissing, TokenMissingCode},
+		{ErrTokenInvalid, TokenInvalidCode},
+		{errors.New("unexpected"), TokenInvalidCode},
+	} {
+		var resp InvalidTokenResp
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody)
+		rec := httptest.NewRecorder()
+
+		srv.invalidTokenResponse(rec, req, tc.reason)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, tc.code, resp.Code, tc.reason.Error())
+	}
+}
+
+func Test_TokenExpiresAfterLifeTime(t *testing.T) {
+	/* GIVEN a token created at fixed time and no leeway
+	 * WHEN it is validated just before and just after its life time passes
+	 * THEN it should be valid before
+	 * AND expired after, without waiting for the wall clock
+	 */
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+	cfg.JWTLeeway = 0
+
+	clock := &fixedClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+	token, err := createJWT(cfg, clock, "admin")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody)
+	req.Header.Set("Token", token)
+
+	clock.now = clock.now.Add(tokenLifeTime - time.Second)
+	assert.NoError(t, validateJWT(cfg, clock, nil, req))
+
+	clock.now = clock.now.Add(2 * time.Second)
+	assert.True(t, errors.Is(validateJWT(cfg, clock, nil, req), ErrTokenExpired))
+}
+
+func Test_TokenExpiryToleratesLeeway(t *testing.T) {
+	/* GIVEN a token created at fixed time and configured leeway
+	 * WHEN it is validated after it expired by less and by more than the leeway
+	 * THEN it should still be valid within the leeway
+	 * AND expired beyond it
+	 */
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+	cfg.JWTLeeway = 30 * time.Second
+
+	clock := &fixedClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+	token, err := createJWT(cfg, clock, "admin")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/version", http.NoBody)
+	req.Header.Set("Token", token)
+
+	clock.now = clock.now.Add(tokenLifeTime + cfg.JWTLeeway - time.Second)
+	assert.NoError(t, validateJWT(cfg, clock, nil, req))
+
+	clock.now = clock.now.Add(2 * time.Second)
+	assert.True(t, errors.Is(validateJWT(cfg, clock, nil, req), ErrTokenExpired))
+}
+
+func Test_ValidateTokenReportsTokenState(t *testing.T) {
+	/* GIVEN valid, expired and malformed tokens
+	 * WHEN they are sent to the validateToken endpoint
+	 * THEN the valid one should be reported with its user and expiry
+	 * AND the others as invalid with the reason code
+	 * AND a request without token should be rejected with 401
+	 */
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+	cfg.JWTLeeway = 0
+
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fixedClock{now: created}
+
+	/* No database is set, reaching it would panic */
+	srv := &HTTPRestServer{cfg: cfg, clock: clock, log: logger.NewConsoleLogger("TEST", logger.ERROR)}
+
+	token, err := createJWT(cfg, clock, "admin")
+	assert.NoError(t, err)
+
+	validate := func(token string) (*httptest.ResponseRecorder, ValidateTokenResp) {
+		var resp ValidateTokenResp
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/validateToken", http.NoBody)
+		if token != "" {
+			req.Header.Set("Token", token)
+		}
+
+		rec := httptest.NewRecorder()
+		srv.validateToken(rec, req)
+
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+		return rec, resp
+	}
+
+	rec, resp := validate(token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, resp.Valid)
+	assert.Equal(t, "admin", resp.User)
+	assert.Equal(t, created.Add(tokenLifeTime).Unix(), resp.ExpiresAt)
+	assert.Empty(t, resp.Reason)
+
+	rec, resp = validate("not.a.token")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, TokenInvalidCode, resp.Reason)
+	assert.Empty(t, resp.User)
+
+	clock.now = created.Add(tokenLifeTime + time.Second)
+
+	rec, resp = validate(token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, TokenExpiredCode, resp.Reason)
+
+	rec, _ = validate("")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func Test_OversizedTokenIsRejectedBeforeParsing(t *testing.T) {
+	/* GIVEN maximum token size of 1 KiB and a multi-megabyte token header
+	 * WHEN the token is validated
+	 * THEN it should be rejected as invalid because of its size, without being parsed
+	 * AND a token within the limit should still be accepted
+	 */
+	cfg := config.Default()
+	cfg.TokenSecret = "test-secret"
+	cfg.MaxTokenBytes = 1 << 10
+
+	valid, err := createJWT(cfg, SystemClock{}, "admin")
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		token    string
+		expected error
+	}{
+		{valid, nil},
+		{valid + strings.Repeat("A", 4<<20), ErrTokenInvalid},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Token", tc.token)
+
+		start := time.Now()
+		err = validateJWT(cfg, SystemClock{}, nil, req)
+
+		assert.ErrorIs(t, err, tc.expected)
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+		if tc.expected != nil && err != nil {
+			assert.Contains(t, err.Error(), "at most 1024 allowed")
+		}
+	}
+}
@@ -6,27 +6,197 @@ package v1rest
 
 import (
 	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"eventshub/config"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 const (
-	DateTimeStructName       string        = "DateTime"
-	EventDataStructName      string        = "EventData"
-	ResponseStatusName       string        = "ResponseStatus"
-	AddEventRespName         string        = "AddEventResp"
-	GetEventCheckSumRespName string        = "GetEventCheckSumResp"
-	GetEventsRespName        string        = "GetEventsResp"
-	GetStatusRespName        string        = "GetStatusResp"
-	InvalidTokenRespName     string        = "InvalidTokenResp"
-	KillRespName             string        = "KillResp"
-	Version                  string        = "v1.1.0"
-	VersionRespName          string        = "VersionResp"
-	GracefulShutdownTimeout  time.Duration = 2 * time.Second
+	DateTimeStructName        string        = "DateTime"
+	EventDataStructName       string        = "EventData"
+	EventCategoryMaxLength    int           = 64
+	EventCountsByDayRespName  string        = "EventCountsByDayResp"
+	EventCountsMaxDays        int           = 1000
+	DeleteEventsRespName      string        = "DeleteEventsResp"
+	DiffEventsRespName        string        = "DiffEventsResp"
+	ErrorRespName             string        = "ErrorResp"
+	EventBusBufferSize        int           = 64
+	EventChangeMsgName        string        = "EventChangeMsg"
+	EventStreamContentType    string        = "text/event-stream"
+	EventStreamKeepAlive      time.Duration = 15 * time.Second
+	EventStreamRetry          time.Duration = 3 * time.Second
+	DeleteEventsMaxUUIDs      int           = 1000
+	UpdateFlagsRespName       string        = "UpdateFlagsResp"
+	UpdateFlagsMaxUUIDs       int           = 1000
+	ReassignEventsRespName    string        = "ReassignEventsResp"
+	AttachmentRespName        string        = "AttachmentResp"
+	AttachmentStructName      string        = "Attachment"
+	MergeEventsRespName       string        = "MergeEventsResp"
+	PlanSyncRespName          string        = "PlanSyncResp"
+	PlanSyncMaxUUIDs          int           = 1000
+	CountEventsRespName       string        = "CountEventsResp"
+	NotReadyRespName          string        = "NotReadyResp"
+	ReadyRespName             string        = "ReadyResp"
+	HandlerTimeoutRespName    string        = "HandlerTimeoutResp"
+	PatchEventRespName        string        = "PatchEventResp"
+	ResponseStatusName        string        = "ResponseStatus"
+	AddEventRespName          string        = "AddEventResp"
+	ValidationErrorRespName   string        = "ValidationErrorResp"
+	AuditActionDelete         string        = "delete"
+	AuditActionInsert         string        = "insert"
+	AuditActionUpdate         string        = "update"
+	AuditDefaultLimit         int           = 100
+	AuditEntryStructName      string        = "AuditEntry"
+	AuditMaxLimit             int           = 1000
+	BackupContentType         string        = "application/vnd.sqlite3"
+	ExportCSVContentType      string        = "text/csv; charset=utf-8"
+	ExportBatchSize           int           = 500
+	SMTPTimeout               time.Duration = 30 * time.Second
+	BackupRespName            string        = "BackupResp"
+	GetAuditRespName          string        = "GetAuditResp"
+	MyRemindersRespName       string        = "MyRemindersResp"
+	MyRemindersDefaultDays    int           = 7
+	MyRemindersMaxDays        int           = 366
+	UserReminderStructName    string        = "UserReminder"
+	PriorityEventsRespName    string        = "GetEventsByPriorityResp"
+	DatabaseReconnectAttempts int           = 3
+	DatabaseReconnectBackoff  time.Duration = 100 * time.Millisecond
+	DatabaseStartupBackoff    time.Duration = 100 * time.Millisecond
+	DatabaseStartupMaxBackoff time.Duration = 5 * time.Second
+	GetEventCheckSumRespName  string        = "GetEventCheckSumResp"
+	GetEventDetailRespName    string        = "GetEventDetailResp"
+	GetEventsRespName         string        = "GetEventsResp"
+	GetLocationsRespName      string        = "GetLocationsResp"
+	LocationsDefaultLimit     int           = 50
+	LocationsMaxLimit         int           = 500
+	GetStatusRespName         string        = "GetStatusResp"
+	GetStatusHistoryRespName  string        = "GetStatusHistoryResp"
+	StatusHistoryDefaultLimit int           = 10
+	StatusHistoryMaxLimit     int           = 1000
+	ImportStreamLineRespName  string        = "ImportStreamLineResp"
+	ImportStreamBatchSize     int           = 500
+	ImportFromURLRespName     string        = "ImportFromURLResp"
+	ImportURLTimeout          time.Duration = 4 * time.Second
+	BuildInfoRespName         string        = "BuildInfoResp"
+	InvalidAPIVersionRespName string        = "InvalidAPIVersionResp"
+	InvalidTokenRespName      string        = "InvalidTokenResp"
+	InvertedTimeRangeMsg      string        = "Start must be before end."
+	RangeTooLargeMsg          string        = "Requested range too large, at most %d days allowed."
+	TokenExpiredCode          string        = "token_expired"
+	TokenInvalidCode          string        = "token_invalid"
+	TokenMissingCode          string        = "token_missing"
+	UnsupportedMediaRespName  string        = "UnsupportedMediaResp"
+	UserEmailRespName         string        = "UserEmailResp"
+	UserEmailMaxLength        int           = 255
+	UserSettingsRespName      string        = "UserSettingsResp"
+	ValidateTokenRespName     string        = "ValidateTokenResp"
+	UserSettingsDefault       string        = "{}"
+	UserSettingsMaxSize       int           = 16 * 1024
+	WebSocketMaxMessageSize   int           = 64 * 1024
+	WebSocketMaxMessageRate   int           = 10
+	WebSocketReadTimeout      time.Duration = 2 * EventStreamKeepAlive
+	JSONContentType           string        = "application/json; charset=utf-8"
+	NDJSONContentType         string        = "application/x-ndjson; charset=utf-8"
+	KillRespName              string        = "KillResp"
+	ServerTimeRespName        string        = "ServerTimeResp"
+	Version                   string        = "v1.1.0"
+	VersionRespName           string        = "VersionResp"
+	GracefulShutdownTimeout   time.Duration = 2 * time.Second
 )
 
+// Time range shortcuts accepted by getEventsByShortcut.
+const (
+	ShortcutToday     string = "today"
+	ShortcutTomorrow  string = "tomorrow"
+	ShortcutThisWeek  string = "this_week"
+	ShortcutThisMonth string = "this_month"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// ErrEmptyBody is returned when a request requiring JSON body has none.
+var ErrEmptyBody = errors.New("missing request body")
+
+// ErrBackupNotSupported is returned when database has no file to back up.
+var ErrBackupNotSupported = errors.New("backup is not supported for in-memory database")
+
+// ErrInvalidEvent is wrapped by errors of EventData.Validate.
+var ErrInvalidEvent = errors.New("invalid event")
+
+// ValidationError lists every invalid field of an event, keyed by JSON field name.
+// It wraps ErrInvalidEvent, so errors.Is keeps working for callers not interested in fields.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	for i, field := range fields {
+		fields[i] = field + " " + e.Fields[field]
+	}
+
+	return fmt.Sprintf("%s: %s", ErrInvalidEvent, strings.Join(fields, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidEvent
+}
+
+// ErrEventNotFound is returned when no event has the requested UUID.
+var ErrEventNotFound = errors.New("event not found")
+
+// ErrEventExists is reported when insert-only mode finds the UUID already stored.
+var ErrEventExists = errors.New("event already exists")
+
+// ErrDuplicateEvent is returned when import deduplication skips an event,
+// because other event with the same content is already stored.
+var ErrDuplicateEvent = errors.New("duplicate event")
+
+// ErrQuotaExceeded is returned when inserting an event would exceed
+// cfg.MaxEventsPerUser events owned by its user.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// ErrUserNotFound is returned when an operation names a user who is not registered.
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrInvalidQuery is returned when EventQueryOptions can not be turned into a query.
+var ErrInvalidQuery = errors.New("invalid query options")
+
+// ErrInsecureNotAllowed is returned by Start when plaintext serving was not enabled explicitly.
+var ErrInsecureNotAllowed = errors.New("plaintext HTTP is disabled, set GOCALENDAR_ALLOW_INSECURE=true to enable it")
+
+// ErrAttachmentNotFound is returned when an event has no attachment.
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// ErrUnknownShortcut is returned when a time range shortcut is not one of Shortcut* values.
+var ErrUnknownShortcut = errors.New("unknown shortcut")
+
+// Common identifies a message. Version is set to the API version on every response
+// so clients can branch on the schema, handlers only fill in Type.
 type Common struct {
-	Type string `json:"__type__,omitempty"`
+	Type    string `json:"__type__,omitempty"`
+	Version string `json:"__version__,omitempty"`
+}
+
+func (c *Common) setVersion(version string) {
+	c.Version = version
+}
+
+// versioned is implemented by pointers to messages embedding Common.
+type versioned interface {
+	setVersion(version string)
 }
 
 type User struct {
@@ -43,6 +213,64 @@ type DateTime struct {
 	Minute int32 `json:"minute"`
 }
 
+// DateTimeISO8601Layout is the string form of DateTime, a wall time without offset
+// interpreted in configured time zone, same as the object form.
+const DateTimeISO8601Layout = "2006-01-02T15:04:05"
+
+// dateTimeInputLayouts are string forms of DateTime accepted on input.
+var dateTimeInputLayouts = []string{DateTimeISO8601Layout, "2006-01-02T15:04", "2006-01-02"}
+
+func (d DateTime) ISO8601() string {
+	// ISO8601 formats the date time as DateTimeISO8601Layout.
+	return fmt.Sprintf("%04d-%02d-%02dT%02d:%02d:00", d.Year, d.Month, d.Day, d.Hour, d.Minute)
+}
+
+func (d *DateTime) UnmarshalJSON(data []byte) error {
+	// UnmarshalJSON accepts both the object form and an ISO 8601 string
+	// without offset, regardless of configured output format.
+	//
+	// Parameter: JSON value of the date time.
+	var text string
+
+	if len(data) == 0 || data[0] != '"' {
+		/* Alias type has no methods, so decoding it does not recurse here */
+		type dateTimeObject DateTime
+
+		if err := json.Unmarshal(data, (*dateTimeObject)(d)); err != nil {
+			return err
+		}
+
+		/* Clients may omit the discriminator, responses rely on it to format date times */
+		d.Type = DateTimeStructName
+
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+
+	for _, layout := range dateTimeInputLayouts {
+		t, err := time.Parse(layout, text)
+		if err != nil {
+			continue
+		}
+
+		*d = DateTime{
+			Common: Common{Type: DateTimeStructName},
+			Year:   int32(t.Year()),
+			Month:  int32(t.Month()),
+			Day:    int32(t.Day()),
+			Hour:   int32(t.Hour()),
+			Minute: int32(t.Minute()),
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("date time %q is neither an object nor in %s format", text, DateTimeISO8601Layout)
+}
+
 //nolint:govet //All structs should have similar attributes order
 type EventData struct {
 	Common
@@ -59,6 +287,85 @@ type EventData struct {
 	Important bool     `json:"important"`
 	Urgent    bool     `json:"urgent"`
 	Source    string   `json:"source"`
+	Category  string   `json:"category"`
+	Color     string   `json:"color"`
+	/* Owner is the user who inserted the event, set by handlers and never exchanged with clients */
+	Owner string `json:"-"`
+}
+
+func (e *EventData) Normalize() {
+	// Normalize trims surrounding whitespace from text fields and uppercases
+	// the source, so equal events from different sources produce equal checksums.
+	//
+	// Parameter: EventData object (self).
+	e.Title = strings.TrimSpace(e.Title)
+	e.Address = strings.TrimSpace(e.Address)
+	e.Info = strings.TrimSpace(e.Info)
+	e.Source = strings.ToUpper(strings.TrimSpace(e.Source))
+	e.Category = strings.TrimSpace(e.Category)
+	e.Color = strings.ToLower(strings.TrimSpace(e.Color))
+}
+
+func (e *EventData) Validate(cfg *config.Config) error {
+	// Validate checks that event fields have values accepted by the database.
+	// Text fields may not exceed configured lengths, so behaviour does not depend
+	// on backend enforcing VARCHAR limits. Color is optional, when set it has
+	// to be a hex `#rrggbb` value. Source is not checked, events stored before
+	// the allowlist was configured can still be updated.
+	//
+	// Parameter: EventData object (self), configuration with length limits.
+	// Return type: *ValidationError describing every invalid field, nil if event is valid.
+	return e.validate(cfg, false)
+}
+
+func (e *EventData) ValidateNew(cfg *config.Config) error {
+	// ValidateNew checks event which is not stored yet, same as Validate and
+	// additionally requires its source to be on the configured allowlist.
+	//
+	// Parameter: EventData object (self), configuration with limits and allowed sources.
+	// Return type: *ValidationError describing every invalid field, nil if event is valid.
+	return e.validate(cfg, true)
+}
+
+func (e *EventData) validate(cfg *config.Config, isNew bool) error {
+	/* Collect every invalid field, source is checked for new events only */
+	invalid := map[string]string{}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		limit int
+	}{
+		{"title", e.Title, cfg.MaxTitleLength},
+		{"address", e.Address, cfg.MaxAddressLength},
+		{"info", e.Info, cfg.MaxInfoLength},
+		{"category", e.Category, EventCategoryMaxLength},
+	} {
+		if length := utf8.RuneCountInString(field.value); length > field.limit {
+			invalid[field.name] = fmt.Sprintf("has %d characters, at most %d allowed", length, field.limit)
+		}
+	}
+
+	/* Stored plain text with the prefix would be read back as ciphertext and fail to decrypt */
+	for name, value := range map[string]string{"address": e.Address, "info": e.Info} {
+		if _, ok := invalid[name]; !ok && strings.HasPrefix(value, EncryptedFieldPrefix) {
+			invalid[name] = fmt.Sprintf("must not start with %q", EncryptedFieldPrefix)
+		}
+	}
+
+	if e.Color != "" && !colorPattern.MatchString(e.Color) {
+		invalid["color"] = fmt.Sprintf("%q is not a #rrggbb value", e.Color)
+	}
+
+	if isNew && !cfg.SourceAllowed(e.Source) {
+		invalid["source"] = fmt.Sprintf("%q is not allowed", e.Source)
+	}
+
+	if len(invalid) > 0 {
+		return &ValidationError{Fields: invalid}
+	}
+
+	return nil
 }
 
 func (e *EventData) Sha256() [32]byte {
@@ -70,14 +377,31 @@ func (e *EventData) Sha256() [32]byte {
 	return hash
 }
 
+func (e *EventData) ContentHash() string {
+	// ContentHash returns hex SHA256 of the fields identifying an event regardless
+	// of its UUID: title, time and address. Re-imported copies of the same event
+	// have equal content hashes, while their Sha256 differ.
+	//
+	// Parameter: EventData object (self).
+	// Return type: string.
+	dateTime := func(dt DateTime) string {
+		return fmt.Sprintf("%04d-%02d-%02d %02d:%02d", dt.Year, dt.Month, dt.Day, dt.Hour, dt.Minute)
+	}
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("Title: %s, Start: %s, End: %s, Address: %s",
+		strings.TrimSpace(e.Title), dateTime(e.Start), dateTime(e.End), strings.TrimSpace(e.Address))))
+
+	return fmt.Sprintf("%x", hash)
+}
+
 func (e *EventData) ToString() string {
 	// ToString converts EventData object to a string representation.
 	//
 	// Parameter: EventData object (self).
 	// Return type: string.
 	result := fmt.Sprintf(
-		"Version: %s, UUID: %s, Title: %s, Start: %v, End: %v, Address: %s, Info: %s, Reminder: %d, Done: %t, Important: %t, Urgent: %t",
-		e.Version, e.UUID, e.Title, e.Start, e.End, e.Address, e.Info, e.Reminder, e.Done, e.Important, e.Urgent)
+		"Version: %s, UUID: %s, Title: %s, Start: %v, End: %v, Address: %s, Info: %s, Reminder: %d, Done: %t, Important: %t, Urgent: %t, Category: %s, Color: %s",
+		e.Version, e.UUID, e.Title, e.Start, e.End, e.Address, e.Info, e.Reminder, e.Done, e.Important, e.Urgent, e.Category, e.Color)
 
 	return result
 }
@@ -89,8 +413,17 @@ type ResponseStatus struct {
 	Message string `json:"message"`
 }
 
+// AddEventReq carries event to insert. With UseDefaultReminder set, event without
+// reminder gets cfg.ReminderDays; otherwise zero reminder is kept as sent.
 type AddEventReq struct {
-	Event EventData `json:"event"`
+	Event              EventData `json:"event"`
+	UseDefaultReminder bool      `json:"use_default_reminder,omitempty"`
+}
+
+func (req *AddEventReq) applyDefaultReminder(days int) {
+	if req.UseDefaultReminder && req.Event.Reminder == 0 {
+		req.Event.Reminder = int32(days)
+	}
 }
 
 type AddEventResp struct {
@@ -98,6 +431,229 @@ type AddEventResp struct {
 	Status ResponseStatus `json:"status"`
 }
 
+// ValidationErrorResp reports all invalid fields of an event at once, as field name to message.
+//
+//nolint:govet //All structs should have similar attributes order
+type ValidationErrorResp struct {
+	Common
+	Errors map[string]string `json:"errors"`
+	Status ResponseStatus    `json:"status"`
+}
+
+// PatchEventReq carries only the fields to change, absent fields are nil.
+type PatchEventReq struct {
+	UUID      string    `json:"uuid"`
+	Version   *string   `json:"version,omitempty"`
+	Title     *string   `json:"title,omitempty"`
+	Start     *DateTime `json:"start,omitempty"`
+	End       *DateTime `json:"end,omitempty"`
+	Address   *string   `json:"address,omitempty"`
+	Info      *string   `json:"info,omitempty"`
+	Reminder  *int32    `json:"reminder,omitempty"`
+	Done      *bool     `json:"done,omitempty"`
+	Important *bool     `json:"important,omitempty"`
+	Urgent    *bool     `json:"urgent,omitempty"`
+	Source    *string   `json:"source,omitempty"`
+	Category  *string   `json:"category,omitempty"`
+	Color     *string   `json:"color,omitempty"`
+}
+
+func (p *PatchEventReq) Apply(e *EventData) {
+	// Apply copies every field present in the patch onto the event,
+	// fields absent from the patch are left untouched.
+	//
+	// Parameter: EventData object to be patched.
+	if p.Version != nil {
+		e.Version = *p.Version
+	}
+
+	if p.Title != nil {
+		e.Title = *p.Title
+	}
+
+	if p.Start != nil {
+		e.Start = *p.Start
+	}
+
+	if p.End != nil {
+		e.End = *p.End
+	}
+
+	if p.Address != nil {
+		e.Address = *p.Address
+	}
+
+	if p.Info != nil {
+		e.Info = *p.Info
+	}
+
+	if p.Reminder != nil {
+		e.Reminder = *p.Reminder
+	}
+
+	if p.Done != nil {
+		e.Done = *p.Done
+	}
+
+	if p.Important != nil {
+		e.Important = *p.Important
+	}
+
+	if p.Urgent != nil {
+		e.Urgent = *p.Urgent
+	}
+
+	if p.Source != nil {
+		e.Source = *p.Source
+	}
+
+	if p.Category != nil {
+		e.Category = *p.Category
+	}
+
+	if p.Color != nil {
+		e.Color = *p.Color
+	}
+}
+
+func (e *EventData) FillFrom(other *EventData) {
+	// FillFrom copies text fields and reminder of the other event into
+	// the ones left empty in this event. Non-empty fields, time range,
+	// flags and identity of this event are kept.
+	//
+	// Parameter: EventData object providing missing values.
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+
+	fill(&e.Version, other.Version)
+	fill(&e.Title, other.Title)
+	fill(&e.Address, other.Address)
+	fill(&e.Info, other.Info)
+	fill(&e.Source, other.Source)
+	fill(&e.Category, other.Category)
+	fill(&e.Color, other.Color)
+
+	if e.Reminder == 0 {
+		e.Reminder = other.Reminder
+	}
+}
+
+type MergeEventsReq struct {
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type MergeEventsResp struct {
+	Common
+	Event  *EventData     `json:"event"`
+	Status ResponseStatus `json:"status"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type PatchEventResp struct {
+	Common
+	Event  *EventData     `json:"event"`
+	Status ResponseStatus `json:"status"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type AuditEntry struct {
+	Common
+	Timestamp int64  `json:"timestamp"`
+	User      string `json:"user"`
+	Action    string `json:"action"`
+	UUID      string `json:"uuid"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type GetAuditResp struct {
+	Common
+	Entries []AuditEntry   `json:"entries"`
+	Status  ResponseStatus `json:"status"`
+}
+
+// UserReminder is an upcoming reminder of an event, RemindAt is Unix time it fires at.
+//
+//nolint:govet //All structs should have similar attributes order
+type UserReminder struct {
+	Common
+	RemindAt int64     `json:"remind_at"`
+	Event    EventData `json:"event"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type MyRemindersResp struct {
+	Common
+	Reminders []UserReminder `json:"reminders"`
+	Status    ResponseStatus `json:"status"`
+}
+
+// Attachment describes a file attached to an event. Its content is kept in BlobStore
+// under Key, which is generated by the server and never exchanged with clients.
+//
+//nolint:govet //All structs should have similar attributes order
+type Attachment struct {
+	Common
+	EventUUID   string `json:"uuid"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Key         string `json:"-"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type AttachmentResp struct {
+	Common
+	Attachment *Attachment    `json:"attachment"`
+	Status     ResponseStatus `json:"status"`
+}
+
+type BackupResp struct {
+	Common
+	Status ResponseStatus `json:"status"`
+}
+
+type DeleteEventsReq struct {
+	UUIDs []string `json:"uuids"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type DeleteEventsResp struct {
+	Common
+	Deleted int64          `json:"deleted"`
+	Status  ResponseStatus `json:"status"`
+}
+
+// UpdateFlagsReq carries UUIDs of events and only the flags to set, absent flags are nil.
+type UpdateFlagsReq struct {
+	UUIDs     []string `json:"uuids"`
+	Done      *bool    `json:"done,omitempty"`
+	Important *bool    `json:"important,omitempty"`
+	Urgent    *bool    `json:"urgent,omitempty"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type UpdateFlagsResp struct {
+	Common
+	Updated int64          `json:"updated"`
+	Status  ResponseStatus `json:"status"`
+}
+
+type ReassignEventsReq struct {
+	FromUser string `json:"fromUser"`
+	ToUser   string `json:"toUser"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type ReassignEventsResp struct {
+	Common
+	Reassigned int64          `json:"reassigned"`
+	Status     ResponseStatus `json:"status"`
+}
+
 type GetEventCheckSumReq struct {
 	UUID string `json:"uuid"`
 }
@@ -108,6 +664,172 @@ type GetEventCheckSumResp struct {
 	Status ResponseStatus `json:"status"`
 }
 
+// PlanSyncReq maps UUIDs of client's events to their checksums, as returned by getEventCheckSum.
+type PlanSyncReq struct {
+	Checksums map[string]string `json:"checksums"`
+}
+
+// PlanSyncResp sorts UUIDs of PlanSyncReq by what the client has to upload:
+// New are not stored, Changed are stored with other checksum, Unchanged match.
+//
+//nolint:govet //All structs should have similar attributes order
+type PlanSyncResp struct {
+	Common
+	New       []string       `json:"new"`
+	Changed   []string       `json:"changed"`
+	Unchanged []string       `json:"unchanged"`
+	Status    ResponseStatus `json:"status"`
+}
+
+type GetEventDetailReq struct {
+	UUID string `json:"uuid"`
+}
+
+// EventDetail is EventData extended with bookkeeping metadata. Timestamps are Unix
+// seconds, 0 when unknown. LastModifiedBy is the user of the latest insert or update.
+type EventDetail struct {
+	EventData
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+	LastModifiedBy string `json:"last_modified_by"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type GetEventDetailResp struct {
+	Common
+	Event  *EventDetail   `json:"event"`
+	Status ResponseStatus `json:"status"`
+}
+
+type FindEventsByUUIDPrefixReq struct {
+	Prefix string `json:"prefix"`
+}
+
+type GetOverlappingEventsReq struct {
+	UUID string `json:"uuid"`
+}
+
+// EventQueryOptions select, order and paginate events returned by GetAllEventsFiltered.
+// Zero value returns all events ordered by start. Nil flags, nil range bounds, empty source
+// and empty text do not filter. Start and End are Unix times, events overlapping the range
+// with bounds included are selected, same as by GetEventsByTimeRange. Text matches part of
+// the title case-insensitively, while Title and Category, when not nil, have to match exactly.
+// CreatedFrom and CreatedTo bound creation time, bounds included. Limit of 0 means no limit.
+// SortBy has to be one of eventSortColumns.
+type EventQueryOptions struct {
+	Limit       int
+	Offset      int
+	SortBy      string
+	Descending  bool
+	Done        *bool
+	Important   *bool
+	Urgent      *bool
+	Source      string
+	Start       *int64
+	End         *int64
+	Text        string
+	Title       *string
+	Category    *string
+	UUIDPrefix  string
+	ExcludeUUID string
+	CreatedFrom *int64
+	CreatedTo   *int64
+}
+
+// CountEventsReq takes filters of EventQueryOptions, unset flags and range bounds do not filter.
+type CountEventsReq struct {
+	Done      *bool     `json:"done,omitempty"`
+	Important *bool     `json:"important,omitempty"`
+	Urgent    *bool     `json:"urgent,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	Start     *DateTime `json:"start,omitempty"`
+	End       *DateTime `json:"end,omitempty"`
+	Text      string    `json:"text,omitempty"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type CountEventsResp struct {
+	Common
+	Count  int64          `json:"count"`
+	Status ResponseStatus `json:"status"`
+}
+
+type DayCount struct {
+	Day   string `json:"day"`
+	Count int64  `json:"count"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type EventCountsByDayResp struct {
+	Common
+	Days   []DayCount     `json:"days"`
+	Status ResponseStatus `json:"status"`
+}
+
+// EventChangeMsg notifies WebSocket client about inserted, updated or deleted event.
+type EventChangeMsg struct {
+	Common
+	EventChange
+}
+
+// DiffEventsReq holds two time ranges which events are compared.
+type DiffEventsReq struct {
+	First  GetEventsReq `json:"first"`
+	Second GetEventsReq `json:"second"`
+}
+
+// DiffEventsResp lists events present in only one of compared ranges, matched by UUID.
+//
+//nolint:govet //All structs should have similar attributes order
+type DiffEventsResp struct {
+	Common
+	OnlyInFirst       []EventData    `json:"only_in_first"`
+	OnlyInFirstCount  int            `json:"only_in_first_count"`
+	OnlyInSecond      []EventData    `json:"only_in_second"`
+	OnlyInSecondCount int            `json:"only_in_second_count"`
+	Status            ResponseStatus `json:"status"`
+}
+
+// GetEventsByPriorityReq limits events to optional time range, missing bound is open.
+type GetEventsByPriorityReq struct {
+	Start *DateTime `json:"start,omitempty"`
+	End   *DateTime `json:"end,omitempty"`
+}
+
+type GetLocationsReq struct {
+	Prefix string `json:"prefix"`
+	Limit  int    `json:"limit"`
+}
+
+// GetLocationsResp lists distinct addresses of stored events in alphabetical order.
+//
+//nolint:govet //All structs should have similar attributes order
+type GetLocationsResp struct {
+	Common
+	Locations []string       `json:"locations"`
+	Status    ResponseStatus `json:"status"`
+}
+
+// GetEventsByPriorityResp groups events into quadrants of the Eisenhower matrix.
+//
+//nolint:govet //All structs should have similar attributes order
+type GetEventsByPriorityResp struct {
+	Common
+	ImportantUrgent       []EventData    `json:"important_urgent"`
+	ImportantNotUrgent    []EventData    `json:"important_not_urgent"`
+	NotImportantUrgent    []EventData    `json:"not_important_urgent"`
+	NotImportantNotUrgent []EventData    `json:"not_important_not_urgent"`
+	Status                ResponseStatus `json:"status"`
+}
+
+type GetEventsByCategoryReq struct {
+	Category string `json:"category"`
+}
+
+type GetEventsByTitleReq struct {
+	Title string `json:"title"`
+}
+
 type GetEventsReq struct {
 	Start DateTime `json:"start"`
 	End   DateTime `json:"end"`
@@ -120,6 +842,10 @@ type GetEventsResp struct {
 	Status ResponseStatus `json:"status"`
 }
 
+type GetEventsByShortcutReq struct {
+	Shortcut string `json:"shortcut"`
+}
+
 type GetStatusReq struct {
 }
 
@@ -129,9 +855,131 @@ type GetStatusResp struct {
 	Timestamp int64          `json:"timestamp"`
 	Status    ResponseStatus `json:"status"`
 	Version   string         `json:"version"`
+	Instance  string         `json:"instance,omitempty"`
+	Changes   *int64         `json:"changes,omitempty"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type ImportStreamLineResp struct {
+	Common
+	Line   int            `json:"line"`
+	UUID   string         `json:"uuid"`
+	Status ResponseStatus `json:"status"`
+}
+
+//nolint:govet //All structs should have similar attributes order
+type GetStatusHistoryResp struct {
+	Common
+	History []GetStatusResp `json:"history"`
+	Status  ResponseStatus  `json:"status"`
+}
+
+type InvalidAPIVersionResp struct {
+	Common
+	Status  ResponseStatus `json:"status"`
+	Version string         `json:"version"`
+}
+
+// BuildVCS describes the commit the binary was built from, as stamped by the Go toolchain.
+type BuildVCS struct {
+	System   string `json:"system"`
+	Revision string `json:"revision"`
+	Time     string `json:"time"`
+	Modified bool   `json:"modified"`
 }
 
+//nolint:govet //All structs should have similar attributes order
+type BuildInfoResp struct {
+	Common
+	Version       string         `json:"version"`
+	GoVersion     string         `json:"go_version"`
+	Module        string         `json:"module"`
+	DBDriver      string         `json:"db_driver"`
+	SchemaVersion int            `json:"schema_version"`
+	VCS           BuildVCS       `json:"vcs"`
+	Status        ResponseStatus `json:"status"`
+}
+
+//nolint:govet //All structs should have similar attributes order
 type InvalidTokenResp struct {
+	Common
+	Code   string         `json:"code"`
+	Status ResponseStatus `json:"status"`
+}
+
+type HandlerTimeoutResp struct {
+	Common
+	Status ResponseStatus `json:"status"`
+}
+
+type ImportFromURLReq struct {
+	URL    string `json:"url"`
+	Format string `json:"format"`
+}
+
+// ImportFromURLResp counts events of the fetched feed. Skipped ones duplicate content
+// of stored events (see GOCALENDAR_IMPORT_DEDUP), failed ones were rejected.
+//
+//nolint:govet //All structs should have similar attributes order
+type ImportFromURLResp struct {
+	Common
+	Received int            `json:"received"`
+	Imported int            `json:"imported"`
+	Skipped  int            `json:"skipped"`
+	Failed   int            `json:"failed"`
+	Status   ResponseStatus `json:"status"`
+}
+
+// ReadyResp confirms the server may receive traffic. FreeBytes is left out
+// for in-memory database, which has no filesystem to watch.
+//
+//nolint:govet //All structs should have similar attributes order
+type ReadyResp struct {
+	Common
+	FreeBytes *uint64        `json:"free_bytes,omitempty"`
+	Status    ResponseStatus `json:"status"`
+}
+
+type NotReadyResp struct {
+	Common
+	Status ResponseStatus `json:"status"`
+}
+
+type UserEmailReq struct {
+	Email string `json:"email"`
+}
+
+// UserEmailResp carries the address reminders of the authenticated user are sent to,
+// empty when the user receives no reminders.
+//
+//nolint:govet //All structs should have similar attributes order
+type UserEmailResp struct {
+	Common
+	Email  string         `json:"email"`
+	Status ResponseStatus `json:"status"`
+}
+
+// UserSettingsResp carries opaque settings of the authenticated user, stored
+// as sent by the client. Users who never stored settings receive an empty object.
+//
+//nolint:govet //All structs should have similar attributes order
+type UserSettingsResp struct {
+	Common
+	Settings json.RawMessage `json:"settings"`
+	Status   ResponseStatus  `json:"status"`
+}
+
+// ErrorResp is sent when request fails unexpectedly, RequestID allows
+// to find the failure in server logs.
+//
+//nolint:govet //All structs should have similar attributes order
+type ErrorResp struct {
+	Common
+	RequestID string         `json:"request_id"`
+	Status    ResponseStatus `json:"status"`
+}
+
+type UnsupportedMediaResp struct {
 	Common
 	Status ResponseStatus `json:"status"`
 }
@@ -149,6 +997,31 @@ type TokenMsg struct {
 	Token string `json:"token"`
 }
 
+// ValidateTokenResp reports whether the request token is valid. User and
+// ExpiresAt are set for valid tokens only, Reason for invalid ones.
+//
+//nolint:govet //All structs should have similar attributes order
+type ValidateTokenResp struct {
+	Common
+	Valid     bool           `json:"valid"`
+	User      string         `json:"user,omitempty"`
+	ExpiresAt int64          `json:"expires_at,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+	Status    ResponseStatus `json:"status"`
+}
+
+// ServerTimeResp tells clients how the server interprets DateTime values:
+// in TimeZone, currently UTCOffset seconds east of UTC.
+//
+//nolint:govet //All structs should have similar attributes order
+type ServerTimeResp struct {
+	Common
+	TimeZone  string         `json:"timezone"`
+	UTCOffset int            `json:"utc_offset"`
+	Timestamp int64          `json:"timestamp"`
+	Status    ResponseStatus `json:"status"`
+}
+
 type VersionResp struct {
 	Common
 	Status  ResponseStatus `json:"status"`
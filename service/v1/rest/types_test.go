@@ -0,0 +1,244 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"encoding/json"
+	"eventshub/config"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DateTimeAcceptsObjectAndISO8601(t *testing.T) {
+	/* GIVEN time range requests with object, ISO 8601 and malformed date times
+	 * WHEN they are decoded
+	 * THEN object and ISO 8601 forms should result in equal date times
+	 * AND malformed or offset carrying strings should be rejected
+	 */
+	t.Parallel()
+
+	var object, iso GetEventsReq
+
+	want := DateTime{Common{Type: DateTimeStructName}, 2024, 2, 13, 12, 30}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"start": {"year": 2024, "month": 2, "day": 13, "hour": 12, "minute": 30},
+		"end": {"__type__": "DateTime", "year": 2024, "month": 2, "day": 14}}`), &object))
+	assert.NoError(t, json.Unmarshal([]byte(`{"start": "2024-02-13T12:30", "end": "2024-02-14"}`), &iso))
+
+	assert.Equal(t, want, object.Start)
+	assert.Equal(t, want, iso.Start)
+	assert.Equal(t, object.End, iso.End)
+	assert.Equal(t, "2024-02-13T12:30:00", iso.Start.ISO8601())
+
+	for _, malformed := range []string{`"13.02.2024"`, `"2024-02-13T12:30:00Z"`, `42`} {
+		var d DateTime
+
+		assert.Error(t, json.Unmarshal([]byte(malformed), &d), malformed)
+	}
+}
+
+func Test_NormalizeTrimsTextAndUppercasesSource(t *testing.T) {
+	/* GIVEN an event with surrounding whitespace and lowercase source
+	 * WHEN it is normalized
+	 * THEN text fields should be trimmed
+	 * AND source should be uppercased
+	 */
+	t.Parallel()
+
+	e := TestEvent1
+	e.Title = "  Ur. Mr X\t"
+	e.Address = " Warszawa, ul. Okrężna 26 "
+	e.Info = "Likes beer\n"
+	e.Source = "app "
+
+	e.Normalize()
+
+	assert.Equal(t, "Ur. Mr X", e.Title)
+	assert.Equal(t, "Warszawa, ul. Okrężna 26", e.Address)
+	assert.Equal(t, "Likes beer", e.Info)
+	assert.Equal(t, "APP", e.Source)
+}
+
+func Test_NormalizedEventsHaveEqualChecksum(t *testing.T) {
+	/* GIVEN two events differing only by surrounding whitespace and source casing
+	 * WHEN both are normalized
+	 * THEN their checksums should be equal
+	 */
+	t.Parallel()
+
+	a := TestEvent2
+	b := TestEvent2
+	b.Title = " " + b.Title + " "
+	b.Info = b.Info + "  "
+	b.Source = "web "
+
+	assert.NotEqual(t, a.Sha256(), b.Sha256())
+
+	a.Normalize()
+	b.Normalize()
+
+	assert.Equal(t, a.Sha256(), b.Sha256())
+	assert.Equal(t, a.Source, b.Source)
+}
+
+func Test_ValidateRejectsMalformedColor(t *testing.T) {
+	/* GIVEN events with empty, valid and malformed colors
+	 * WHEN they are validated
+	 * THEN only malformed colors should be rejected
+	 * AND category and color should be part of the checksum
+	 */
+	t.Parallel()
+
+	for color, valid := range map[string]bool{
+		"":           true,
+		"#1e90ff":    true,
+		"1e90ff":     false,
+		"#1e90f":     false,
+		"#1e90fg":    false,
+		"dodgerblue": false,
+	} {
+		e := TestEvent1
+		e.Color = color
+
+		assert.Equal(t, valid, e.Validate(config.Default()) == nil, color)
+	}
+
+	a := TestEvent1
+	b := TestEvent1
+	b.Category = "work"
+
+	assert.NotEqual(t, a.Sha256(), b.Sha256())
+
+	b = TestEvent1
+	b.Color = "#1e90ff"
+
+	assert.NotEqual(t, a.Sha256(), b.Sha256())
+}
+
+func Test_ValidateRejectsOverLengthText(t *testing.T) {
+	/* GIVEN events with title, info or address one character over the limit
+	 * WHEN they are validated
+	 * THEN error should name the offending field
+	 * AND text exactly at the limit should be accepted
+	 */
+	t.Parallel()
+
+	cfg := config.Default()
+	cfg.MaxTitleLength = 10
+	cfg.MaxInfoLength = 20
+	cfg.MaxAddressLength = 30
+
+	for _, tc := range []struct {
+		field  string
+		modify func(e *EventData, length int)
+		limit  int
+	}{
+		{"title", func(e *EventData, length int) { e.Title = strings.Repeat("ł", length) }, cfg.MaxTitleLength},
+		{"info", func(e *EventData, length int) { e.Info = strings.Repeat("i", length) }, cfg.MaxInfoLength},
+		{"address", func(e *EventData, length int) { e.Address = strings.Repeat("a", length) }, cfg.MaxAddressLength},
+	} {
+		e := TestEvent1
+		e.Title, e.Info, e.Address = "", "", ""
+
+		tc.modify(&e, tc.limit)
+		assert.NoError(t, e.Validate(cfg), tc.field)
+
+		tc.modify(&e, tc.limit+1)
+
+		err := e.Validate(cfg)
+		assert.Error(t, err, tc.field)
+
+		if err != nil {
+			assert.Contains(t, err.Error(), tc.field)
+		}
+	}
+}
+
+func Test_ValidateRejectsSourcesOutsideAllowlist(t *testing.T) {
+	/* GIVEN configuration with an allowlist of sources
+	 * WHEN new events with allowed and unknown source are validated
+	 * THEN the allowed one should pass
+	 * AND the unknown one should fail with ErrInvalidEvent
+	 * AND source should not be checked for stored events nor with empty allowlist
+	 */
+	t.Parallel()
+
+	cfg := config.Default()
+	cfg.AllowedSources = config.ParseSources("APP,ICAL,WEB,XML")
+
+	e := TestEvent1
+	e.Source = "XML"
+	assert.NoError(t, e.ValidateNew(cfg))
+
+	e.Source = "XLM"
+	err := e.ValidateNew(cfg)
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+
+	if err != nil {
+		assert.Contains(t, err.Error(), "source")
+	}
+
+	assert.NoError(t, e.Validate(cfg))
+
+	cfg.AllowedSources = nil
+	assert.NoError(t, e.ValidateNew(cfg))
+}
+
+func Test_ValidateRejectsCiphertextLookalikes(t *testing.T) {
+	/* GIVEN events with info or address starting with EncryptedFieldPrefix
+	 * WHEN they are validated
+	 * THEN error should name the offending field, regardless of encryption settings
+	 */
+	t.Parallel()
+
+	for _, field := range []string{"info", "address"} {
+		e := TestEvent1
+
+		if field == "info" {
+			e.Info = EncryptedFieldPrefix + "typed by user"
+		} else {
+			e.Address = EncryptedFieldPrefix + "typed by user"
+		}
+
+		err := e.Validate(config.Default())
+		assert.ErrorIs(t, err, ErrInvalidEvent, field)
+
+		if err != nil {
+			assert.Contains(t, err.Error(), field)
+		}
+	}
+}
+
+func Test_ContentHashIgnoresIdentity(t *testing.T) {
+	/* GIVEN copies of an event with different UUID, version and flags
+	 * WHEN their content hashes are computed
+	 * THEN hashes should be equal
+	 * AND change of title, time or address should change the hash
+	 */
+	t.Parallel()
+
+	copied := TestEvent1
+	copied.UUID = "0123456789abcdef0123456789abcdef"
+	copied.Version = "2.0.0"
+	copied.Done = !copied.Done
+	copied.Start.Common = Common{}
+
+	assert.Equal(t, TestEvent1.ContentHash(), copied.ContentHash())
+	assert.NotEqual(t, TestEvent1.Sha256(), copied.Sha256())
+
+	for _, modify := range []func(e *EventData){
+		func(e *EventData) { e.Title += "!" },
+		func(e *EventData) { e.Start.Minute++ },
+		func(e *EventData) { e.End.Hour++ },
+		func(e *EventData) { e.Address = "Kraków" },
+	} {
+		changed := TestEvent1
+		modify(&changed)
+
+		assert.NotEqual(t, TestEvent1.ContentHash(), changed.ContentHash())
+	}
+}
@@ -6,9 +6,20 @@ package v1rest
 
 import (
 	"database/sql"
+	"errors"
+	"eventshub/config"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+)
 
-	"golang.org/x/crypto/bcrypt"
+// Database backends, each of them stores booleans differently.
+const (
+	BackendPostgres string = "postgres"
+	BackendSQLite   string = "sqlite3"
 )
 
 func Btoi(b bool) int {
@@ -19,31 +30,133 @@ func Btoi(b bool) int {
 	return 0
 }
 
-func convertRawEventRecordToEventData(r *sql.Rows) (EventData, error) {
-	/* Convert SQL row data into EventData structure */
+func encodeBool(backend string, b bool) any {
+	/* Encode boolean for storage. SQLite has no boolean type and stores 0 or 1,
+	 * other backends receive native boolean.
+	 */
+	if backend == BackendSQLite {
+		return Btoi(b)
+	}
+
+	return b
+}
+
+func decodeBool(backend string, v any) (bool, error) {
+	/* Decode boolean column scanned into `any`. Drivers return bool for BOOLEAN columns,
+	 * int64 for INTEGER ones and text for anything else, e.g. `t` or `1`.
+	 */
+	switch value := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return value, nil
+	case int64:
+		return value != 0, nil
+	case []byte:
+		return strconv.ParseBool(string(value))
+	case string:
+		return strconv.ParseBool(value)
+	default:
+		return false, fmt.Errorf("unsupported %s boolean value %v of type %T", backend, v, v)
+	}
+}
+
+func escapeLike(s string) string {
+	/* Escape LIKE wildcards, so the value is matched literally with ESCAPE '\' */
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func convertRawEventRecordToEventData(r *sql.Rows, backend, timeZone string) (EventData, error) {
+	/* Convert SQL row data of given backend into EventData structure */
 	var (
-		e  EventData
-		t1 int64
-		t2 int64
+		e         EventData
+		t1        int64
+		t2        int64
+		done      any
+		important any
+		urgent    any
+		err       error
 	)
 
-	if err := r.Scan(&e.ID, &e.Version, &e.UUID, &e.Title,
+	if err = r.Scan(&e.ID, &e.Version, &e.UUID, &e.Title,
 		&t1, &t2, &e.Address, &e.Info, &e.Reminder,
-		&e.Done, &e.Important, &e.Urgent, &e.Source); err != nil {
+		&done, &important, &urgent, &e.Source, &e.Category, &e.Color); err != nil {
+		return e, err
+	}
+
+	if e.Done, err = decodeBool(backend, done); err != nil {
+		return e, err
+	}
+
+	if e.Important, err = decodeBool(backend, important); err != nil {
+		return e, err
+	}
+
+	if e.Urgent, err = decodeBool(backend, urgent); err != nil {
 		return e, err
 	}
 
 	e.Type = EventDataStructName
-	e.Start, _ = unixToDateTime(&t1)
-	e.End, _ = unixToDateTime(&t2)
+	e.Start, _ = unixToDateTime(&t1, timeZone)
+	e.End, _ = unixToDateTime(&t2, timeZone)
 
 	return e, nil
 }
 
-func dateTimeToUnix(d *DateTime) (int64, error) {
-	/* Convert DateTime object value to Unix time */
-	timeZone := "Europe/Warsaw"
+func eventsMissingFrom(events, other []EventData) []EventData {
+	/* Return events which UUID is not present in other, preserving order */
+	present := make(map[string]bool, len(other))
+	for _, e := range other {
+		present[e.UUID] = true
+	}
 
+	result := []EventData{}
+
+	for _, e := range events {
+		if !present[e.UUID] {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
+
+func rangeExceedsDays(start, end int64, days int) bool {
+	/* Report whether the time range between Unix times start and end spans more than days */
+	return end-start > int64(days)*int64((24*time.Hour)/time.Second)
+}
+
+func shortcutRange(shortcut string, now time.Time, loc *time.Location) (int64, int64, error) {
+	/* Return inclusive Unix bounds of the time range named by shortcut, relative to now
+	 * in provided location. Bounds are computed from calendar dates, so days affected by
+	 * DST changes are 23 or 25 hours long. Weeks start on Monday.
+	 */
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	var start, end time.Time
+
+	switch shortcut {
+	case ShortcutToday:
+		start, end = today, today.AddDate(0, 0, 1)
+	case ShortcutTomorrow:
+		start, end = today.AddDate(0, 0, 1), today.AddDate(0, 0, 2)
+	case ShortcutThisWeek:
+		start = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		end = start.AddDate(0, 0, 7)
+	case ShortcutThisMonth:
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 1, 0)
+	default:
+		return 0, 0, fmt.Errorf("%w: %q", ErrUnknownShortcut, shortcut)
+	}
+
+	/* GetEventsByTimeRange treats end as inclusive, so stop right before the next range begins */
+	return start.Unix(), end.Unix() - 1, nil
+}
+
+func dateTimeToUnix(d *DateTime, timeZone string) (int64, error) {
+	/* Convert DateTime object value to Unix time in provided time zone */
 	loc, err := time.LoadLocation(timeZone)
 	if err != nil {
 		return 0, err
@@ -53,10 +166,8 @@ func dateTimeToUnix(d *DateTime) (int64, error) {
 }
 
 //nolint:gosec // Only integers used for date are for conversion so no integer overflow possible
-func unixToDateTime(d *int64) (DateTime, error) {
-	/* Convert Unix time to DateTime object*/
-	timeZone := "Europe/Warsaw"
-
+func unixToDateTime(d *int64, timeZone string) (DateTime, error) {
+	/* Convert Unix time to DateTime object in provided time zone */
 	loc, err := time.LoadLocation(timeZone)
 	if err != nil {
 		return DateTime{
@@ -78,16 +189,72 @@ func unixToDateTime(d *int64) (DateTime, error) {
 	}, nil
 }
 
-func hashPassword(plainPassword string) (string, error) {
-	/* Generate a hash of a password */
-	hash, err := bcrypt.GenerateFromPassword([]byte(plainPassword), bcrypt.DefaultCost)
+func validatePasswordStrength(plainPassword string, cfg *config.Config) error {
+	/* Check password against configured policy, list all unmet requirements */
+	var (
+		unmet               []string
+		lower, upper, digit bool
+	)
+
+	if len([]rune(plainPassword)) < cfg.PasswordMinLength {
+		unmet = append(unmet, fmt.Sprintf("at least %d characters", cfg.PasswordMinLength))
+	}
+
+	if cfg.PasswordMixedClass {
+		for _, c := range plainPassword {
+			lower = lower || unicode.IsLower(c)
+			upper = upper || unicode.IsUpper(c)
+			digit = digit || unicode.IsDigit(c)
+		}
 
-	return string(hash), err
+		if !lower {
+			unmet = append(unmet, "a lowercase letter")
+		}
+
+		if !upper {
+			unmet = append(unmet, "an uppercase letter")
+		}
+
+		if !digit {
+			unmet = append(unmet, "a digit")
+		}
+	}
+
+	if len(unmet) > 0 {
+		return errors.New("password does not meet requirements: " + strings.Join(unmet, ", "))
+	}
+
+	return nil
 }
 
-func checkPasswordHash(plainPassword, hash string) bool {
-	/* Compare if provided plain text password matches with stored hash */
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(plainPassword))
+func readBuildInfo() (string, BuildVCS) {
+	/* Read main module path and VCS settings stamped by `go build`, test binaries and
+	 * builds outside of a checkout carry none of them.
+	 */
+	module := "unknown"
+	vcs := BuildVCS{System: "unknown", Revision: "unknown", Time: "unknown"}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return module, vcs
+	}
+
+	if info.Main.Path != "" {
+		module = info.Main.Path
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs":
+			vcs.System = setting.Value
+		case "vcs.revision":
+			vcs.Revision = setting.Value
+		case "vcs.time":
+			vcs.Time = setting.Value
+		case "vcs.modified":
+			vcs.Modified = setting.Value == "true"
+		}
+	}
 
-	return err == nil
+	return module, vcs
 }
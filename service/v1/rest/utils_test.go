@@ -5,7 +5,10 @@ package v1rest
 // Created: August 18, 2024
 
 import (
+	"database/sql/driver"
+	"eventshub/config"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -22,6 +25,39 @@ func Test_BoolToIntConversion(t *testing.T) {
 	assert.Equal(t, Btoi(false), 0)
 }
 
+func Test_BooleansRoundTripPerBackend(t *testing.T) {
+	/* GIVEN every combination of done, important and urgent flags
+	 * WHEN they are encoded for a backend and converted the way database driver does
+	 * THEN decoding should return initial flags for every backend
+	 * AND textual representations returned by drivers should be decoded too
+	 */
+	t.Parallel()
+
+	for _, backend := range []string{BackendSQLite, BackendPostgres} {
+		for combination := 0; combination < 8; combination++ {
+			flags := []bool{combination&1 != 0, combination&2 != 0, combination&4 != 0}
+
+			for _, flag := range flags {
+				stored, err := driver.DefaultParameterConverter.ConvertValue(encodeBool(backend, flag))
+				assert.NoError(t, err)
+
+				decoded, err := decodeBool(backend, stored)
+				assert.NoError(t, err)
+				assert.Equal(t, flag, decoded, backend)
+			}
+		}
+
+		for raw, expected := range map[any]bool{"t": true, "f": false, "1": true, "0": false, nil: false} {
+			decoded, err := decodeBool(backend, raw)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, decoded, backend)
+		}
+
+		_, err := decodeBool(backend, 1.5)
+		assert.Error(t, err)
+	}
+}
+
 func Test_TimeConversionFromDateTimeToUnixAndViceVersa(t *testing.T) {
 	/* GIVEN a DateTime object sample
 	 * WHEN it is converted to Unix time
@@ -32,11 +68,11 @@ func Test_TimeConversionFromDateTimeToUnixAndViceVersa(t *testing.T) {
 
 	initialSample := DateTime{Common{Type: DateTimeStructName}, 2024, 2, 29, 12, 0}
 
-	step, err := dateTimeToUnix(&initialSample)
+	step, err := dateTimeToUnix(&initialSample, config.DefaultTimeZone)
 
 	assert.NoError(t, err)
 
-	result, err := unixToDateTime(&step)
+	result, err := unixToDateTime(&step, config.DefaultTimeZone)
 
 	assert.NoError(t, err)
 	assert.Equal(t, result.Year, initialSample.Year)
@@ -45,3 +81,106 @@ func Test_TimeConversionFromDateTimeToUnixAndViceVersa(t *testing.T) {
 	assert.Equal(t, result.Hour, initialSample.Hour)
 	assert.Equal(t, result.Minute, initialSample.Minute)
 }
+
+func Test_EscapeLikeWildcards(t *testing.T) {
+	/* GIVEN a value with LIKE wildcards and escape character
+	 * WHEN it is escaped
+	 * THEN all of them should be prefixed with escape character
+	 */
+	t.Parallel()
+
+	assert.Equal(t, "e0b2", escapeLike("e0b2"))
+	assert.Equal(t, `e0\%b\_2\\`, escapeLike(`e0%b_2\`))
+}
+
+func Test_ShortcutRangeAroundBoundaries(t *testing.T) {
+	/* GIVEN Warsaw time on Sunday, March 31 2024, the last day of a week and a month
+	 * and the day clocks are moved forward
+	 * WHEN every shortcut range is computed
+	 * THEN ranges should start at local midnights, with today lasting 23 hours
+	 * AND ranges computed on the following Monday should start a new week and month
+	 * AND unknown shortcuts should be rejected
+	 */
+	t.Parallel()
+
+	loc, err := time.LoadLocation("Europe/Warsaw")
+	assert.NoError(t, err)
+
+	midnight := func(year int, month time.Month, day int) int64 {
+		return time.Date(year, month, day, 0, 0, 0, 0, loc).Unix()
+	}
+
+	sunday := time.Date(2024, 3, 31, 23, 30, 0, 0, loc)
+	monday := time.Date(2024, 4, 1, 0, 30, 0, 0, loc)
+
+	for _, tc := range []struct {
+		shortcut   string
+		now        time.Time
+		start, end int64
+	}{
+		{ShortcutToday, sunday, midnight(2024, 3, 31), midnight(2024, 4, 1) - 1},
+		{ShortcutTomorrow, sunday, midnight(2024, 4, 1), midnight(2024, 4, 2) - 1},
+		{ShortcutThisWeek, sunday, midnight(2024, 3, 25), midnight(2024, 4, 1) - 1},
+		{ShortcutThisMonth, sunday, midnight(2024, 3, 1), midnight(2024, 4, 1) - 1},
+		{ShortcutToday, monday, midnight(2024, 4, 1), midnight(2024, 4, 2) - 1},
+		{ShortcutThisWeek, monday, midnight(2024, 4, 1), midnight(2024, 4, 8) - 1},
+		{ShortcutThisMonth, monday, midnight(2024, 4, 1), midnight(2024, 5, 1) - 1},
+	} {
+		start, end, err := shortcutRange(tc.shortcut, tc.now, loc)
+		assert.NoError(t, err, tc.shortcut)
+		assert.Equal(t, tc.start, start, tc.shortcut)
+		assert.Equal(t, tc.end, end, tc.shortcut)
+	}
+
+	start, end, err := shortcutRange(ShortcutToday, sunday, loc)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(23*60*60-1), end-start)
+
+	_, _, err = shortcutRange("yesterday", sunday, loc)
+	assert.ErrorIs(t, err, ErrUnknownShortcut)
+}
+
+func Test_PasswordStrengthValidation(t *testing.T) {
+	/* GIVEN a password policy
+	 * WHEN a password is validated
+	 * THEN passwords meeting the policy should be accepted
+	 * AND error should list every unmet requirement otherwise
+	 */
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		password   string
+		mixedClass bool
+		unmet      []string
+	}{
+		{"long enough", "correcthorse", false, nil},
+		{"too short", "a", false, []string{"at least 8 characters"}},
+		{"mixed classes", "Correct1horse", true, nil},
+		{"missing upper and digit", "correcthorse", true, []string{"an uppercase letter", "a digit"}},
+		{"missing everything", "!", true, []string{"at least 8 characters", "a lowercase letter", "an uppercase letter", "a digit"}},
+		{"multibyte length", "Łódźźźźź", false, nil},
+	} {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := config.Default()
+			cfg.PasswordMixedClass = tc.mixedClass
+
+			err := validatePasswordStrength(tc.password, cfg)
+
+			if tc.unmet == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Error(t, err)
+
+			for _, requirement := range tc.unmet {
+				assert.ErrorContains(t, err, requirement)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket framing, control frames and close handshake are left to gorilla/websocket,
+// which also answers malformed frames (1002) and messages over the read limit (1009).
+// Client has to send something, at least a pong to server pings, within read timeout,
+// otherwise the connection is dropped.
+
+// wsConn is a WebSocket connection whose messages may be written concurrently,
+// but read by a single goroutine only.
+type wsConn struct {
+	conn        *websocket.Conn
+	mu          sync.Mutex
+	readTimeout time.Duration
+}
+
+func (srv *HTTPRestServer) upgradeWebSocket(w http.ResponseWriter, r *http.Request, readTimeout time.Duration) (*wsConn, error) {
+	/* Validate handshake request and switch protocols, failed handshake is answered with ErrorResp.
+	 * Client has to send a frame at least every readTimeout, zero means no limit.
+	 * Browsers are allowed from the same origin only, the API does not support CORS either.
+	 */
+	upgrader := websocket.Upgrader{
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			srv.sendWithStatus(ErrorResp{
+				Common:    Common{Type: ErrorRespName},
+				RequestID: requestID(r),
+				Status:    ResponseStatus{Common: Common{Type: ResponseStatusName}, Success: false, Message: reason.Error()},
+			}, status, w, r)
+		},
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &wsConn{conn: conn, readTimeout: readTimeout}
+
+	conn.SetReadLimit(int64(WebSocketMaxMessageSize))
+	ws.extendReadDeadline()
+
+	/* Control frames count as activity too, pings are still answered with pongs */
+	conn.SetPongHandler(func(string) error {
+		ws.extendReadDeadline()
+		return nil
+	})
+
+	conn.SetPingHandler(func(data string) error {
+		ws.extendReadDeadline()
+
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(2*EventStreamKeepAlive))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+
+		return err
+	})
+
+	return ws, nil
+}
+
+func (c *wsConn) extendReadDeadline() {
+	/* Give client another readTimeout to send next frame, half-open connections time out */
+	if c.readTimeout > 0 {
+		_ = c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+	}
+}
+
+// ReadMessage returns payload of the next data message. Errors are final, the library
+// has already answered close, malformed and oversized frames by then.
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	_, message, err := c.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	c.extendReadDeadline()
+
+	return message, nil
+}
+
+// WriteText sends payload as a single text message.
+func (c *wsConn) WriteText(payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(2 * EventStreamKeepAlive))
+
+	return c.conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+// Ping sends ping control frame, client answers it with pong.
+func (c *wsConn) Ping() error {
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(2*EventStreamKeepAlive))
+}
+
+// Close sends close frame with the code, unless it is zero, and closes the connection.
+func (c *wsConn) Close(code int) error {
+	if code != 0 {
+		_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""),
+			time.Now().Add(2*EventStreamKeepAlive))
+	}
+
+	return c.conn.Close()
+}
@@ -0,0 +1,216 @@
+package v1rest
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"encoding/json"
+	"errors"
+	"eventshub/config"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// dialWebSocket connects to the WebSocket endpoint of srv with token in query.
+func dialWebSocket(t *testing.T, srv *HTTPRestServer, token string) *websocket.Conn {
+	server := httptest.NewServer(http.HandlerFunc(srv.websocket))
+	t.Cleanup(server.Close)
+
+	conn, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/v1/ws?token="+token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func Test_WebSocketExchangesMessages(t *testing.T) {
+	/* GIVEN a client connected to the WebSocket endpoint with token in query
+	 * WHEN it sends AddEventReq message
+	 * THEN it should receive AddEventResp of the insert
+	 * AND EventChangeMsg of the inserted event
+	 * AND its close should be answered with close
+	 */
+	srv, token := newTestServer(t)
+	srv.bus = NewEventBus()
+	srv.db.(*SQLiteRepository).bus = srv.bus
+
+	conn := dialWebSocket(t, srv, token)
+
+	assert.NoError(t, conn.WriteJSON(AddEventReq{Event: EventData{UUID: "a6000000000000000000000000000001", Title: "Over WebSocket"}}))
+
+	received := map[string]json.RawMessage{}
+
+	for len(received) < 2 {
+		var msg Common
+
+		messageType, payload, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, websocket.TextMessage, messageType)
+		assert.NoError(t, json.Unmarshal(payload, &msg))
+		received[msg.Type] = payload
+	}
+
+	var (
+		resp   AddEventResp
+		change EventChangeMsg
+	)
+
+	assert.NoError(t, json.Unmarshal(received[AddEventRespName], &resp))
+	assert.True(t, resp.Status.Success)
+
+	assert.NoError(t, json.Unmarshal(received[EventChangeMsgName], &change))
+	assert.Equal(t, EventChange{UUID: "a6000000000000000000000000000001", Action: AuditActionInsert}, change.EventChange)
+
+	assert.NoError(t, conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")))
+
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), err)
+}
+
+func Test_WebSocketClosesOnAbuse(t *testing.T) {
+	/* GIVEN clients connected to the WebSocket endpoint
+	 * WHEN one sends a message over WebSocketMaxMessageSize
+	 *   and other more than WebSocketMaxMessageRate messages at once
+	 * THEN the first should be closed with 1009 and the second with 1008
+	 */
+	srv, token := newTestServer(t)
+	srv.bus = NewEventBus()
+
+	conn := dialWebSocket(t, srv, token)
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", WebSocketMaxMessageSize+1))))
+
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), err)
+
+	conn = dialWebSocket(t, srv, token)
+
+	for i := 0; i <= WebSocketMaxMessageRate; i++ {
+		assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{}")))
+	}
+
+	for {
+		if _, _, err = conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err)
+}
+
+func Test_WebSocketRejectsFailedHandshake(t *testing.T) {
+	/* GIVEN a request with valid token but without WebSocket upgrade headers
+	 * WHEN it is sent to the WebSocket endpoint
+	 * THEN it should be rejected with ErrorResp
+	 */
+	srv, token := newTestServer(t)
+	srv.bus = NewEventBus()
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/ws?token="+token, nil)
+	w := httptest.NewRecorder()
+
+	srv.websocket(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), ErrorRespName)
+}
+
+func Test_WebSocketReadDeadlineIsExtendedByPongs(t *testing.T) {
+	/* GIVEN a WebSocket connection with 100ms read timeout
+	 * WHEN client answers with pongs for longer than the timeout and then sends a message
+	 * THEN the message should be read
+	 * AND once client goes silent reading should time out
+	 */
+	srv, _ := newTestServer(t)
+
+	errs := make(chan error, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sut, err := srv.upgradeWebSocket(w, r, 100*time.Millisecond)
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		defer sut.Close(0)
+
+		message, err := sut.ReadMessage()
+		if err == nil && string(message) != "{}" {
+			t.Errorf("unexpected message %q", message)
+		}
+
+		errs <- err
+
+		_, err = sut.ReadMessage()
+		errs <- err
+	}))
+	defer server.Close()
+
+	conn, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+	defer conn.Close()
+
+	for i := 0; i < 6; i++ {
+		time.Sleep(40 * time.Millisecond)
+		assert.NoError(t, conn.WriteControl(websocket.PongMessage, nil, time.Now().Add(time.Second)))
+	}
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{}")))
+
+	assert.NoError(t, <-errs)
+
+	var timeout net.Error
+
+	err = <-errs
+	assert.True(t, errors.As(err, &timeout) && timeout.Timeout(), err)
+}
+
+func Test_WebSocketMessagesFollowCompatMode(t *testing.T) {
+	/* GIVEN a server in legacy compatibility mode
+	 * WHEN a client connected to the WebSocket endpoint adds an event
+	 * THEN neither the response nor the change message should carry `__type__` or `__version__`
+	 */
+	srv, token := newTestServer(t)
+	srv.cfg.Compat = config.CompatLegacy
+	srv.bus = NewEventBus()
+	srv.db.(*SQLiteRepository).bus = srv.bus
+
+	conn := dialWebSocket(t, srv, token)
+
+	assert.NoError(t, conn.WriteJSON(AddEventReq{Event: EventData{UUID: "a6000000000000000000000000000002", Title: "Legacy"}}))
+
+	for i := 0; i < 2; i++ {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NotContains(t, string(payload), `"__type__"`)
+		assert.NotContains(t, string(payload), `"__version__"`)
+
+		if strings.Contains(string(payload), `"action"`) {
+			assert.Contains(t, string(payload), `"uuid":"a6000000000000000000000000000002"`)
+		} else {
+			assert.Contains(t, string(payload), `"success":true`)
+		}
+	}
+}
@@ -10,25 +10,29 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
+	appconfig "eventshub/config"
 	logger "eventshub/logging"
 	v1rest "eventshub/service/v1/rest"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 )
 
 type XMLEventsParser struct {
-	config Config
-	log    *logger.ConsoleLogger
-	token  string
+	config   Config
+	settings *appconfig.Config
+	log      *logger.ConsoleLogger
+	token    string
+	tokenMu  sync.RWMutex
 }
 
-func NewXMLEventsParser(config_path string, logging_lvl int) XMLEventsParser {
+func NewXMLEventsParser(config_path string, logging_lvl int, settings *appconfig.Config) XMLEventsParser {
 	var (
-		config Config
+		config = defaultConfig()
 		log    *logger.ConsoleLogger
 	)
 	log = logger.NewConsoleLogger("XMLParser", logging_lvl)
@@ -48,17 +52,24 @@ func NewXMLEventsParser(config_path string, logging_lvl int) XMLEventsParser {
 		panic(err)
 	}
 
+	err = config.validate()
+	if err != nil {
+		log.Critical("Invalid configuration: ", err)
+		panic(err)
+	}
+
 	return XMLEventsParser{
-		config: config,
-		log:    log,
-		token:  "",
+		config:   config,
+		settings: settings,
+		log:      log,
+		token:    "",
 	}
 }
 
 func (parser *XMLEventsParser) getTransportConfiguration() (*http.Transport, error) {
 	/* Prepare request transport configuration */
 
-	caCert, err := os.ReadFile(os.Getenv("GOCALENDAR_OPENSSL_CA_CERTIFICATE"))
+	caCert, err := os.ReadFile(parser.settings.CACertificatePath)
 	if err != nil {
 		return nil, err
 	}
@@ -71,80 +82,161 @@ func (parser *XMLEventsParser) getTransportConfiguration() (*http.Transport, err
 	}
 
 	transport := &http.Transport{
-		TLSClientConfig: tlsConfig,
-		MaxIdleConns:    10,
-		IdleConnTimeout: 30 * time.Second,
+		TLSClientConfig:     tlsConfig,
+		MaxIdleConns:        parser.config.MaxIdleConns,
+		MaxIdleConnsPerHost: parser.config.MaxIdleConnsPerHost,
+		IdleConnTimeout:     time.Duration(parser.config.IdleConnTimeoutSeconds) * time.Second,
 	}
 	return transport, nil
 }
 
-func (parser *XMLEventsParser) getToken() {
-	/* Login and get JWT */
+func (parser *XMLEventsParser) getToken() error {
+	/* Login and get JWT, errors are returned to the upload worker which needed the token */
 	parser.log.Info("Begin requesting the token.")
-	url := fmt.Sprintf("https://%s:%d/api/v1/login", parser.config.Host, parser.config.Port)
+	url := fmt.Sprintf("https://%s:%d%s/login", parser.config.Host, parser.config.Port, parser.settings.PathPrefix)
 
 	var (
-		err       error
 		token_msg v1rest.TokenMsg
 		user      v1rest.User = v1rest.User{
-			Username: os.Getenv("GOCALENDAR_ADMIN_USERNAME"),
-			Password: os.Getenv("GOCALENDAR_ADMIN_PASSWORD"),
+			Username: parser.settings.AdminUsername,
+			Password: parser.settings.AdminPassword,
 		}
 	)
 
 	if user.Username == "" || user.Password == "" {
 		parser.log.Critical("Missing user data.")
+		return errors.New("missing user data")
 	}
 
 	userData, err := json.Marshal(&user)
 	if err != nil {
-		parser.log.Error(err)
+		return err
 	}
 
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(userData))
 	if err != nil {
-		parser.log.Error(err)
+		return err
 	}
 
+	req.Header.Set("Content-Type", "application/json")
+
 	transport, err := parser.getTransportConfiguration()
 	if err != nil {
-		parser.log.Error(err)
+		return err
 	}
 
 	client := &http.Client{Transport: transport}
 	resp, err := client.Do(req)
 	if err != nil {
-		parser.log.Error(err)
+		return err
 	}
 	defer resp.Body.Close()
 
 	responseData, err := io.ReadAll(resp.Body)
 	if err != nil {
-		parser.log.Error(err)
+		return err
 	}
 
 	err = json.Unmarshal(responseData, &token_msg)
 	if err != nil {
-		parser.log.Error(err)
+		return fmt.Errorf("malformed login response: %w", err)
+	}
+
+	if token_msg.Token == "" {
+		return fmt.Errorf("login failed with status %d", resp.StatusCode)
 	}
 
 	parser.log.Info("Successfully obtained the token.")
+	parser.tokenMu.Lock()
 	parser.token = token_msg.Token
+	parser.tokenMu.Unlock()
+
+	return nil
 }
 
-func (parser *XMLEventsParser) postEvent(e v1rest.EventData) {
-	url := fmt.Sprintf("https://%s:%d/api/v1/insertEvent", parser.config.Host, parser.config.Port)
+func (parser *XMLEventsParser) currentToken() string {
+	/* Token shared by upload workers, refreshed by whichever of them sees it expired */
+	parser.tokenMu.RLock()
+	defer parser.tokenMu.RUnlock()
+
+	return parser.token
+}
+
+func (parser *XMLEventsParser) postEvent(client *http.Client, e v1rest.EventData) error {
+	/* Post single event, returns nil when server stores it or already has the same content */
+	url := fmt.Sprintf("https://%s:%d%s/insertEvent", parser.config.Host, parser.config.Port, parser.settings.PathPrefix)
 
 	addEventReq := v1rest.AddEventReq{Event: e}
 	data, err := json.Marshal(addEventReq)
 	if err != nil {
-		parser.log.Error(err)
-		return
+		return err
 	}
 
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(data))
-	req.Header.Set("Token", parser.token)
-	req.Header.Set("Content-Type", "application/json")
+	throttled := 0
+
+	/* Token is refreshed and request repeated only when the server reports expiry,
+	 * invalid or missing token will not become valid by retrying. */
+	for attempt := 1; attempt <= PostEventAttempts; attempt++ {
+		req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Token", parser.currentToken())
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+
+		status := resp.StatusCode
+		expired := status == http.StatusUnauthorized && isTokenExpired(resp.Body)
+		resp.Body.Close()
+
+		switch {
+		case status == http.StatusOK:
+			parser.log.Debug("Successfully added event with UUID ", e.UUID)
+			return nil
+		case status == http.StatusConflict:
+			parser.log.Info("Skipped event with UUID ", e.UUID, ", server already stores event with the same content.")
+			return nil
+		case status == http.StatusTooManyRequests && throttled < RateLimitRetries:
+			/* Back off exponentially unless server says how long to wait,
+			 * being rate limited does not use up an attempt. */
+			wait := retryAfter(resp.Header.Get("Retry-After"), RateLimitBackoff<<throttled)
+			throttled++
+			attempt--
+
+			parser.log.Info("Rate limited, retrying event with UUID ", e.UUID, " in ", wait)
+			time.Sleep(wait)
+		case expired:
+			parser.log.Info("Token expired. Refreshing token.")
+
+			if err = parser.getToken(); err != nil {
+				return fmt.Errorf("failed to refresh token: %w", err)
+			}
+		case status == http.StatusUnauthorized:
+			return errors.New("token rejected by server")
+		default:
+			return fmt.Errorf("server responded with status %d", status)
+		}
+	}
+
+	return fmt.Errorf("not added after %d attempts", PostEventAttempts)
+}
+
+// UploadStoredEvents posts events from all configured source files using
+// config.Concurrency workers sharing one client, and returns how many events
+// the server accepted and how many failed. Workers report the outcome of every
+// event on a results channel, so a failing event never stops the upload.
+func (parser *XMLEventsParser) UploadStoredEvents() (posted, failed int) {
+	var (
+		events  = make(chan v1rest.EventData)
+		results = make(chan uploadResult)
+		wg      sync.WaitGroup
+		skipped int
+	)
 
 	transport, err := parser.getTransportConfiguration()
 	if err != nil {
@@ -153,45 +245,88 @@ func (parser *XMLEventsParser) postEvent(e v1rest.EventData) {
 	}
 
 	client := &http.Client{Transport: transport}
-	resp, err := client.Do(req)
-	if err != nil {
-		parser.log.Error(err)
-		panic(err)
+
+	for i := 0; i < parser.config.Concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for e := range events {
+				results <- uploadResult{UUID: e.UUID, Err: parser.postEvent(client, e)}
+			}
+		}()
 	}
-	defer resp.Body.Close()
 
-	for retry := 3; retry <= 3; retry++ {
-		switch resp.StatusCode {
-		case http.StatusOK:
-			parser.log.Debug("Successfully added event with UUID ", e.UUID)
-		case http.StatusUnauthorized:
-			parser.getToken()
-			parser.log.Info("Unauthorized. Refreshing token.")
-		default:
-			parser.log.Info("Failed to add event with UUID ", e.UUID)
+	go func() {
+		skipped = parser.readStoredEvents(events)
+		close(events)
+	}()
+
+	/* Results are closed once every worker has drained the events, skipped is set by then */
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		if result.Err != nil {
+			parser.log.Error("Failed to add event with UUID ", result.UUID, ". ", result.Err)
+			failed++
+
+			continue
 		}
+
+		posted++
 	}
+
+	failed += skipped
+
+	parser.log.Info("Upload finished, ", posted, " events posted, ", failed, " failed.")
+
+	return posted, failed
 }
 
-func (parser *XMLEventsParser) UploadStoredEvents() {
+func (parser *XMLEventsParser) readStoredEvents(events chan<- v1rest.EventData) (skipped int) {
+	/* Feed events from all configured source files to upload workers,
+	 * events which can not be converted are skipped and counted,
+	 * files which can not be read or parsed are skipped as a whole.
+	 */
 	for _, path := range parser.config.Source_files_paths {
 		parser.log.Info("Reading data from ", path)
 		xmlFile, err := os.Open(path)
 		if err != nil {
-			log.Fatalf("%v", err)
+			parser.log.Error("Skipping ", path, ". ", err)
+			continue
 		}
-		defer xmlFile.Close()
 
-		byteValue, _ := io.ReadAll(xmlFile)
+		byteValue, err := v1rest.ReadImport(xmlFile, parser.settings.MaxImportBytes)
+		xmlFile.Close()
+
+		if err != nil {
+			parser.log.Error("Skipping ", path, ". ", err)
+			continue
+		}
 
 		var root Root
-		xml.Unmarshal(byteValue, &root)
+		if err = xml.Unmarshal(byteValue, &root); err != nil {
+			parser.log.Error("Skipping malformed ", path, ". ", err)
+			continue
+		}
 
 		parser.log.Debug("Uploading data from ", path)
 		for i := 0; i < len(root.Events); i++ {
-			e := xmlEventToEventDataConverter(root.Events[i])
-			parser.postEvent(e)
+			event, err := xmlEventToEventDataConverter(root.Events[i], parser.config.DateLayouts)
+			if err != nil {
+				parser.log.Error("Skipping event ", root.Events[i].UUID, " of ", path, ". ", err)
+				skipped++
+
+				continue
+			}
+
+			events <- event
 		}
 	}
 
+	return skipped
 }
@@ -0,0 +1,324 @@
+package xmlparser
+
+// Author: Sebastian Oleksiak (oscarsierraproject@protonmail.com)
+// License: The Unlicense
+// Created: October 16, 2026
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	appconfig "eventshub/config"
+	logger "eventshub/logging"
+	v1rest "eventshub/service/v1/rest"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTempFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func Test_TransportUsesConfiguredIdleConnections(t *testing.T) {
+	/* GIVEN a parser configuration with custom idle connection settings
+	 * WHEN transport configuration is built
+	 * THEN configured values should be set on the transport
+	 */
+	settings := appconfig.Default()
+	settings.CACertificatePath = writeTempFile(t, "ca.pem", "")
+
+	configPath := writeTempFile(t, "config.json", `{
+		"host": "localhost",
+		"port": 4789,
+		"max_idle_conns": 100,
+		"max_idle_conns_per_host": 50,
+		"idle_conn_timeout_seconds": 90
+	}`)
+
+	parser := NewXMLEventsParser(configPath, logger.ERROR, settings)
+
+	transport, err := parser.getTransportConfiguration()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 100, transport.MaxIdleConns)
+	assert.Equal(t, 50, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 90*time.Second, transport.IdleConnTimeout)
+}
+
+func Test_TransportUsesDefaultIdleConnections(t *testing.T) {
+	/* GIVEN a parser configuration without idle connection settings
+	 * WHEN transport configuration is built
+	 * THEN default values should be set on the transport
+	 */
+	settings := appconfig.Default()
+	settings.CACertificatePath = writeTempFile(t, "ca.pem", "")
+
+	configPath := writeTempFile(t, "config.json", `{"host": "localhost", "port": 4789}`)
+
+	parser := NewXMLEventsParser(configPath, logger.ERROR, settings)
+
+	transport, err := parser.getTransportConfiguration()
+
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultMaxIdleConns, transport.MaxIdleConns)
+	assert.Equal(t, DefaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, DefaultIdleConnTimeout, transport.IdleConnTimeout)
+}
+
+func Test_NegativeIdleConnectionsAreRejected(t *testing.T) {
+	/* GIVEN a parser configuration with negative idle connections
+	 * WHEN parser is created
+	 * THEN it should panic
+	 */
+	configPath := writeTempFile(t, "config.json", `{"host": "localhost", "port": 4789, "max_idle_conns": -1}`)
+
+	assert.Panics(t, func() {
+		NewXMLEventsParser(configPath, logger.ERROR, appconfig.Default())
+	})
+}
+
+func Test_OnlyExpiredTokenIsRecognized(t *testing.T) {
+	/* GIVEN 401 response bodies with different token error codes
+	 * WHEN they are checked for token expiry
+	 * THEN only the expired code should trigger token refresh
+	 */
+	for body, expected := range map[string]bool{
+		`{"__type__": "InvalidTokenResp", "code": "token_expired"}`: true,
+		`{"__type__": "InvalidTokenResp", "code": "token_invalid"}`: false,
+		`{"__type__": "InvalidTokenResp", "code": "token_missing"}`: false,
+		`not json`: false,
+	} {
+		assert.Equal(t, expected, isTokenExpired(strings.NewReader(body)), body)
+	}
+}
+
+func Test_ConcurrentUploadPostsAllEvents(t *testing.T) {
+	/* GIVEN a source file with many events and parser concurrency above one
+	 * AND a server rate limiting the first request
+	 * WHEN stored events are uploaded
+	 * THEN every event should be posted exactly once
+	 * AND no more requests than configured workers should be in flight
+	 */
+	const events, concurrency = 40, 4
+
+	var (
+		mu                  sync.Mutex
+		posted              = map[string]int{}
+		inFlight, maxFlight int
+		limited             bool
+	)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req v1rest.AddEventReq
+
+		mu.Lock()
+		if !limited {
+			limited = true
+			mu.Unlock()
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		inFlight++
+		if inFlight > maxFlight {
+			maxFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+
+		mu.Lock()
+		inFlight--
+		posted[req.Event.UUID]++
+		mu.Unlock()
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+
+	settings := appconfig.Default()
+	settings.CACertificatePath = writeTempFile(t, "ca.pem", string(pem.EncodeToMemory(
+		&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+
+	var xml strings.Builder
+
+	xml.WriteString("<root>")
+
+	for i := 0; i < events; i++ {
+		fmt.Fprintf(&xml, `<event ver="1" uuid="uuid-%d" start="2024-03-01 10:00" end="2024-03-01 11:00" `+
+			`remind="0" done="No" urgent="No" important="No" title="Event %d" address="" info=""/>`, i, i)
+	}
+
+	xml.WriteString("</root>")
+
+	sourcePath := writeTempFile(t, "events.xml", xml.String())
+	configPath := writeTempFile(t, "config.json", fmt.Sprintf(
+		`{"host": %q, "port": %s, "source_files_paths": [%q], "concurrency": %d}`,
+		serverURL.Hostname(), serverURL.Port(), sourcePath, concurrency))
+
+	parser := NewXMLEventsParser(configPath, logger.ERROR, settings)
+
+	ok, failed := parser.UploadStoredEvents()
+
+	assert.Equal(t, events, ok)
+	assert.Equal(t, 0, failed)
+	assert.Len(t, posted, events)
+
+	for uuid, count := range posted {
+		assert.Equal(t, 1, count, uuid)
+	}
+
+	assert.LessOrEqual(t, maxFlight, concurrency)
+}
+
+func Test_OversizedSourceFileIsSkipped(t *testing.T) {
+	/* GIVEN two source files, one exceeding the maximum import size
+	 * WHEN stored events are read
+	 * THEN only events of the file within the limit should be uploaded
+	 */
+	var uploaded []string
+
+	event := `<event ver="1" uuid="uuid-%d" start="2024-03-01 10:00" end="2024-03-01 11:00" ` +
+		`remind="0" done="No" urgent="No" important="No" title="Event %d" address="" info=""/>`
+
+	small := writeTempFile(t, "small.xml", "<root>"+fmt.Sprintf(event, 1, 1)+"</root>")
+	large := writeTempFile(t, "large.xml", "<root>"+fmt.Sprintf(event, 2, 2)+fmt.Sprintf(event, 3, 3)+"</root>")
+
+	settings := appconfig.Default()
+	settings.MaxImportBytes = 300
+
+	parser := XMLEventsParser{
+		config:   Config{Source_files_paths: []string{large, small}},
+		settings: settings,
+		log:      logger.NewConsoleLogger("TEST", logger.CRITICAL),
+	}
+
+	events := make(chan v1rest.EventData)
+	done := make(chan struct{})
+
+	go func() {
+		for e := range events {
+			uploaded = append(uploaded, e.UUID)
+		}
+
+		close(done)
+	}()
+
+	parser.readStoredEvents(events)
+	close(events)
+	<-done
+
+	assert.Equal(t, []string{"uuid-1"}, uploaded)
+}
+
+func Test_ConverterDefaultsMissingAttributes(t *testing.T) {
+	/* GIVEN exported events missing remind, done and address attributes
+	 * WHEN they are converted
+	 * THEN missing reminder should be zero, missing flag false and missing address empty
+	 * AND malformed attributes should fail with error naming the attribute
+	 */
+	var root Root
+
+	feed := `<root>
+		<event ver="1" uuid="no-remind" start="2024-03-01 10:00" end="2024-03-01 11:00" done="Yes" title="A" address="Home"/>
+		<event ver="1" uuid="no-done" start="2024-03-01 10:00" end="2024-03-01 11:00" remind="2" title="B" address="Home"/>
+		<event ver="1" uuid="no-address" start="2024-03-01 10:00" end="2024-03-01 11:00" remind="2" done="no" title="C"/>
+	</root>`
+
+	assert.NoError(t, xml.Unmarshal([]byte(feed), &root))
+
+	if assert.Len(t, root.Events, 3) {
+		event, err := xmlEventToEventDataConverter(root.Events[0], nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(0), event.Reminder)
+		assert.True(t, event.Done)
+
+		event, err = xmlEventToEventDataConverter(root.Events[1], nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), event.Reminder)
+		assert.False(t, event.Done)
+		assert.False(t, event.Urgent)
+
+		event, err = xmlEventToEventDataConverter(root.Events[2], nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "", event.Address)
+		assert.False(t, event.Done)
+	}
+
+	for attr, xe := range map[string]Event{
+		"remind": {UUID: "bad", Start: "2024-03-01 10:00", End: "2024-03-01 11:00", Remind: "soon"},
+		"urgent": {UUID: "bad", Start: "2024-03-01 10:00", End: "2024-03-01 11:00", Urgent: "maybe"},
+		"start":  {UUID: "bad", End: "2024-03-01 11:00"},
+	} {
+		_, err := xmlEventToEventDataConverter(xe, nil)
+		assert.True(t, errors.Is(err, v1rest.ErrFeedMalformed), attr)
+
+		if err != nil {
+			assert.Contains(t, err.Error(), "attribute "+attr, attr)
+		}
+	}
+}
+
+func Test_UploadReportsFailuresInsteadOfPanicking(t *testing.T) {
+	/* GIVEN a malformed source file and a valid one with two events
+	 * AND a server which is no longer reachable
+	 * WHEN stored events are uploaded
+	 * THEN the malformed file should be skipped
+	 * AND both events should be reported as failed without panicking the worker
+	 */
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+
+	settings := appconfig.Default()
+	settings.CACertificatePath = writeTempFile(t, "ca.pem", string(pem.EncodeToMemory(
+		&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+
+	server.Close()
+
+	event := `<event ver="1" uuid="uuid-%d" start="2024-03-01 10:00" end="2024-03-01 11:00" ` +
+		`remind="0" done="No" urgent="No" important="No" title="Event %d" address="" info=""/>`
+
+	malformed := writeTempFile(t, "malformed.xml", `<root><event ver="1" uuid=`)
+	valid := writeTempFile(t, "valid.xml", "<root>"+fmt.Sprintf(event, 1, 1)+fmt.Sprintf(event, 2, 2)+"</root>")
+
+	configPath := writeTempFile(t, "config.json", fmt.Sprintf(
+		`{"host": %q, "port": %s, "source_files_paths": [%q, %q], "concurrency": 2}`,
+		serverURL.Hostname(), serverURL.Port(), malformed, valid))
+
+	parser := NewXMLEventsParser(configPath, logger.CRITICAL, settings)
+
+	var ok, failed int
+
+	assert.NotPanics(t, func() { ok, failed = parser.UploadStoredEvents() })
+	assert.Equal(t, 0, ok)
+	assert.Equal(t, 2, failed)
+}
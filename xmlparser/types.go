@@ -4,30 +4,40 @@ package xmlparser
 // License: The Unlicense
 // Created: August 18, 2024
 
-import "encoding/xml"
+import (
+	v1rest "eventshub/service/v1/rest"
+	"time"
+)
 
-type Config struct {
-	Host               string   `json:"host"`
-	Port               int      `json:"port"`
-	Source_files_paths []string `json:"source_files_paths"`
-}
+const (
+	DefaultConcurrency         int           = 1
+	DefaultMaxIdleConns        int           = 10
+	DefaultMaxIdleConnsPerHost int           = 2
+	DefaultIdleConnTimeout     time.Duration = 30 * time.Second
+	PostEventAttempts          int           = 3
+	RateLimitRetries           int           = 5
+	RateLimitBackoff           time.Duration = 1 * time.Second
+)
 
-type Root struct {
-	XMLName xml.Name `xml:"root"`
-	Events  []Event  `xml:"event"`
+// uploadResult is the outcome of posting a single event, Err is nil once the server stored it.
+type uploadResult struct {
+	UUID string
+	Err  error
 }
 
-type Event struct {
-	XMLName   xml.Name `xml:"event"`
-	Version   string   `xml:"ver,attr"`
-	UUID      string   `xml:"uuid,attr"`
-	Start     string   `xml:"start,attr"`
-	End       string   `xml:"end,attr"`
-	Remind    string   `xml:"remind,attr"`
-	Done      string   `xml:"done,attr"`
-	Urgent    string   `xml:"urgent,attr"`
-	Important string   `xml:"important,attr"`
-	Title     string   `xml:"title,attr"`
-	Address   string   `xml:"address,attr"`
-	Info      string   `xml:"info,attr"`
+type Config struct {
+	Host                   string   `json:"host"`
+	Port                   int      `json:"port"`
+	Source_files_paths     []string `json:"source_files_paths"`
+	MaxIdleConns           int      `json:"max_idle_conns"`
+	MaxIdleConnsPerHost    int      `json:"max_idle_conns_per_host"`
+	IdleConnTimeoutSeconds int      `json:"idle_conn_timeout_seconds"`
+	Concurrency            int      `json:"concurrency"`
+	DateLayouts            []string `json:"date_layouts"`
 }
+
+// Root and Event describe the XML export, shared with the server's importFromURL.
+type (
+	Root  = v1rest.XMLFeed
+	Event = v1rest.XMLFeedEvent
+)
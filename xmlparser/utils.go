@@ -5,47 +5,67 @@ package xmlparser
 // Created: August 18, 2024
 
 import (
+	"encoding/json"
+	"errors"
 	v1rest "eventshub/service/v1/rest"
+	"io"
 	"strconv"
-	"strings"
+	"time"
 )
 
-func yesNoToBool(s string) bool {
-	return s == "Yes"
+func defaultConfig() Config {
+	/* Config with defaults for all optional settings */
+	return Config{
+		MaxIdleConns:           DefaultMaxIdleConns,
+		MaxIdleConnsPerHost:    DefaultMaxIdleConnsPerHost,
+		IdleConnTimeoutSeconds: int(DefaultIdleConnTimeout / time.Second),
+		Concurrency:            DefaultConcurrency,
+	}
 }
 
-func stringToDateTimeConverter(s string) v1rest.DateTime {
-	tmp := strings.Split(s, " ")
-	date := strings.Split(tmp[0], "-")
-	time := strings.Split(tmp[1], ":")
-	year, _ := strconv.Atoi(date[0])
-	month, _ := strconv.Atoi(date[1])
-	day, _ := strconv.Atoi(date[2])
-	hour, _ := strconv.Atoi(time[0])
-	minute, _ := strconv.Atoi(time[1])
-
-	dt := v1rest.DateTime{Year: int32(year), Month: int32(month), Day: int32(day), Hour: int32(hour), Minute: int32(minute)}
-	dt.Type = "datetime"
-	return dt
+func isTokenExpired(body io.Reader) bool {
+	/* Check if 401 response body reports expired token, as opposed to invalid one */
+	var resp v1rest.InvalidTokenResp
+
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return false
+	}
+
+	return resp.Code == v1rest.TokenExpiredCode
 }
 
-func xmlEventToEventDataConverter(xe Event) v1rest.EventData {
-	var event v1rest.EventData
-	event.Version = xe.Version
-	event.UUID = xe.UUID
-	event.Title = xe.Title
-	event.Start = stringToDateTimeConverter(xe.Start)
-	event.End = stringToDateTimeConverter(xe.End)
-	event.Address = xe.Address
-	event.Info = xe.Info
-	i, err := strconv.Atoi(xe.Remind)
-	if err != nil {
-		panic(err)
+func retryAfter(header string, fallback time.Duration) time.Duration {
+	/* Delay requested by Retry-After header in seconds, fallback when absent or malformed */
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return fallback
 	}
-	event.Reminder = int32(i)
-	event.Done = yesNoToBool(xe.Done)
-	event.Important = yesNoToBool(xe.Important)
-	event.Urgent = yesNoToBool(xe.Urgent)
-	event.Source = "XML"
-	return event
+
+	return time.Duration(seconds) * time.Second
+}
+
+func (c *Config) validate() error {
+	/* Check that connection settings have sane values */
+	if c.MaxIdleConns < 0 {
+		return errors.New("max_idle_conns must not be negative")
+	}
+
+	if c.MaxIdleConnsPerHost < 0 {
+		return errors.New("max_idle_conns_per_host must not be negative")
+	}
+
+	if c.IdleConnTimeoutSeconds < 0 {
+		return errors.New("idle_conn_timeout_seconds must not be negative")
+	}
+
+	if c.Concurrency < 1 {
+		return errors.New("concurrency must be positive")
+	}
+
+	return nil
+}
+
+func xmlEventToEventDataConverter(xe Event, layouts []string) (v1rest.EventData, error) {
+	/* Convert exported event, dates are tried with the default layout and then configured ones */
+	return xe.EventData(layouts)
 }